@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/output"
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// batchCommand runs every prompt in a file, one after another, rewriting the
+// results file after each prompt so an interrupted batch loses at most the
+// prompt in flight. With --resume, prompts that already succeeded in the
+// results file (matched by prompt hash) are skipped and failed ones run again.
+func batchCommand(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	outputPath := fs.String("output", "batch-results.jsonl", "results file, one JSON run per line (readable by devgru replay)")
+	resume := fs.Bool("resume", false, "continue an interrupted batch: skip prompts that already succeeded in --output")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: devgru batch [flags] FILE\n\nFILE holds one prompt per line; blank lines and lines starting with # are skipped.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	prompts, err := readBatchPrompts(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read prompts: %v\n", err)
+		os.Exit(1)
+	}
+	if len(prompts) == 0 {
+		fmt.Fprintf(os.Stderr, "No prompts found in %s\n", fs.Arg(0))
+		os.Exit(1)
+	}
+
+	runs, err := readBatchResults(*outputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", *outputPath, err)
+		os.Exit(1)
+	}
+	if len(runs) > 0 && !*resume {
+		fmt.Fprintf(os.Stderr, "%s already holds %d result(s); pass --resume to continue that batch, or choose another --output\n", *outputPath, len(runs))
+		os.Exit(1)
+	}
+
+	// Index earlier results by prompt so re-runs replace failed entries in place
+	byHash := make(map[string]int, len(runs))
+	for i, run := range runs {
+		hash := run.PromptHash
+		if hash == "" {
+			hash = output.PromptHash(run.Prompt)
+		}
+		byHash[hash] = i
+	}
+
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Make sure you have a devgru.yaml file in the current directory or ~/.devgru/\n")
+		os.Exit(1)
+	}
+
+	if message := missingAPIKeysMessage(cfg); message != "" {
+		fmt.Fprint(os.Stderr, message)
+		os.Exit(1)
+	}
+
+	r, err := runner.NewRunner(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create runner: %v\n", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	skipped, failed := 0, 0
+	for i, prompt := range prompts {
+		label := fmt.Sprintf("[%d/%d]", i+1, len(prompts))
+		hash := output.PromptHash(prompt)
+		existing, seen := byHash[hash]
+		if seen && runs[existing].Success {
+			skipped++
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "%s %s\n", label, batchPreview(prompt))
+		result, runErr := r.Run(ctx, prompt)
+		if ctx.Err() != nil {
+			// The interrupted prompt isn't recorded, so --resume runs it again
+			fmt.Fprintf(os.Stderr, "Interrupted; %s keeps the finished prompts. Run again with --resume to continue.\n", *outputPath)
+			os.Exit(1)
+		}
+
+		run := output.FromRunResult(result, runErr)
+		run.Prompt = prompt
+		run.PromptHash = hash
+		if seen {
+			runs[existing] = run
+		} else {
+			byHash[hash] = len(runs)
+			runs = append(runs, run)
+		}
+
+		if err := writeBatchResults(*outputPath, runs); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save results: %v\n", err)
+			os.Exit(1)
+		}
+
+		if runErr != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s failed: %v\n", label, runErr)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s %s\n", label, summaryLine(result))
+	}
+
+	fmt.Fprintf(os.Stderr, "Ran %d prompt(s), skipped %d already answered, %d failed; results in %s\n",
+		len(prompts)-skipped, skipped, failed, *outputPath)
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "Run again with --resume to retry the failed prompts.\n")
+		os.Exit(1)
+	}
+}
+
+// readBatchPrompts reads one prompt per line, skipping blank lines, # comments
+// and repeats of an earlier prompt
+func readBatchPrompts(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var prompts []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		prompt := strings.TrimSpace(scanner.Text())
+		if prompt == "" || strings.HasPrefix(prompt, "#") || seen[prompt] {
+			continue
+		}
+		seen[prompt] = true
+		prompts = append(prompts, prompt)
+	}
+	return prompts, scanner.Err()
+}
+
+// readBatchResults reads an earlier batch's results; a missing file is an
+// empty batch
+func readBatchResults(path string) ([]output.Run, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return output.ReadRuns(file)
+}
+
+// writeBatchResults replaces the results file with runs, one per line. The new
+// contents go to a temporary file that is synced and renamed over the old one,
+// so a crash leaves either the previous or the new results, never half of them.
+func writeBatchResults(path string, runs []output.Run) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, run := range runs {
+		if err := encoder.Encode(run); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// batchPreview shortens a prompt for progress lines
+func batchPreview(prompt string) string {
+	runes := []rune(prompt)
+	if len(runes) > 70 {
+		return string(runes[:69]) + "…"
+	}
+	return prompt
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/output"
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// benchSample is one timed call of a worker
+type benchSample struct {
+	duration   time.Duration
+	firstToken time.Duration // zero when no text was streamed
+	tokens     int
+	cost       float64
+	failed     bool
+}
+
+// benchCommand runs a prompt several times on each worker, with no judges,
+// and prints a table comparing the workers' latency, time to first token,
+// tokens and cost. Workers are benchmarked one after another so they don't
+// compete for the network; --parallel overlaps one worker's runs, still
+// within its provider's requests_per_minute.
+//
+// With --prompts, every prompt in a file is run once through the full
+// pipeline instead (see benchPrompts).
+func benchCommand(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	runs := fs.Int("n", 5, "runs per worker (single PROMPT only)")
+	parallel := fs.Int("parallel", 1, "runs in flight at once: of a worker, or with --prompts, prompts (1: one after another)")
+	workerList := fs.String("workers", "", "comma-separated worker IDs to benchmark (default: all; single PROMPT only)")
+	promptsPath := fs.String("prompts", "", "benchmark every prompt in this file (one per line), each run once with workers and judges")
+	outputPath := fs.String("output", "", "with --prompts: append each run to this JSONL file as it finishes (default: logging.run_log, else bench-results.jsonl)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: devgru bench [flags] PROMPT\n       devgru bench [flags] --prompts FILE\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prompt := strings.Join(fs.Args(), " ")
+	if (strings.TrimSpace(prompt) == "") == (*promptsPath == "") || *runs < 1 || *parallel < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *promptsPath != "" && *workerList != "" {
+		fmt.Fprintf(os.Stderr, "Error: --workers only applies to single-prompt benchmarks; --prompts runs every worker\n")
+		os.Exit(1)
+	}
+
+	var prompts []string
+	if *promptsPath != "" {
+		var err error
+		if prompts, err = readBatchPrompts(*promptsPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read prompts: %v\n", err)
+			os.Exit(1)
+		}
+		if len(prompts) == 0 {
+			fmt.Fprintf(os.Stderr, "No prompts found in %s\n", *promptsPath)
+			os.Exit(1)
+		}
+	}
+
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Make sure you have a devgru.yaml file in the current directory or ~/.devgru/\n")
+		os.Exit(1)
+	}
+
+	workers := cfg.Workers
+	if *workerList != "" {
+		workers = nil
+		for _, id := range strings.Split(*workerList, ",") {
+			worker, err := cfg.GetWorkerByID(strings.TrimSpace(id))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			workers = append(workers, *worker)
+		}
+	}
+
+	if message := missingAPIKeysMessage(cfg); message != "" {
+		fmt.Fprint(os.Stderr, message)
+		os.Exit(1)
+	}
+
+	r, err := runner.NewRunner(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create runner: %v\n", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if prompts != nil {
+		logPath := *outputPath
+		if logPath == "" {
+			logPath = cfg.Logging.RunLog
+		}
+		if logPath == "" {
+			logPath = "bench-results.jsonl"
+		}
+		stats := benchPrompts(ctx, r, prompts, *parallel, logPath)
+		if ctx.Err() != nil {
+			fmt.Fprintf(os.Stderr, "Interrupted after %d of %d prompt(s)\n", stats.done, len(prompts))
+		}
+		printBenchTable(cfg, workers, stats.samples)
+		stats.printSummary(workers)
+		fmt.Fprintf(os.Stderr, "Runs appended to %s\n", logPath)
+		return
+	}
+
+	results := make([][]benchSample, len(workers))
+	for i, worker := range workers {
+		fmt.Fprintf(os.Stderr, "Benchmarking %s (%d run(s))...\n", worker.ID, *runs)
+		results[i] = benchWorker(ctx, r, worker.ID, prompt, *runs, *parallel)
+		if ctx.Err() != nil {
+			fmt.Fprintf(os.Stderr, "Interrupted\n")
+			os.Exit(1)
+		}
+	}
+
+	samples := make(map[string][]benchSample, len(workers))
+	for i, worker := range workers {
+		samples[worker.ID] = results[i]
+	}
+	printBenchTable(cfg, workers, samples)
+}
+
+// benchPromptStats accumulates a prompts-file benchmark as runs finish. Only
+// numbers are kept per answer, so memory stays flat however many prompts run.
+type benchPromptStats struct {
+	samples map[string][]benchSample // by worker ID
+	wins    map[string]int           // consensus picks by worker ID
+	done    int                      // prompts finished
+	failed  int                      // runs that returned an error
+	cost    float64                  // workers' estimated cost so far; judges aren't counted
+}
+
+// benchPrompts runs every prompt through the full pipeline, workers and
+// judges, with up to parallel prompts in flight. Each run is appended to
+// logPath (when set) as soon as it finishes and then dropped, and a progress
+// line with the running totals is printed to stderr.
+func benchPrompts(ctx context.Context, r *runner.Runner, prompts []string, parallel int, logPath string) *benchPromptStats {
+	stats := &benchPromptStats{samples: make(map[string][]benchSample), wins: make(map[string]int)}
+	var mu sync.Mutex
+	logFailed := false
+
+	g := new(errgroup.Group)
+	g.SetLimit(parallel)
+	for _, prompt := range prompts {
+		if ctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			result, err := r.Run(ctx, prompt)
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			var logErr error
+			if logPath != "" {
+				logErr = output.AppendRun(logPath, result, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if logErr != nil && !logFailed {
+				logFailed = true
+				fmt.Fprintf(os.Stderr, "Warning: failed to append runs to %s: %v\n", logPath, logErr)
+			}
+
+			stats.done++
+			winner := "-"
+			if err != nil {
+				stats.failed++
+				winner = "failed"
+			}
+			if result != nil {
+				stats.cost += result.EstimatedCost
+				for _, worker := range result.Workers {
+					id := benchWorkerID(worker)
+					stats.samples[id] = append(stats.samples[id], newBenchSample(worker))
+					if result.Consensus != nil && worker.WorkerID == result.Consensus.Winner {
+						stats.wins[id]++
+						winner = id
+					}
+				}
+			}
+
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s: %s; workers so far $%.4f, %d failed\n",
+				stats.done, len(prompts), clip(prompt, 40), winner, stats.cost, stats.failed)
+			return nil
+		})
+	}
+	g.Wait()
+	return stats
+}
+
+// printSummary prints the total cost and how often each worker's answer was
+// picked
+func (s *benchPromptStats) printSummary(workers []config.Worker) {
+	parts := make([]string, 0, len(workers))
+	for _, worker := range workers {
+		parts = append(parts, fmt.Sprintf("%s %d", worker.ID, s.wins[worker.ID]))
+	}
+	fmt.Printf("\n%d prompt(s), %d failed, $%.4f for the workers (judges not counted). Picked: %s\n",
+		s.done, s.failed, s.cost, strings.Join(parts, ", "))
+}
+
+// benchWorkerID returns the configured worker behind a result, so the
+// samples of a worker with samples > 1 count as that worker's runs
+func benchWorkerID(worker runner.WorkerResult) string {
+	if id, ok := worker.Metadata["worker"].(string); ok {
+		return id
+	}
+	return worker.WorkerID
+}
+
+// newBenchSample takes the timings, tokens and cost of a worker's answer
+func newBenchSample(worker runner.WorkerResult) benchSample {
+	sample := benchSample{failed: worker.Error != nil}
+	if worker.Stats != nil {
+		sample.duration = worker.Stats.Duration
+		sample.firstToken = worker.Stats.TimeToFirstToken
+		sample.cost = worker.Stats.EstimatedCost
+	}
+	if worker.TokensUsed != nil {
+		sample.tokens = worker.TokensUsed.TotalTokens
+	}
+	return sample
+}
+
+// printBenchTable prints devgru bench's table, a row per worker
+func printBenchTable(cfg *config.Config, workers []config.Worker, samples map[string][]benchSample) {
+	fmt.Printf("%-20s  %-24s  %7s  %9s  %9s  %9s  %10s  %10s  %10s\n",
+		"WORKER", "MODEL", "OK", "MEDIAN", "P95", "TTFT", "AVG TOKENS", "AVG COST", "TOTAL COST")
+	for _, worker := range workers {
+		model := "-"
+		if providerConfig, ok := cfg.Providers[worker.Provider]; ok && providerConfig.Model != "" {
+			model = providerConfig.Model
+		}
+		printBenchRow(worker.ID, model, samples[worker.ID])
+	}
+}
+
+// benchWorker runs the prompt on one worker n times, at most parallel at once
+func benchWorker(ctx context.Context, r *runner.Runner, workerID, prompt string, n, parallel int) []benchSample {
+	var mu sync.Mutex
+	samples := make([]benchSample, 0, n)
+
+	g := new(errgroup.Group)
+	g.SetLimit(parallel)
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			sample := benchSample{failed: true}
+			result, err := r.RunWorker(ctx, workerID, prompt)
+			if result != nil && len(result.Workers) > 0 {
+				sample = newBenchSample(result.Workers[0])
+			}
+			if err != nil {
+				sample.failed = true
+			}
+
+			mu.Lock()
+			samples = append(samples, sample)
+			fmt.Fprintf(os.Stderr, "  [%d/%d] %s", len(samples), n, sample.duration.Round(time.Millisecond))
+			if sample.failed {
+				fmt.Fprintf(os.Stderr, " failed: %v", err)
+			}
+			fmt.Fprintln(os.Stderr)
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+	return samples
+}
+
+// printBenchRow prints a worker's row of devgru bench's table. Latency,
+// tokens and cost are over the successful runs; cost totals every run, as
+// failed runs may still be billed.
+func printBenchRow(workerID, model string, samples []benchSample) {
+	var durations, firstTokens []time.Duration
+	var tokens int
+	var cost, totalCost float64
+	for _, sample := range samples {
+		totalCost += sample.cost
+		if sample.failed {
+			continue
+		}
+		durations = append(durations, sample.duration)
+		if sample.firstToken > 0 {
+			firstTokens = append(firstTokens, sample.firstToken)
+		}
+		tokens += sample.tokens
+		cost += sample.cost
+	}
+
+	ok := fmt.Sprintf("%d/%d", len(durations), len(samples))
+	median, p95, ttft, avgTokens, avgCost := "-", "-", "-", "-", "-"
+	if len(durations) > 0 {
+		median = formatBenchDuration(percentile(durations, 50))
+		p95 = formatBenchDuration(percentile(durations, 95))
+		avgTokens = fmt.Sprintf("%d", tokens/len(durations))
+		avgCost = fmt.Sprintf("$%.4f", cost/float64(len(durations)))
+	}
+	if len(firstTokens) > 0 {
+		ttft = formatBenchDuration(percentile(firstTokens, 50))
+	}
+
+	fmt.Printf("%-20s  %-24s  %7s  %9s  %9s  %9s  %10s  %10s  %10s\n",
+		clip(workerID, 20), clip(model, 24), ok, median, p95, ttft, avgTokens, avgCost, fmt.Sprintf("$%.4f", totalCost))
+}
+
+// percentile returns the nearest-rank pth percentile of durations
+func percentile(durations []time.Duration, p int) time.Duration {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// formatBenchDuration formats a latency for devgru bench's table
+func formatBenchDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evisdrenova/devgru/internal/output"
+	"github.com/evisdrenova/devgru/internal/runner"
+	"github.com/evisdrenova/devgru/ui"
+)
+
+// parseCompare splits the --compare value into its two worker IDs
+func parseCompare(value string) (left, right string, err error) {
+	ids := strings.Split(value, ",")
+	if len(ids) != 2 || strings.TrimSpace(ids[0]) == "" || strings.TrimSpace(ids[1]) == "" {
+		return "", "", fmt.Errorf("--compare takes exactly two worker IDs, e.g. --compare gpt-worker,claude-worker")
+	}
+	return strings.TrimSpace(ids[0]), strings.TrimSpace(ids[1]), nil
+}
+
+// runCompare asks two workers the prompt and shows their answers diffed
+// instead of a consensus: in the comparison view on a terminal, as plain text
+// when stdout is piped, or as the run's JSON with --format json
+func runCompare(ctx context.Context, r *runner.Runner, prompt, left, right, format string, showPrompts bool, save, runLog string) {
+	comparison, err := r.Compare(ctx, prompt, left, right)
+	if comparison == nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	result := comparison.Result
+
+	if runLog != "" {
+		if logErr := output.AppendRun(runLog, result, err); logErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to append to the run log: %v\n", logErr)
+		}
+	}
+	if save != "" {
+		if saveErr := saveRun(save, result, err); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save run: %v\n", saveErr)
+		}
+	}
+	if showPrompts {
+		printPrompts(os.Stderr, result)
+	}
+
+	if format == "json" {
+		if writeErr := output.WriteComparisonJSON(os.Stdout, comparison, err); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", writeErr)
+			os.Exit(1)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Run failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !isTerminal(os.Stdout) {
+		if writeErr := output.WriteComparison(os.Stdout, comparison); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", writeErr)
+			os.Exit(1)
+		}
+		return
+	}
+	p := tea.NewProgram(ui.NewCompareModel(comparison), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error displaying results: %v\n", err)
+		os.Exit(1)
+	}
+}
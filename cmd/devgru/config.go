@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/knadh/koanf/parsers/yaml"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+// configCommand dispatches the `devgru config` subcommands
+func configCommand(args []string) {
+	if len(args) == 0 {
+		printConfigUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "show":
+		configShowCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown config command: %s\n\n", args[0])
+		printConfigUsage()
+		os.Exit(1)
+	}
+}
+
+// printConfigUsage prints help for the config subcommands
+func printConfigUsage() {
+	fmt.Fprintf(os.Stderr, `Usage:
+  devgru config show [--format yaml|json]  Print the configuration in effect, secrets redacted
+`)
+}
+
+// configShowCommand prints the fully resolved configuration: the config file
+// with DEVGRU_ environment overrides, injected API keys and defaults applied.
+// API keys and auth tokens only show whether they are set.
+func configShowCommand(args []string) {
+	fs := flag.NewFlagSet("config show", flag.ExitOnError)
+	format := fs.String("format", "yaml", "output format: yaml or json")
+	fs.Parse(args)
+
+	if *format != "yaml" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown format %q: use yaml or json\n", *format)
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	var data []byte
+	if *format == "json" {
+		data, err = json.MarshalIndent(cfg.Effective(), "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Parser().Marshal(cfg.Effective())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to render config: %v\n", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(data)
+}
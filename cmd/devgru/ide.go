@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/ide"
+	"github.com/gorilla/websocket"
+)
+
+// ideCommand dispatches the `devgru ide` subcommands
+func ideCommand(args []string) {
+	if len(args) == 0 {
+		printIDEUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "watch":
+		ideWatchCommand(args[1:])
+	case "test":
+		ideTestCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown ide command: %s\n\n", args[0])
+		printIDEUsage()
+		os.Exit(1)
+	}
+}
+
+// printIDEUsage prints help for the ide subcommands
+func printIDEUsage() {
+	fmt.Fprintf(os.Stderr, `Usage:
+  devgru ide watch [--port N]  Start the IDE server and print every message the extension sends
+  devgru ide test [flags]      Send editor messages to a running server and print the resulting context
+
+Run "devgru ide test -h" for the message flags.
+`)
+}
+
+// ideWatchCommand starts the IDE server and prints incoming extension messages
+// as they arrive; pressing enter prints the derived IDE context snapshot
+func ideWatchCommand(args []string) {
+	fs := flag.NewFlagSet("ide watch", flag.ExitOnError)
+	port := fs.Int("port", generateWorkspacePort(), "port to listen on (defaults to this workspace's port)")
+	fs.Parse(args)
+
+	ideConfig := ide.Config{
+		Enable:    true,
+		Transport: "websocket",
+		Port:      *port,
+	}
+
+	// The config file is optional here; it only supplies the address, token and limits
+	if cfg, err := config.LoadDefault(); err == nil {
+		ideConfig.BindAddress = cfg.Ide.BindAddress
+		ideConfig.AuthToken = cfg.Ide.AuthToken
+		ideConfig.MaxDiagnostics = cfg.Ide.Context.MaxDiagnostics
+		ideConfig.MinSeverity = cfg.Ide.Context.MinSeverity
+	}
+
+	server := ide.NewServer(ideConfig)
+	if err := server.Listen(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", ideServerErrorMessage(err, *port))
+		if errors.Is(err, ide.ErrPortInUse) {
+			fmt.Fprintf(os.Stderr, "Stop the instance using it, or watch another port with --port.\n")
+		}
+		os.Exit(1)
+	}
+
+	server.OnMessage(func(msg ide.Message) {
+		data, _ := json.Marshal(msg.Data)
+		fmt.Printf("[%s] %-10s %s\n", msg.Timestamp.Format("15:04:05.000"), msg.Type, data)
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Print the derived context whenever the user presses enter
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			snapshot, _ := json.MarshalIndent(server.GetContext(), "", "  ")
+			fmt.Printf("--- IDE context ---\n%s\n", snapshot)
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "Watching IDE messages (enter: print context • ctrl+c: quit)\n")
+
+	if err := server.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "IDE server error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ideTestCommand plays the editor extension against a running IDE server: it
+// sends synthetic messages built from flags and/or a JSON fixture, then prints
+// the context the server derived from them
+func ideTestCommand(args []string) {
+	fs := flag.NewFlagSet("ide test", flag.ExitOnError)
+	port := fs.Int("port", generateWorkspacePort(), "port of the IDE server (defaults to this workspace's port)")
+	token := fs.String("token", "", "auth token (defaults to ide.auth_token from the config)")
+	fixture := fs.String("fixture", "", "JSON file with messages to send first: [{\"type\": \"selection\", \"data\": {...}}, ...]")
+	workspace := fs.String("workspace", "", "send a workspace message with this root")
+	openFiles := fs.String("open", "", "comma-separated open files for the workspace message")
+	file := fs.String("file", "", "send a fileChange message making this the active file")
+	selection := fs.String("selection", "", "send a selection of this text in --file")
+	lines := fs.String("lines", "1-1", "START-END lines of the --selection")
+	diagnostic := fs.String("diagnostic", "", "send a diagnostic with this message in --file")
+	line := fs.Int("line", 1, "line of the --diagnostic")
+	severity := fs.String("severity", "error", "severity of the --diagnostic: error, warning, info or hint")
+	fs.Parse(args)
+
+	host := "127.0.0.1"
+	authToken := *token
+	if cfg, err := config.LoadDefault(); err == nil {
+		host = ideHost(cfg)
+		if authToken == "" {
+			authToken = cfg.Ide.AuthToken
+		}
+	}
+
+	messages, err := ideTestMessages(*fixture)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *workspace != "" || *openFiles != "" {
+		var open []interface{}
+		for _, f := range strings.Split(*openFiles, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				open = append(open, f)
+			}
+		}
+		messages = append(messages, ide.Message{Type: "workspace", Data: map[string]interface{}{"root": *workspace, "open_files": open}})
+	}
+	if *file != "" {
+		messages = append(messages, ide.Message{Type: "fileChange", Data: map[string]interface{}{"file": *file}})
+	}
+	if *selection != "" {
+		var start, end int
+		if _, err := fmt.Sscanf(*lines, "%d-%d", &start, &end); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --lines must look like 10-12\n")
+			os.Exit(1)
+		}
+		messages = append(messages, ide.Message{Type: "selection", Data: map[string]interface{}{
+			"file": *file, "text": *selection, "start_line": start, "end_line": end,
+		}})
+	}
+	if *diagnostic != "" {
+		messages = append(messages, ide.Message{Type: "diagnostic", Data: map[string]interface{}{
+			"file": *file, "message": *diagnostic, "line": *line, "severity": *severity,
+		}})
+	}
+	if len(messages) == 0 {
+		fmt.Fprintf(os.Stderr, "Nothing to send: pass --fixture or message flags (see devgru ide test -h)\n")
+		os.Exit(1)
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(*port))
+	header := http.Header{}
+	if authToken != "" {
+		header.Set("Authorization", "Bearer "+authToken)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+addr+"/ws", header)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not connect to the IDE server on %s: %v\n", addr, err)
+		fmt.Fprintf(os.Stderr, "Start devgru (or devgru ide watch) in this workspace, or pass --port.\n")
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	for _, msg := range messages {
+		msg.Timestamp = time.Now()
+		if msg.Data == nil {
+			msg.Data = map[string]interface{}{}
+		}
+		if err := conn.WriteJSON(msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: sending %s message: %v\n", msg.Type, err)
+			os.Exit(1)
+		}
+		data, _ := json.Marshal(msg.Data)
+		fmt.Printf("sent %-10s %s\n", msg.Type, data)
+	}
+
+	// The server handles a connection's messages in order, so once it answers
+	// our close frame every message above has been applied to the context
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "devgru ide test done"))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	ideContext, err := fetchIDEContext(http.DefaultClient, addr, header)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	snapshot, _ := json.MarshalIndent(ideContext, "", "  ")
+	fmt.Printf("--- IDE context ---\n%s\n", snapshot)
+}
+
+// ideHost returns the host to reach this workspace's IDE server on: its
+// bind address, unless that listens on every interface
+func ideHost(cfg *config.Config) string {
+	if ip := net.ParseIP(cfg.Ide.BindAddress); cfg.Ide.BindAddress == "localhost" || (ip != nil && !ip.IsUnspecified()) {
+		return cfg.Ide.BindAddress
+	}
+	return "127.0.0.1"
+}
+
+// fetchIDEContext asks the IDE server at addr for the editor context it holds
+func fetchIDEContext(client *http.Client, addr string, header http.Header) (*ide.IDEContext, error) {
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/context", nil)
+	req.Header = header
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching the IDE context: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching the IDE context: %s", resp.Status)
+	}
+
+	var ideContext ide.IDEContext
+	if err := json.NewDecoder(resp.Body).Decode(&ideContext); err != nil {
+		return nil, fmt.Errorf("reading the IDE context: %w", err)
+	}
+	return &ideContext, nil
+}
+
+// ideTestMessages reads the messages in a fixture file, which holds either a
+// JSON array of messages or a single message
+func ideTestMessages(path string) ([]ide.Message, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []ide.Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		var single ide.Message
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", path, err)
+		}
+		messages = []ide.Message{single}
+	}
+
+	for i, msg := range messages {
+		if msg.Type == "" {
+			return nil, fmt.Errorf("fixture %s: message %d has no type", path, i+1)
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/ui"
+)
+
+// errSetupCancelled is returned by setupConfig when the wizard is left early
+var errSetupCancelled = errors.New("setup cancelled")
+
+// initCommand creates a starter devgru.yaml, through the setup wizard or,
+// with --no-wizard, for the providers whose API keys are set
+func initCommand(args []string) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	noWizard := fs.Bool("no-wizard", false, "don't ask: write ./devgru.yaml for the providers whose API keys are set (OpenAI if none)")
+	force := fs.Bool("force", false, "overwrite an existing config file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: devgru init [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	wizard := !*noWizard
+	if wizard && !isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "The setup wizard needs a terminal; use devgru init --no-wizard in scripts\n")
+		os.Exit(1)
+	}
+
+	if _, err := setupConfig(wizard, *force); err != nil {
+		if errors.Is(err, errSetupCancelled) {
+			fmt.Fprintf(os.Stderr, "Setup cancelled; nothing was written\n")
+		} else {
+			fmt.Fprintf(os.Stderr, "Setup failed: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// setupConfig writes a starter config and returns its path. With wizard the
+// providers, models and location are asked for; otherwise the providers are
+// detected from the environment and the config goes in the current directory.
+func setupConfig(wizard, force bool) (string, error) {
+	providers := config.DetectedProviders()
+	path := "devgru.yaml"
+
+	if wizard {
+		paths := []string{path}
+		if home, err := os.UserHomeDir(); err == nil {
+			paths = append(paths, filepath.Join(home, ".devgru", "devgru.yaml"))
+		}
+		final, err := tea.NewProgram(ui.NewSetupModel(paths)).Run()
+		if err != nil {
+			return "", err
+		}
+		var ok bool
+		if providers, path, ok = final.(ui.SetupModel).Result(); !ok {
+			return "", errSetupCancelled
+		}
+	}
+
+	data, err := config.Scaffold(providers)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("%s already exists; pass --force to overwrite it", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s with:\n", path)
+	for _, p := range providers {
+		status := "found"
+		envVar := config.APIKeyEnvVar(p.Kind)
+		if os.Getenv(envVar) == "" {
+			status = "not set; export it before running devgru"
+		}
+		fmt.Fprintf(os.Stderr, "  %-10s %-28s %s %s\n", p.Kind, p.Model, envVar, status)
+	}
+	return path, nil
+}
+
+// offerSetup asks whether to create a config now that none was found
+func offerSetup() bool {
+	fmt.Fprint(os.Stderr, "No devgru.yaml found. Set one up now? [Y/n] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
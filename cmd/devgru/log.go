@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/output"
+)
+
+// logPollInterval is how often devgru log --follow checks the log for new runs
+const logPollInterval = time.Second
+
+// logCommand summarizes the runs in a JSONL run log as a table, oldest
+// first, and with --follow keeps printing runs as they're appended
+func logCommand(args []string) {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	since := fs.String("since", "", "only runs started within a duration (24h) or since a date (2006-01-02, or RFC 3339)")
+	providerName := fs.String("provider", "", "only runs where a worker used this provider")
+	status := fs.String("status", "all", "only runs that: all, succeeded or failed (low-confidence runs count as failed)")
+	limit := fs.Int("n", 20, "show the last N matching runs (0: all of them)")
+	follow := fs.Bool("follow", false, "keep watching the log and print runs as they're appended")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: devgru log [flags] [FILE]\n\nFILE is a JSONL run log (default: logging.run_log from the config).\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	path := fs.Arg(0)
+	if path == "" {
+		if cfg, err := config.LoadDefault(); err == nil {
+			path = cfg.Logging.RunLog
+		}
+	}
+	if path == "" || fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	filter, err := newRunFilter(*since, *providerName, *status)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	runs, offset, err := readRunLog(path, 0)
+	if err != nil && !(*follow && errors.Is(err, os.ErrNotExist)) {
+		fmt.Fprintf(os.Stderr, "Failed to read run log: %v\n", err)
+		os.Exit(1)
+	}
+	read := len(runs)
+
+	var matching []output.Run
+	for _, run := range runs {
+		if filter.matches(run) {
+			matching = append(matching, run)
+		}
+	}
+	shown := matching
+	if *limit > 0 && len(shown) > *limit {
+		shown = shown[len(shown)-*limit:]
+	}
+
+	fmt.Printf("%-16s  %-40s  %-24s  %10s  %8s  %s\n", "TIME", "PROMPT", "WINNER", "COST", "TOKENS", "STATUS")
+	for _, run := range shown {
+		printRunRow(run)
+	}
+	printRunTotals(matching, len(shown))
+
+	if !*follow {
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// A log rewritten in place (e.g. a batch results file) is read again
+		// from the start, skipping the runs already printed
+		skip := 0
+		if info, err := os.Stat(path); err == nil && info.Size() < offset {
+			offset, skip = 0, read
+		}
+
+		runs, next, err := readRunLog(path, offset)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Failed to read run log: %v\n", err)
+			os.Exit(1)
+		}
+		offset = next
+		if skip == 0 {
+			read += len(runs)
+		} else {
+			read = len(runs)
+		}
+
+		for i, run := range runs {
+			if i >= skip && filter.matches(run) {
+				printRunRow(run)
+			}
+		}
+	}
+}
+
+// readRunLog reads the runs in a run log from offset on. It stops before a
+// run that is still being written, and returns the offset just past the last
+// complete run so the next read can pick up from there.
+func readRunLog(path string, offset int64) ([]output.Run, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+
+	start := offset
+	decoder := json.NewDecoder(file)
+	var runs []output.Run
+	for {
+		var run output.Run
+		if err := decoder.Decode(&run); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return runs, offset, nil
+			}
+			return runs, offset, fmt.Errorf("run at byte %d: %w", offset, err)
+		}
+		if run.SchemaVersion > output.SchemaVersion {
+			return runs, offset, fmt.Errorf("run at byte %d uses schema version %d, newer than supported version %d", offset, run.SchemaVersion, output.SchemaVersion)
+		}
+		runs = append(runs, run)
+		offset = start + decoder.InputOffset()
+	}
+}
+
+// runFilter selects the runs devgru log shows
+type runFilter struct {
+	since    time.Time // zero for any time
+	provider string    // "" for any provider
+	status   string    // all, succeeded or failed
+}
+
+// newRunFilter parses devgru log's filter flags
+func newRunFilter(since, providerName, status string) (runFilter, error) {
+	filter := runFilter{provider: providerName, status: status}
+	if status != "all" && status != "succeeded" && status != "failed" {
+		return filter, fmt.Errorf("unknown status %q (valid: all, succeeded, failed)", status)
+	}
+
+	if since != "" {
+		if d, err := time.ParseDuration(since); err == nil {
+			filter.since = time.Now().Add(-d)
+		} else if t, err := time.ParseInLocation("2006-01-02", since, time.Local); err == nil {
+			filter.since = t
+		} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+			filter.since = t
+		} else {
+			return filter, fmt.Errorf("--since %q is neither a duration (24h) nor a date (2006-01-02, or RFC 3339)", since)
+		}
+	}
+	return filter, nil
+}
+
+func (f runFilter) matches(run output.Run) bool {
+	if !f.since.IsZero() && run.StartedAt.Before(f.since) {
+		return false
+	}
+	if (f.status == "succeeded" && !run.Success) || (f.status == "failed" && run.Success) {
+		return false
+	}
+	if f.provider == "" {
+		return true
+	}
+	for _, worker := range run.Workers {
+		if worker.Provider == f.provider {
+			return true
+		}
+	}
+	return false
+}
+
+// printRunRow prints a run as a row of devgru log's table
+func printRunRow(run output.Run) {
+	winner := "-"
+	status := "ok"
+	if run.Consensus != nil {
+		winner = run.Consensus.Winner
+	}
+	if run.Consensus != nil && run.Consensus.LowConfidence {
+		status = "review"
+	} else if !run.Success {
+		status = "failed"
+	}
+
+	fmt.Printf("%-16s  %-40s  %-24s  %10s  %8d  %s\n",
+		run.StartedAt.Local().Format("2006-01-02 15:04"), clip(run.Prompt, 40), clip(winner, 24),
+		fmt.Sprintf("$%.4f", run.EstimatedCost), run.TotalTokens, status)
+}
+
+// printRunTotals sums up the matching runs, of which the last shown were listed
+func printRunTotals(runs []output.Run, shown int) {
+	var cost float64
+	var tokens, failed int
+	for _, run := range runs {
+		cost += run.EstimatedCost
+		tokens += run.TotalTokens
+		if !run.Success {
+			failed++
+		}
+	}
+
+	fmt.Printf("\n%d run(s)", len(runs))
+	if shown < len(runs) {
+		fmt.Printf(" (last %d shown)", shown)
+	}
+	fmt.Printf(", %d failed: $%.4f, %d tokens\n", failed, cost, tokens)
+}
+
+// clip flattens text to one line of at most width characters
+func clip(text string, width int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -15,19 +19,111 @@ import (
 
 func main() {
 	if len(os.Args) == 1 {
-		runInteractiveMode()
+		runInteractiveMode(false, "", false)
 		return
 	}
+
+	switch os.Args[1] {
+	case "run":
+		runCommand(os.Args[2:])
+	case "ide":
+		ideCommand(os.Args[2:])
+	case "replay":
+		replayCommand(os.Args[2:])
+	case "batch":
+		batchCommand(os.Args[2:])
+	case "log":
+		logCommand(os.Args[2:])
+	case "bench":
+		benchCommand(os.Args[2:])
+	case "serve":
+		serveCommand(os.Args[2:])
+	case "config":
+		configCommand(os.Args[2:])
+	case "init":
+		initCommand(os.Args[2:])
+	case "help", "-h", "--help":
+		printUsage()
+	default:
+		if strings.HasPrefix(os.Args[1], "-") {
+			interactiveCommand(os.Args[1:])
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", os.Args[1])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+// printUsage prints the top-level command help
+func printUsage() {
+	fmt.Fprintf(os.Stderr, `Usage:
+  devgru [--full] [--tee FILE] [--no-wizard]
+                            Start interactive mode (--full: preview responses untruncated,
+                            --tee: also write a plain-text transcript to FILE); without a
+                            config, offers the setup wizard unless --no-wizard is given
+  devgru init [--no-wizard] Create a devgru.yaml with the setup wizard (--no-wizard: from
+                            the API keys in the environment, without asking)
+  devgru run [flags] PROMPT Run a prompt across all workers and show the results
+  devgru replay [flags] FILE
+                            Show a run saved with --format json, or re-run it (--rerun)
+  devgru batch [flags] FILE Run every prompt in FILE, checkpointing results (--resume continues)
+  devgru log [flags] [FILE] Summarize logged runs: time, prompt, winner, cost (--follow to watch)
+  devgru bench [flags] PROMPT
+                            Time PROMPT on each worker N times: latency, time to first token, cost
+                            (--prompts FILE: run a prompt set through workers and judges instead)
+  devgru serve [--port N]   Serve runs over HTTP (POST /run, POST /plan)
+  devgru ide watch          Print messages received from the editor extension
+  devgru ide test [flags]   Send synthetic editor messages to a running IDE server
+  devgru config show        Print the configuration in effect (--format yaml|json), secrets redacted
+
+Run "devgru run -h" for run flags.
+`)
 }
 
-// runInteractiveMode starts the interactive TUI mode with auto IDE server
-func runInteractiveMode() {
+// interactiveCommand parses interactive mode's flags and starts it
+func interactiveCommand(args []string) {
+	fs := flag.NewFlagSet("devgru", flag.ExitOnError)
+	full := fs.Bool("full", false, "preview worker responses untruncated")
+	tee := fs.String("tee", "", "also write a plain-text transcript of the session to this file as it goes")
+	noWizard := fs.Bool("no-wizard", false, "when there's no config, fail instead of offering the setup wizard")
+	fs.Usage = printUsage
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	runInteractiveMode(*full, *tee, *noWizard)
+}
+
+// runInteractiveMode starts the interactive TUI mode with auto IDE server;
+// full shows whole worker responses instead of previews, and a non-empty
+// tee names the file the session's transcript is written to. Without a
+// config, it offers the setup wizard unless noWizard is set.
+func runInteractiveMode(full bool, tee string, noWizard bool) {
 	cfg, err := config.LoadDefault()
+	if errors.Is(err, config.ErrNoConfig) && !noWizard && isTerminal(os.Stdin) && offerSetup() {
+		if _, setupErr := setupConfig(true, false); setupErr != nil {
+			fmt.Fprintf(os.Stderr, "Setup didn't finish: %v\n", setupErr)
+			fmt.Fprintf(os.Stderr, "Run devgru init to try again, or create devgru.yaml yourself.\n")
+			os.Exit(1)
+		}
+		cfg, err = config.LoadDefault()
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
 		fmt.Fprintf(os.Stderr, "Make sure you have a devgru.yaml file in the current directory or ~/.devgru/\n")
 		os.Exit(1)
 	}
+	if full {
+		cfg.Display.MaxWorkerChars = -1
+	}
+
+	if message := missingAPIKeysMessage(cfg); message != "" {
+		fmt.Fprint(os.Stderr, message)
+		os.Exit(1)
+	}
 
 	r, err := runner.NewRunner(cfg)
 	if err != nil {
@@ -46,6 +142,12 @@ func runInteractiveMode() {
 		Transport: cfg.Ide.Transport,
 		DiffTool:  cfg.Ide.DiffTool,
 		Port:      workspacePort,
+
+		BindAddress: cfg.Ide.BindAddress,
+		AuthToken:   cfg.Ide.AuthToken,
+
+		MaxDiagnostics: cfg.Ide.Context.MaxDiagnostics,
+		MinSeverity:    cfg.Ide.Context.MinSeverity,
 	}
 
 	ideServer = ide.NewServer(ideConfig)
@@ -53,13 +155,33 @@ func runInteractiveMode() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go func() {
-		if err := ideServer.Start(ctx); err != nil {
-			fmt.Printf("IDE server warning: %v\n", err)
-		}
-	}()
+	// Bind before the TUI starts so a busy port is reported instead of silently
+	// leaving the session without an IDE connection
+	ideErr := ideServer.Listen()
+	if ideErr != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", ideServerErrorMessage(ideErr, workspacePort))
+		ideServer = nil
+	} else {
+		go func() {
+			if err := ideServer.Start(ctx); err != nil {
+				fmt.Printf("IDE server warning: %v\n", err)
+			}
+		}()
+	}
 
 	model := ui.NewInteractiveModel(r, cfg, ideServer)
+	if tee != "" {
+		transcript, err := os.Create(tee)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create transcript: %v\n", err)
+			os.Exit(1)
+		}
+		defer transcript.Close()
+		model.SetTranscript(transcript)
+	}
+	if ideErr != nil {
+		model.AddSystemMessage(ideServerErrorMessage(ideErr, workspacePort))
+	}
 
 	p := tea.NewProgram(
 		model,
@@ -73,6 +195,45 @@ func runInteractiveMode() {
 	}
 }
 
+// ideServerErrorMessage explains why the IDE server could not start
+func ideServerErrorMessage(err error, port int) string {
+	if errors.Is(err, ide.ErrPortInUse) {
+		return fmt.Sprintf("IDE server unavailable: port %d is already in use. Is another devgru instance running in this workspace? Close it and start devgru again.", port)
+	}
+	return fmt.Sprintf("IDE server unavailable: %v", err)
+}
+
+// missingAPIKeysMessage explains which environment variables to set when
+// providers have no API key, or returns "" when every key is present
+func missingAPIKeysMessage(cfg *config.Config) string {
+	missing := cfg.MissingAPIKeys()
+	if len(missing) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	var envVars []string
+	b.WriteString("Missing API keys:\n")
+	for _, name := range missing {
+		envVar := config.APIKeyEnvVar(cfg.Providers[name].Kind)
+		if !slices.Contains(envVars, envVar) {
+			envVars = append(envVars, envVar)
+		}
+		fmt.Fprintf(&b, "  provider %s (%s): set %s\n", name, cfg.Providers[name].Kind, envVar)
+	}
+
+	if cfg.SecretsFile != "" {
+		fmt.Fprintf(&b, "\nAdd a NAME=value line for the provider or variable to %s,\nor export the key before starting devgru, for example:\n", cfg.SecretsFile)
+	} else {
+		b.WriteString("\nExport the key before starting devgru, for example:\n")
+	}
+	for _, envVar := range envVars {
+		fmt.Fprintf(&b, "  export %s=...\n", envVar)
+	}
+	b.WriteString("or remove the providers you don't use from devgru.yaml.\n")
+	return b.String()
+}
+
 // Create a hash of the workspace path to generate a consistent port
 // This ensures the same workspace always gets the same port
 // Port range: 8123-8200 (77 possible ports)
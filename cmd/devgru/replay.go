@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/output"
+	"github.com/evisdrenova/devgru/internal/runner"
+	"github.com/evisdrenova/devgru/ui"
+)
+
+// replayCommand shows a run saved with `devgru run --format json`, or runs its
+// prompt again with the current config to compare against the recorded result
+func replayCommand(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	index := fs.Int("index", 0, "run to replay when the file holds several, counting from 1 (default: the last)")
+	rerun := fs.Bool("rerun", false, "run the recorded prompt again with the current config and compare")
+	format := fs.String("format", "tui", "output format: tui or json")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: devgru replay [flags] FILE\n\nFILE holds runs written by `devgru run --format json`, e.g. appended to runs.jsonl.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *format != "tui" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown format %q (valid: tui, json)\n", *format)
+		os.Exit(1)
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open run log: %v\n", err)
+		os.Exit(1)
+	}
+	runs, err := output.ReadRuns(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read run log: %v\n", err)
+		os.Exit(1)
+	}
+	if len(runs) == 0 {
+		fmt.Fprintf(os.Stderr, "No runs found in %s\n", fs.Arg(0))
+		os.Exit(1)
+	}
+
+	selected := len(runs)
+	if *index != 0 {
+		selected = *index
+	}
+	if selected < 1 || selected > len(runs) {
+		fmt.Fprintf(os.Stderr, "Run %d not found; %s holds %d run(s)\n", selected, fs.Arg(0), len(runs))
+		os.Exit(1)
+	}
+
+	run := runs[selected-1]
+	if !*rerun && *format == "json" {
+		// Print the recorded run as it was saved, error included
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(run); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	recorded := run.ToRunResult()
+	result := recorded
+	var runErr error
+
+	if *rerun {
+		result, runErr = rerunPrompt(recorded.Prompt)
+		printComparison(recorded, result, runErr)
+	}
+
+	if *format == "json" {
+		if err := output.WriteJSON(os.Stdout, result, runErr); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", err)
+			os.Exit(1)
+		}
+		if runErr != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if result == nil || len(result.Workers) == 0 {
+		fmt.Fprintln(os.Stderr, "No worker results to show")
+		os.Exit(1)
+	}
+
+	p := tea.NewProgram(ui.NewResultsModel(result), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error displaying results: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// rerunPrompt runs the prompt again with the current config
+func rerunPrompt(prompt string) (*runner.RunResult, error) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if message := missingAPIKeysMessage(cfg); message != "" {
+		fmt.Fprint(os.Stderr, message)
+		os.Exit(1)
+	}
+
+	r, err := runner.NewRunner(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create runner: %v\n", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	fmt.Fprintf(os.Stderr, "Re-running: %s\n", prompt)
+	return r.Run(context.Background(), prompt)
+}
+
+// printComparison summarizes the recorded run next to its re-run
+func printComparison(recorded, current *runner.RunResult, runErr error) {
+	fmt.Fprintln(os.Stderr)
+	printRunSummary("recorded", recorded)
+	printRunSummary("re-run", current)
+	if runErr != nil {
+		fmt.Fprintf(os.Stderr, "  re-run failed: %v\n", runErr)
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+func printRunSummary(label string, result *runner.RunResult) {
+	if result == nil {
+		fmt.Fprintf(os.Stderr, "  %-9s no result\n", label)
+		return
+	}
+
+	winner := "none"
+	if result.Consensus != nil {
+		winner = fmt.Sprintf("%s (%.0f%% confidence)", result.Consensus.Winner, result.Consensus.Confidence*100)
+	}
+	fmt.Fprintf(os.Stderr, "  %-9s winner %-36s %6d tokens  $%.4f  %v\n",
+		label, winner, result.TotalTokens, result.EstimatedCost, result.TotalDuration.Round(time.Millisecond))
+}
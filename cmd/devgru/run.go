@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/ide"
+	"github.com/evisdrenova/devgru/internal/output"
+	"github.com/evisdrenova/devgru/internal/runner"
+	"github.com/evisdrenova/devgru/ui"
+)
+
+// runCommand runs a single prompt across all workers and shows the results
+func runCommand(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	verbose := fs.Bool("verbose", false, "print prompts, raw responses, judge output and timings to stderr")
+	showPrompts := fs.Bool("show-prompts", false, "print the assembled prompt of every worker and judge after the run")
+	confirm := fs.Bool("confirm", false, "ask before running when the worst-case cost exceeds cost.confirm_above")
+	save := fs.String("save", "", "also write the run to this file: JSON for .json paths, appended as a line to .jsonl ones, a Markdown report otherwise")
+	format := fs.String("format", "tui", "output format: tui, json (versioned by schema_version) or diff (plan, execute and print unified patches)")
+	summary := fs.Bool("summary", false, "print a single summary line (winner, confidence, tokens, cost, duration) instead of the results view")
+	instruct := fs.String("instruct", "", "instruction added to the prompt for every worker, e.g. \"Answer in Spanish\" (replaces workers_instruction)")
+	template := fs.String("template", "", "run the named prompt template from the templates config; PROMPT, if given, is added after it")
+	useIDEContext := fs.Bool("use-ide-context", false, "add the editor's selection, active file and diagnostics from this workspace's IDE server (started by interactive devgru), when it's reachable")
+	tee := fs.String("tee", "", "also write a plain-text transcript (the prompt, then the results as Markdown) to this file while the results view is open")
+	seed := fs.Int64("seed", 0, "seed sent to every worker and judge, for repeatable answers where the provider supports it (best effort)")
+	resume := fs.String("resume", "", "finish an interrupted run by its ID, reusing the workers it completed (PROMPT may be left out)")
+	compare := fs.String("compare", "", "run only these two workers (id1,id2) and diff their answers instead of reaching consensus; plain text when stdout is piped")
+	var vars stringList
+	fs.Var(&vars, "var", "template variable as name=value, filling {{name}} (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: devgru run [flags] PROMPT\n       devgru run --template NAME [--var name=value ...] [PROMPT]\n       devgru run --resume RUN_ID [flags]\n       devgru run --compare ID1,ID2 [flags] PROMPT\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	prompt := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if prompt == "" && *template == "" && *resume == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *resume != "" && (*template != "" || *format == "diff") {
+		fmt.Fprintln(os.Stderr, "--resume finishes an earlier run of the same prompt and can't be combined with --template or --format diff")
+		os.Exit(1)
+	}
+	if len(vars) > 0 && *template == "" {
+		fmt.Fprintln(os.Stderr, "--var fills a template's placeholders and needs --template")
+		os.Exit(1)
+	}
+	templateVars, err := runner.ParseTemplateVars(vars)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var compareLeft, compareRight string
+	if *compare != "" {
+		if compareLeft, compareRight, err = parseCompare(*compare); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if *resume != "" || *format == "diff" || *summary || *tee != "" || *confirm {
+			fmt.Fprintln(os.Stderr, "--compare shows its own diff of two answers and can't be combined with --resume, --format diff, --summary, --tee or --confirm")
+			os.Exit(1)
+		}
+	}
+	if *format != "tui" && *format != "json" && *format != "diff" {
+		fmt.Fprintf(os.Stderr, "Unknown format %q (valid: tui, json, diff)\n", *format)
+		os.Exit(1)
+	}
+	if *summary && *format != "tui" {
+		fmt.Fprintf(os.Stderr, "--summary replaces the results view and can't be combined with --format %s\n", *format)
+		os.Exit(1)
+	}
+	if *tee != "" && (*summary || *format != "tui") {
+		fmt.Fprintln(os.Stderr, "--tee copies the results view and needs it; use --save to keep --format json, diff or --summary output")
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Make sure you have a devgru.yaml file in the current directory or ~/.devgru/\n")
+		os.Exit(1)
+	}
+
+	if message := missingAPIKeysMessage(cfg); message != "" {
+		fmt.Fprint(os.Stderr, message)
+		os.Exit(1)
+	}
+	if *instruct != "" {
+		cfg.WorkersInstruction = *instruct
+	}
+
+	r, err := runner.NewRunner(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create runner: %v\n", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	if *template != "" {
+		rendered, err := r.RenderTemplate(*template, templateVars)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if prompt != "" {
+			rendered += "\n\n" + prompt
+		}
+		prompt = rendered
+	}
+
+	if *verbose {
+		r.SetVerboseOutput(os.Stderr)
+	}
+	// Any seed may be given, 0 included, so check whether the flag was set
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			r.SetSeed(*seed)
+		}
+	})
+	r.SetRecordPrompts(*showPrompts)
+
+	// Editor context reaches the run through its context, and the plan of a
+	// diff run as an argument; nil when not asked for or not available
+	ctx := context.Background()
+	if *resume != "" {
+		if !output.ValidRunID(*resume) {
+			fmt.Fprintf(os.Stderr, "Invalid run ID %q: run IDs are letters, digits, - and _\n", *resume)
+			os.Exit(1)
+		}
+		checkpoint, err := output.ReadCheckpoint(output.CheckpointPath(cfg.Cache.Dir, *resume))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't resume run %s: %v\n", *resume, err)
+			os.Exit(1)
+		}
+		if prompt != "" && prompt != checkpoint.Prompt {
+			fmt.Fprintf(os.Stderr, "Run %s was for a different prompt; leave PROMPT out to resume it\n", *resume)
+			os.Exit(1)
+		}
+		prompt = checkpoint.Prompt
+		ctx = runner.WithRunID(runner.WithCompletedWorkers(ctx, checkpoint.Workers), *resume)
+		fmt.Fprintf(os.Stderr, "Resuming run %s: reusing %d completed worker(s)\n", *resume, len(checkpoint.Workers))
+	}
+	var ideContext interface{}
+	if *useIDEContext {
+		if editor := workspaceIDEContext(cfg); editor != nil {
+			ctx = runner.WithIDEContext(ctx, editor)
+			ideContext = editor
+		}
+	}
+
+	if *compare != "" {
+		runCompare(ctx, r, prompt, compareLeft, compareRight, *format, *showPrompts, *save, cfg.Logging.RunLog)
+		return
+	}
+
+	if *confirm {
+		estimate := r.EstimateRun(prompt)
+		if *format == "diff" {
+			estimate = r.EstimatePlanRun(prompt, ideContext)
+		}
+		if cfg.Cost.NeedsConfirmation(estimate.MaxCost) && !confirmCost(estimate) {
+			fmt.Fprintln(os.Stderr, "Run cancelled")
+			os.Exit(1)
+		}
+	}
+
+	if *format == "diff" {
+		runDiff(ctx, r, prompt, ideContext, *showPrompts, *save, cfg.Logging.RunLog)
+		return
+	}
+
+	var transcript *os.File
+	if *tee != "" {
+		transcript, err = os.Create(*tee)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create transcript: %v\n", err)
+			os.Exit(1)
+		}
+		defer transcript.Close()
+		fmt.Fprintf(transcript, "> %s\n\n", prompt)
+	}
+
+	// Checkpoint each worker as it completes, so an interrupted run can be
+	// resumed without asking those workers again
+	var checkpointOnce sync.Once
+	r.SetWorkerCheckpoint(func(runID, workerID string, results []runner.WorkerResult) {
+		path := output.CheckpointPath(cfg.Cache.Dir, runID)
+		if err := output.AppendCheckpoint(path, runID, prompt, workerID, results); err != nil {
+			checkpointOnce.Do(func() {
+				fmt.Fprintf(os.Stderr, "Failed to checkpoint the run: %v\n", err)
+			})
+		}
+	})
+
+	runCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	result, err := r.Run(runCtx, prompt)
+	interrupted := runCtx.Err() != nil
+	stop()
+	finishCheckpoint(cfg, result, err, interrupted)
+
+	if cfg.Logging.RunLog != "" {
+		if logErr := output.AppendRun(cfg.Logging.RunLog, result, err); logErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to append to the run log: %v\n", logErr)
+		}
+	}
+	if transcript != nil {
+		if teeErr := output.WriteMarkdown(transcript, result, err); teeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write transcript: %v\n", teeErr)
+		}
+	}
+	if *save != "" {
+		if saveErr := saveRun(*save, result, err); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save run: %v\n", saveErr)
+		}
+	}
+
+	// JSON goes to stdout for scripts; everything else stays on stderr
+	if *format == "json" {
+		if *showPrompts {
+			printPrompts(os.Stderr, result)
+		}
+		if writeErr := output.WriteJSON(os.Stdout, result, err); writeErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write results: %v\n", writeErr)
+			os.Exit(1)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *summary {
+		if result != nil {
+			fmt.Println(summaryLine(result))
+		}
+		if *showPrompts {
+			printPrompts(os.Stderr, result)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Run failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Run failed: %v\n", err)
+		if result == nil || len(result.Workers) == 0 {
+			os.Exit(1)
+		}
+	}
+
+	p := tea.NewProgram(ui.NewResultsModel(result), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error displaying results: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *showPrompts {
+		printPrompts(os.Stdout, result)
+	}
+}
+
+// finishCheckpoint tells how to resume a run that was interrupted or lost
+// workers, and drops the checkpoint of any other. A run whose workers all
+// answered but whose consensus failed (below min_score, say) isn't worth
+// resuming: it would reach the same consensus again.
+func finishCheckpoint(cfg *config.Config, result *runner.RunResult, err error, interrupted bool) {
+	if result == nil || result.RunID == "" {
+		return
+	}
+	path := output.CheckpointPath(cfg.Cache.Dir, result.RunID)
+	if !interrupted && (err == nil || !workersFailed(result)) {
+		os.Remove(path)
+		return
+	}
+	if _, statErr := os.Stat(path); statErr == nil {
+		fmt.Fprintf(os.Stderr, "Completed workers were saved; finish this run with: devgru run --resume %s\n", result.RunID)
+	}
+}
+
+// workersFailed reports whether some of a run's workers gave no answer
+func workersFailed(result *runner.RunResult) bool {
+	if len(result.Workers) == 0 {
+		return true
+	}
+	for _, worker := range result.Workers {
+		if worker.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// runDiff plans and executes the prompt like interactive mode, then prints the
+// proposed edits as unified patches on stdout for git apply or patch -p1
+func runDiff(ctx context.Context, r *runner.Runner, prompt string, ideContext interface{}, showPrompts bool, save, runLog string) {
+	fmt.Fprintln(os.Stderr, "Generating plan...")
+	plan, err := r.GeneratePlan(ctx, prompt, ideContext)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Planning failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintln(os.Stderr, "Executing plan...")
+	result, err := r.ExecutePlan(plan, ideContext)
+	if runLog != "" {
+		if logErr := output.AppendRun(runLog, result, err); logErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to append to the run log: %v\n", logErr)
+		}
+	}
+	if save != "" {
+		if saveErr := saveRun(save, result, err); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save run: %v\n", saveErr)
+		}
+	}
+	if showPrompts && result != nil {
+		printPrompts(os.Stderr, result)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Run failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(result.Diffs) == 0 {
+		fmt.Fprintln(os.Stderr, "No file changes proposed")
+		return
+	}
+	for _, diff := range result.Diffs {
+		fmt.Fprint(os.Stdout, diff.Patch)
+	}
+	fmt.Fprintf(os.Stderr, "Proposed changes to %d file(s)\n", len(result.Diffs))
+}
+
+// workspaceIDEContext fetches the editor context from this workspace's IDE
+// server. A server that isn't running is reported and the run goes ahead
+// without it.
+func workspaceIDEContext(cfg *config.Config) *ide.IDEContext {
+	addr := net.JoinHostPort(ideHost(cfg), strconv.Itoa(generateWorkspacePort()))
+	header := http.Header{}
+	if cfg.Ide.AuthToken != "" {
+		header.Set("Authorization", "Bearer "+cfg.Ide.AuthToken)
+	}
+
+	ideContext, err := fetchIDEContext(&http.Client{Timeout: 2 * time.Second}, addr, header)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Running without editor context: no IDE server answered on %s (%v)\n", addr, err)
+		return nil
+	}
+	return ideContext
+}
+
+// saveRun writes the run to path, creating parent directories as needed.
+// .json paths get the versioned JSON schema and .jsonl paths have it appended
+// as one line, like the run log; anything else gets a Markdown report.
+func saveRun(path string, result *runner.RunResult, runErr error) error {
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		return output.AppendRun(path, result, runErr)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = output.WriteJSON(file, result, runErr)
+	default:
+		err = output.WriteMarkdown(file, result, runErr)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// confirmCost shows the worst-case cost breakdown and asks whether to go ahead
+func confirmCost(estimate *runner.CostEstimate) bool {
+	fmt.Fprintf(os.Stderr, "This run may cost up to $%.4f:\n", estimate.MaxCost)
+	for _, call := range estimate.Calls {
+		fmt.Fprintf(os.Stderr, "  %-30s %-24s %6d prompt + %6d completion tokens × %d  $%.4f\n",
+			call.ID, call.Model, call.PromptTokens, call.MaxCompletionTokens, call.Requests, call.MaxCost)
+	}
+	fmt.Fprint(os.Stderr, "Continue? [y/N] ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// printPrompts prints the assembled prompt each worker and judge received
+func printPrompts(w io.Writer, result *runner.RunResult) {
+	for _, worker := range result.Workers {
+		fmt.Fprintf(w, "===== worker %s =====\n", worker.WorkerID)
+		if prompt, ok := worker.Metadata["assembled_prompt"].(string); ok {
+			fmt.Fprint(w, prompt)
+		} else {
+			fmt.Fprintln(w, "(not sent: the worker failed before its request was built)")
+		}
+		fmt.Fprintln(w)
+
+		for _, judge := range worker.JudgeResults {
+			if judge.Prompt == "" {
+				continue
+			}
+			fmt.Fprintf(w, "===== judge %s → worker %s =====\n", judge.JudgeID, worker.WorkerID)
+			fmt.Fprint(w, judge.Prompt)
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// summaryLine condenses a run into one key=value line for logs and shell one-liners
+func summaryLine(result *runner.RunResult) string {
+	winner := "none"
+	confidence := 0.0
+	if result.Consensus != nil {
+		winner = result.Consensus.Winner
+		confidence = result.Consensus.Confidence
+	}
+	line := fmt.Sprintf("run=%s winner=%s confidence=%.2f tokens=%d cost=$%.4f duration=%.1fs",
+		result.RunID, winner, confidence, result.TotalTokens, result.EstimatedCost, result.TotalDuration.Seconds())
+	if result.Consensus != nil && result.Consensus.LowConfidence {
+		line += " low_confidence=true"
+	}
+	return line
+}
+
+// stringList collects a flag given more than once
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,311 @@
+// Package api serves devgru runs over HTTP so other tools can use a
+// long-lived runner as a local service.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/output"
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// maxRequestBytes bounds request bodies, which only carry a prompt
+const maxRequestBytes = 1 << 20
+
+// Server handles the HTTP API:
+//
+//	POST /run   {"prompt": "..."} → run in the output JSON schema
+//	POST /plan  {"prompt": "..."} → generated plan
+//	GET  /health
+//
+// Adding ?stream=true to /run or /plan answers with server-sent events:
+// "judge" events while judges score workers, then one "result" event.
+//
+// Every request shares one runner. At most max_in_flight requests run at a
+// time and up to queue_size more wait their turn; past that the server
+// answers 429. Each response carries an X-Request-ID header, also included
+// in JSON bodies and log lines, to correlate them.
+type Server struct {
+	runner    *runner.Runner
+	authToken string
+	runLog    string // JSONL file every run is appended to, "" for none
+
+	slots    chan struct{} // held by running and queued requests
+	inFlight chan struct{} // held by running requests
+}
+
+// NewServer creates an API server backed by r. A non-empty cfg.AuthToken
+// must be sent by clients as a bearer token. r saves generated plans to
+// plans/*.md only when cfg.SavePlans is set.
+func NewServer(r *runner.Runner, cfg config.Serve) *Server {
+	r.SetSavePlans(cfg.SavePlans)
+	return &Server{
+		runner:    r,
+		authToken: cfg.AuthToken,
+		slots:     make(chan struct{}, cfg.MaxInFlight+max(cfg.QueueSize, 0)),
+		inFlight:  make(chan struct{}, cfg.MaxInFlight),
+	}
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/run", s.handleRun)
+	mux.HandleFunc("/plan", s.handlePlan)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "service": "devgru-api"})
+	})
+	return mux
+}
+
+// runRequest is the body accepted by /run and /plan
+type runRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+// runResponse is the /run response: the run in the output JSON schema
+type runResponse struct {
+	RequestID string `json:"request_id"`
+	output.Run
+}
+
+// planResponse is the /plan response
+type planResponse struct {
+	RequestID string `json:"request_id"`
+	*runner.PlanResult
+}
+
+// judgeEvent is the data of a "judge" stream event
+type judgeEvent struct {
+	JudgeID  string `json:"judge_id"`
+	WorkerID string `json:"worker_id"`
+	Done     bool   `json:"done"`
+	Score    int    `json:"score,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SetRunLog makes the server append every run to a JSONL run log
+func (s *Server) SetRunLog(path string) {
+	s.runLog = path
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	prompt, ok := s.readPrompt(w, r)
+	if !ok {
+		return
+	}
+
+	s.respond(w, r, func(ctx context.Context, requestID string) (interface{}, int) {
+		// The run takes the request's ID, so its traces match the log lines here
+		result, err := s.runner.Run(runner.WithRunID(ctx, requestID), prompt)
+		if s.runLog != "" {
+			if logErr := output.AppendRun(s.runLog, result, err); logErr != nil {
+				log.Printf("api %s: failed to append to the run log: %v", requestID, logErr)
+			}
+		}
+		status := http.StatusOK
+		if result == nil {
+			status = http.StatusInternalServerError
+		}
+		return runResponse{RequestID: requestID, Run: output.FromRunResult(result, err)}, status
+	})
+}
+
+func (s *Server) handlePlan(w http.ResponseWriter, r *http.Request) {
+	prompt, ok := s.readPrompt(w, r)
+	if !ok {
+		return
+	}
+
+	s.respond(w, r, func(ctx context.Context, requestID string) (interface{}, int) {
+		// The plan takes the request's ID, as runs do
+		plan, err := s.runner.GeneratePlan(runner.WithRunID(ctx, requestID), prompt, nil)
+		if err != nil {
+			return map[string]string{"request_id": requestID, "error": err.Error()}, http.StatusInternalServerError
+		}
+		return planResponse{RequestID: requestID, PlanResult: plan}, http.StatusOK
+	})
+}
+
+// readPrompt checks the method and token and decodes the prompt, writing an
+// error response and returning false when the request can't be served
+func (s *Server) readPrompt(w http.ResponseWriter, r *http.Request) (string, bool) {
+	w.Header().Set("X-Request-ID", newRequestID())
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return "", false
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return "", false
+	}
+
+	var req runRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return "", false
+	}
+	req.Prompt = strings.TrimSpace(req.Prompt)
+	if req.Prompt == "" {
+		writeError(w, http.StatusBadRequest, "prompt is required")
+		return "", false
+	}
+	return req.Prompt, true
+}
+
+// respond waits for a free slot, runs fn and writes its result, either as one
+// JSON response or, with ?stream=true, as server-sent events ending in a
+// "result" event
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, requestID string) (interface{}, int)) {
+	requestID := w.Header().Get("X-Request-ID")
+
+	release, ok := s.acquire(r.Context())
+	if !ok {
+		if r.Context().Err() == nil {
+			log.Printf("api %s: %s %s rejected, queue full", requestID, r.Method, r.URL.Path)
+			w.Header().Set("Retry-After", "5")
+			writeError(w, http.StatusTooManyRequests, "server busy: too many queued requests")
+		}
+		return
+	}
+	defer release()
+
+	started := time.Now()
+	log.Printf("api %s: %s %s started", requestID, r.Method, r.URL.Path)
+	defer func() {
+		log.Printf("api %s: %s %s finished in %v", requestID, r.Method, r.URL.Path, time.Since(started).Round(time.Millisecond))
+	}()
+
+	if r.URL.Query().Get("stream") != "true" {
+		body, status := fn(r.Context(), requestID)
+		writeJSON(w, status, body)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported by this connection")
+		return
+	}
+
+	// Judges report from their own goroutines; only this one writes to w
+	events := make(chan runner.JudgeEvent, 16)
+	ctx := runner.WithJudgeObserver(r.Context(), func(event runner.JudgeEvent) {
+		select {
+		case events <- event:
+		case <-r.Context().Done():
+		}
+	})
+
+	// The status is already sent; failures show up in the result body
+	done := make(chan interface{}, 1)
+	go func() {
+		body, _ := fn(ctx, requestID)
+		done <- body
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event := <-events:
+			writeEvent(w, "judge", toJudgeEvent(event))
+			flusher.Flush()
+
+		case result := <-done:
+			// Judge events sent just before the run finished go out first
+			for len(events) > 0 {
+				writeEvent(w, "judge", toJudgeEvent(<-events))
+			}
+			writeEvent(w, "result", result)
+			flusher.Flush()
+			return
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// acquire takes a queue slot, failing at once when the queue is full, then
+// waits to run. It fails if the client goes away while queued.
+func (s *Server) acquire(ctx context.Context) (release func(), ok bool) {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		return nil, false
+	}
+
+	select {
+	case s.inFlight <- struct{}{}:
+		return func() {
+			<-s.inFlight
+			<-s.slots
+		}, true
+	case <-ctx.Done():
+		<-s.slots
+		return nil, false
+	}
+}
+
+// newRequestID returns a short random ID for correlating a request's
+// response with its log lines
+func newRequestID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func toJudgeEvent(event runner.JudgeEvent) judgeEvent {
+	data := judgeEvent{JudgeID: event.JudgeID, WorkerID: event.WorkerID, Done: event.Done, Score: event.Score}
+	if event.Err != nil {
+		data.Error = event.Err.Error()
+	}
+	return data
+}
+
+// authorized checks the client's bearer token when the server requires one
+func (s *Server) authorized(r *http.Request) bool {
+	if s.authToken == "" {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.authToken)) == 1
+}
+
+func writeEvent(w http.ResponseWriter, event string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		encoded, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	body := map[string]string{"error": message}
+	if requestID := w.Header().Get("X-Request-ID"); requestID != "" {
+		body["request_id"] = requestID
+	}
+	writeJSON(w, status, body)
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// testServer starts the API backed by a runner whose one worker answers
+// answer from a fake OpenAI endpoint
+func testServer(t *testing.T, answer string, serve config.Serve) (*httptest.Server, *runner.Runner) {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			// Plans aren't streamed
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"choices": []map[string]interface{}{{"message": map[string]string{"content": answer}}},
+			})
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk, _ := json.Marshal(map[string]interface{}{
+			"choices": []map[string]interface{}{{"delta": map[string]string{"content": answer}}},
+		})
+		fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", chunk)
+	}))
+	t.Cleanup(upstream.Close)
+
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	path := filepath.Join(t.TempDir(), "devgru.yaml")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+workers:
+  - id: worker
+    provider: openai
+consensus:
+  algorithm: majority
+`, upstream.URL)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.NewRunner(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	if serve.MaxInFlight == 0 {
+		serve.MaxInFlight = 2
+	}
+	server := httptest.NewServer(NewServer(r, serve).Handler())
+	t.Cleanup(server.Close)
+	return server, r
+}
+
+func post(t *testing.T, url, body string, header http.Header) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestRun(t *testing.T) {
+	server, _ := testServer(t, "Paris", config.Serve{})
+
+	resp := post(t, server.URL+"/run", `{"prompt": "Capital of France?"}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var body runResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	requestID := resp.Header.Get("X-Request-ID")
+	if requestID == "" || body.RequestID != requestID || body.RunID != requestID {
+		t.Errorf("header ID %q, body ID %q, run ID %q; want all the same", requestID, body.RequestID, body.RunID)
+	}
+	if !body.Success || len(body.Workers) != 1 || body.Workers[0].Content != "Paris" {
+		t.Errorf("run = %+v, want the worker's answer", body.Run)
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	server, _ := testServer(t, "Paris", config.Serve{})
+
+	resp := post(t, server.URL+"/run?stream=true", `{"prompt": "Capital of France?"}`, nil)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %s, want text/event-stream", ct)
+	}
+	events, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(events), "event: result\ndata: {") || !strings.Contains(string(events), `"Paris"`) {
+		t.Errorf("stream doesn't end in the result:\n%s", events)
+	}
+}
+
+func TestRejectsBadRequests(t *testing.T) {
+	server, _ := testServer(t, "Paris", config.Serve{AuthToken: "secret"})
+	bearer := http.Header{"Authorization": {"Bearer secret"}}
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		header http.Header
+		want   int
+	}{
+		{name: "wrong method", method: http.MethodGet, header: bearer, want: http.StatusMethodNotAllowed},
+		{name: "no token", method: http.MethodPost, body: `{"prompt": "hi"}`, want: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodPost, body: `{"prompt": "hi"}`, header: http.Header{"Authorization": {"Bearer guess"}}, want: http.StatusUnauthorized},
+		{name: "not JSON", method: http.MethodPost, body: `prompt=hi`, header: bearer, want: http.StatusBadRequest},
+		{name: "blank prompt", method: http.MethodPost, body: `{"prompt": "  "}`, header: bearer, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+"/run", strings.NewReader(tt.body))
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			var body map[string]string
+			json.NewDecoder(resp.Body).Decode(&body)
+			if body["error"] == "" || body["request_id"] != resp.Header.Get("X-Request-ID") {
+				t.Errorf("body = %v, want an error with the request ID", body)
+			}
+		})
+	}
+
+	if resp := post(t, server.URL+"/run", `{"prompt": "hi"}`, bearer); resp.StatusCode != http.StatusOK {
+		t.Errorf("status with the token = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestPlanSavesNoFilesByDefault(t *testing.T) {
+	t.Chdir(t.TempDir())
+	server, r := testServer(t, "## Action Items\n1. Add the endpoint", config.Serve{})
+
+	resp := post(t, server.URL+"/plan", `{"prompt": "Add a /health endpoint"}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status = %d, want 200: %s", resp.StatusCode, b)
+	}
+	var body planResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.PlanResult == nil || body.RunID != body.RequestID || len(body.Todos) != 1 {
+		t.Errorf("plan = %+v, want the request's plan under its ID", body.PlanResult)
+	}
+	if _, err := os.Stat("plans"); !os.IsNotExist(err) {
+		t.Errorf("plans directory created by the API: %v", err)
+	}
+
+	r.SetSavePlans(true)
+	post(t, server.URL+"/plan", `{"prompt": "Add a /health endpoint"}`, nil)
+	if saved, _ := filepath.Glob("plans/plan_*.md"); len(saved) != 1 {
+		t.Errorf("saved plans = %v, want one with save_plans on", saved)
+	}
+}
+
+func TestAcquireRejectsPastTheQueue(t *testing.T) {
+	// One request runs and one waits
+	s := &Server{slots: make(chan struct{}, 2), inFlight: make(chan struct{}, 1)}
+	ctx := t.Context()
+
+	release, ok := s.acquire(ctx)
+	if !ok {
+		t.Fatal("first request wasn't let in")
+	}
+
+	// The second waits for the first; the third finds the queue full
+	queued := make(chan bool)
+	go func() {
+		releaseQueued, ok := s.acquire(ctx)
+		if ok {
+			releaseQueued()
+		}
+		queued <- ok
+	}()
+	for len(s.slots) < 2 {
+		// Wait for the second request to take its queue slot
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := s.acquire(ctx); ok {
+		t.Error("third request let in past a full queue")
+	}
+
+	release()
+	if !<-queued {
+		t.Error("queued request never ran")
+	}
+}
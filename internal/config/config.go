@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,6 +14,9 @@ import (
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
+
+	"github.com/evisdrenova/devgru/internal/provider/anthropic"
+	"github.com/evisdrenova/devgru/internal/provider/openai"
 )
 
 // the devgru config
@@ -19,18 +25,142 @@ type Config struct {
 	Workers   []Worker            `koanf:"workers"`
 	Judges    []Judge             `koanf:"judges"`
 	Consensus Consensus           `koanf:"consensus"`
+	Breaker   CircuitBreaker      `koanf:"circuit_breaker"`
 	Cache     Cache               `koanf:"cache"`
 	Logging   Logging             `koanf:"logging"`
 	Ide       IDE                 `koanf:"ide"`
+	Display   Display             `koanf:"display"`
+	Cost      Cost                `koanf:"cost"`
+	Planning  Planning            `koanf:"planning"`
+	Serve     Serve               `koanf:"serve"`
+
+	FileReferences FileReferences    `koanf:"file_references"`
+	Prompt         Prompt            `koanf:"prompt"`
+	Templates      map[string]string `koanf:"templates"` // named prompt presets with {{placeholders}}, for run --template and /name
+
+	WorkersSystemPrelude string `koanf:"workers_system_prelude"` // shared instructions placed before every worker's system prompt
+	WorkersSystemSuffix  string `koanf:"workers_system_suffix"`  // shared instructions placed after every worker's system prompt
+	WorkersInstruction   string `koanf:"workers_instruction"`    // steering directive appended to every prompt the workers get
+
+	SecretsFile string `koanf:"secrets_file"` // NAME=value file of API keys, preferred over the environment; relative to the config file
+}
+
+// reservedRawOptions are request fields devgru sets itself; raw_options can't override them
+var reservedRawOptions = []string{
+	"model", "messages", "system", "stream", "stream_options",
+	"temperature", "max_tokens", "max_completion_tokens", "n",
+}
+
+// checkRawOptions rejects raw_options that would clobber a core request field
+func checkRawOptions(owner string, options map[string]interface{}) error {
+	for key := range options {
+		if slices.Contains(reservedRawOptions, key) {
+			return fmt.Errorf("%s raw_options cannot set %q; it is set by devgru (reserved: %s)", owner, key, strings.Join(reservedRawOptions, ", "))
+		}
+	}
+	return nil
+}
+
+// schemaTypeNames are the JSON Schema types a response_schema may use
+var schemaTypeNames = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// checkSchema catches response_schema mistakes that would otherwise only show
+// up as every answer failing validation
+func checkSchema(schema map[string]interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+
+	var types []interface{}
+	switch t := schema["type"].(type) {
+	case nil:
+	case string:
+		types = []interface{}{t}
+	case []interface{}:
+		types = t
+	default:
+		return fmt.Errorf("%s.type must be a type name or a list of them", path)
+	}
+	for _, t := range types {
+		if name, ok := t.(string); !ok || !slices.Contains(schemaTypeNames, name) {
+			return fmt.Errorf("%s.type %v is not a JSON Schema type (valid: %s)", path, t, strings.Join(schemaTypeNames, ", "))
+		}
+	}
+
+	if properties, exists := schema["properties"]; exists {
+		propertyMap, ok := properties.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s.properties must be a map of property schemas", path)
+		}
+		for name, property := range propertyMap {
+			propertySchema, ok := property.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.properties.%s must be a schema", path, name)
+			}
+			if err := checkSchema(propertySchema, path+".properties."+name); err != nil {
+				return err
+			}
+		}
+	}
+	if required, exists := schema["required"]; exists {
+		names, ok := required.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s.required must be a list of property names", path)
+		}
+		for _, name := range names {
+			if _, ok := name.(string); !ok {
+				return fmt.Errorf("%s.required must be a list of property names", path)
+			}
+		}
+	}
+	if items, exists := schema["items"]; exists {
+		itemSchema, ok := items.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s.items must be a schema", path)
+		}
+		return checkSchema(itemSchema, path+".items")
+	}
+	return nil
 }
 
+// Default output, context and cost limits
+const (
+	DefaultMaxWorkerChars   = 200     // characters of each worker shown in the interactive results
+	DefaultOpenFilesTokens  = 50      // token budget for the open-files list in planning context
+	DefaultMaxOpenFiles     = 10      // open files listed in planning context
+	DefaultMaxDiagnostics   = 5       // editor diagnostics kept and injected into planning context
+	DefaultConfirmCostAbove = 0.25    // dollars of worst-case run cost before asking for confirmation
+	DefaultMaxResponseBytes = 1 << 20 // bytes kept from a single response before it is rejected
+	DefaultFileRefTokens    = 8000    // token budget for files attached because the prompt mentions them
+	DefaultJudgeConcurrency = 4       // judge calls in flight at once
+	DefaultSimilarity       = 0.9     // cosine similarity at which semantic majority groups two answers
+	MaxSamples              = 10      // most answers a worker may be sampled for in one run
+)
+
 // Provider defines configuration for an LLM provider
 type Provider struct {
-	Kind    string `koanf:"kind"`     // openai, anthropic, ollama
-	Model   string `koanf:"model"`    // gpt-4o-mini, claude-3-sonnet, etc.
-	BaseURL string `koanf:"base_url"` // API endpoint
-	Host    string `koanf:"host"`     // for ollama
-	APIKey  string `koanf:"api_key"`  // will be populated from env vars
+	Kind    string `koanf:"kind"`                  // openai, anthropic, ollama; inferred from well-known model names when unset
+	Model   string `koanf:"model"`                 // gpt-4o-mini, claude-3-sonnet, etc.
+	BaseURL string `koanf:"base_url"`              // API endpoint
+	Host    string `koanf:"host"`                  // for ollama, e.g. http://localhost:11434
+	APIKey  string `koanf:"api_key" secret:"true"` // will be populated from env vars
+
+	PromptCaching bool `koanf:"prompt_caching"` // anthropic: cache system prompt and project context
+
+	// openai: overrides of the built-in model capabilities, for models devgru doesn't know yet
+	SendTemperature   *bool  `koanf:"send_temperature"`   // whether the model accepts a temperature
+	MaxTokensParam    string `koanf:"max_tokens_param"`   // max_tokens or max_completion_tokens
+	StructuredOutputs *bool  `koanf:"structured_outputs"` // whether the model enforces a strict response_schema
+
+	RawOptions map[string]interface{} `koanf:"raw_options" secret:"keys"` // extra request body fields sent with every request
+
+	RequestsPerMinute int `koanf:"requests_per_minute"` // shared by providers using the same API key (0 is unlimited)
+
+	Preflight bool `koanf:"preflight"` // open a connection at startup so the first request starts warm
+
+	StreamBufferBytes int `koanf:"stream_buffer_bytes"` // openai: read buffer for streamed responses (0: default)
+
+	EmbeddingModel string `koanf:"embedding_model"` // openai, ollama: model used for embeddings (default per kind)
 }
 
 // Worker represents a configured LLM worker which is an instance of a provider
@@ -40,31 +170,145 @@ type Worker struct {
 	Temperature  float64 `koanf:"temperature"`
 	MaxTokens    int     `koanf:"max_tokens"`
 	SystemPrompt string  `koanf:"system_prompt"`
+
+	FallbackProvider string            `koanf:"fallback_provider"` // used when the primary provider is down or rate limited
+	Tags             map[string]string `koanf:"tags"`              // free-form labels (e.g. role, tier) shown with the worker's results
+
+	RawOptions map[string]interface{} `koanf:"raw_options" secret:"keys"` // extra request body fields, over the provider's raw_options
+
+	ResponseSchema map[string]interface{} `koanf:"response_schema"` // JSON Schema the worker's answer must match
+
+	Samples int `koanf:"samples"` // answers drawn from this worker per run, each a result of its own (0 or 1 for one)
 }
 
 // Judge represents a model that evaluates worker responses
 type Judge struct {
 	ID           string `koanf:"id"`
+	Kind         string `koanf:"kind"` // llm (default) or code_check
 	Provider     string `koanf:"provider"`
 	SystemPrompt string `koanf:"system_prompt"`
 }
 
+// Judge kinds
+const (
+	JudgeKindLLM       = "llm"        // a model scores the answer using the judge's system prompt
+	JudgeKindCodeCheck = "code_check" // scores by whether the answer's Go and JSON code blocks parse; no provider needed
+)
+
+// Normalizers decide when the majority algorithm counts two answers as the same
+const (
+	NormalizerExact    = "exact"    // byte-for-byte equal
+	NormalizerTrimmed  = "trimmed"  // equal ignoring surrounding whitespace and letter case
+	NormalizerCode     = "code"     // same code, ignoring whitespace and any prose around the code blocks
+	NormalizerSemantic = "semantic" // embeddings at least similarity_threshold apart, by cosine similarity
+)
+
+// Embedding fallbacks decide what semantic grouping does when embeddings can't be had
+const (
+	EmbeddingFallbackLexical = "lexical" // group by word overlap instead, noting it in the reasoning
+	EmbeddingFallbackFail    = "fail"    // fail the consensus
+)
+
+// SlashCommands are interactive mode's built-in commands, without the
+// slash; templates, typed as /name, can't take their names
+var SlashCommands = []string{"explain", "model"}
+
+// Low-confidence policies decide what happens to a consensus below min_confidence
+const (
+	LowConfidenceFlag     = "flag"     // return it marked for review
+	LowConfidenceResample = "resample" // ask every worker once more, then reach consensus again
+	LowConfidenceEscalate = "escalate" // ask escalation_provider as well, then reach consensus again
+)
+
+// Prompt preprocessors transform prompts before they reach the workers
+const (
+	PreprocessorTemplate   = "template"    // expands {{selection}}, {{active_file}} and {{workspace}} from the editor
+	PreprocessorIDEContext = "ide_context" // adds the editor's project context to plans, and to runs given one (run --use-ide-context)
+	PreprocessorFiles      = "files"       // attaches workspace files the prompt mentions, per file_references
+)
+
+// DefaultPreprocessors is the pipeline used when prompt.preprocessors is unset
+var DefaultPreprocessors = []string{PreprocessorTemplate, PreprocessorIDEContext, PreprocessorFiles}
+
 // Consensus defines how to reach consensus among workers
 type Consensus struct {
-	Algorithm string        `koanf:"algorithm"` // majority, score_top1, embedding_cluster, referee
-	MinScore  float64       `koanf:"min_score"`
-	Timeout   time.Duration `koanf:"timeout"`
+	Algorithm   string        `koanf:"algorithm"` // majority, score_top1, json_merge, embedding_cluster, referee
+	MinScore    float64       `koanf:"min_score"`
+	Timeout     time.Duration `koanf:"timeout"`      // overall cap on a run, covering every phase but a low-confidence second round
+	IdleTimeout time.Duration `koanf:"idle_timeout"` // max gap between streamed chunks before a worker is abandoned
+	TieBreaker  string        `koanf:"tie_breaker"`  // order, lowest_cost, lowest_latency, priority, shortest, longest
+	Priority    []string      `koanf:"priority"`     // worker IDs in preference order, used by the priority tie-breaker
+
+	MaxParticipants int `koanf:"max_participants"` // most answers consensus considers; beyond it a diverse subset is chosen (0: no limit)
+
+	JudgeAggregation string `koanf:"judge_aggregation"` // how judges' scores combine into a worker's score: mean, median, min, max, trimmed_mean
+
+	TruncatedPenalty float64 `koanf:"truncated_penalty"` // score_top1 points taken off answers cut off at max_tokens
+	MinConfidence    float64 `koanf:"min_confidence"`    // consensus confidence below which a run is flagged for review (0 disables)
+
+	OnLowConfidence    string `koanf:"on_low_confidence"`   // flag, resample or escalate a consensus below min_confidence
+	EscalationProvider string `koanf:"escalation_provider"` // stronger provider the escalate policy asks
+
+	EscalationTimeout time.Duration `koanf:"escalation_timeout"` // max time for a low-confidence second round, on top of timeout (default: worker_timeout plus judging_timeout)
+
+	CostWeight    float64 `koanf:"cost_weight"`    // score_top1 near-ties: points taken off the costliest answer, none off the cheapest
+	LatencyWeight float64 `koanf:"latency_weight"` // score_top1 near-ties: points taken off the slowest answer, none off the fastest
+
+	Normalizer          string  `koanf:"normalizer"`           // how majority groups equal answers: exact, trimmed, code, semantic
+	EmbeddingProvider   string  `koanf:"embedding_provider"`   // provider whose embeddings the semantic normalizer uses
+	SimilarityThreshold float64 `koanf:"similarity_threshold"` // cosine similarity at which the semantic normalizer counts answers as the same
+	EmbeddingFallback   string  `koanf:"embedding_fallback"`   // lexical or fail, when the semantic normalizer gets no embeddings
+
+	JudgeTimeout time.Duration `koanf:"judge_timeout"` // max time for a single judge attempt
+	JudgeRetries int           `koanf:"judge_retries"` // extra attempts after a failed judge call (-1 disables)
+
+	JudgeConcurrency int `koanf:"judge_concurrency"` // judge calls in flight at once across all workers (-1: no limit)
+
+	// Phase budgets within Timeout; unset ones are derived in setDefaults
+	WorkerTimeout  time.Duration `koanf:"worker_timeout"`  // max time for each worker to answer
+	JudgingTimeout time.Duration `koanf:"judging_timeout"` // max time to judge a worker once it has answered
+
+	MaxResponseBytes int `koanf:"max_response_bytes"` // largest response kept from a worker or judge (-1 disables)
+}
+
+// setPhaseDefaults splits Timeout between the worker and judging phases when
+// they aren't set. Judging gets one judge timeout, at most half the run, and
+// workers get the rest; without judging, workers get the whole run.
+func (c *Consensus) setPhaseDefaults(judged bool) {
+	if !judged {
+		if c.WorkerTimeout == 0 {
+			c.WorkerTimeout = c.Timeout
+		}
+		return
+	}
+
+	switch {
+	case c.WorkerTimeout == 0 && c.JudgingTimeout == 0:
+		c.JudgingTimeout = min(c.JudgeTimeout, c.Timeout/2)
+		c.WorkerTimeout = c.Timeout - c.JudgingTimeout
+	case c.WorkerTimeout == 0:
+		c.WorkerTimeout = c.Timeout - c.JudgingTimeout
+	case c.JudgingTimeout == 0:
+		c.JudgingTimeout = c.Timeout - c.WorkerTimeout
+	}
+}
+
+// CircuitBreaker configures fast failure for providers that keep failing
+type CircuitBreaker struct {
+	Threshold int           `koanf:"threshold"` // consecutive auth/network failures before tripping (-1 disables)
+	Cooldown  time.Duration `koanf:"cooldown"`  // how long to skip the provider before trying it again
 }
 
 // Cache configuration
 type Cache struct {
-	Dir     string `koanf:"dir"`
+	Dir     string `koanf:"dir"` // also holds checkpoints of unfinished runs; ~/ is the home directory
 	Enabled bool   `koanf:"enabled"`
 }
 
 // Logging configuration
 type Logging struct {
-	Level string `koanf:"level"` // debug, info, warn, error
+	Level  string `koanf:"level"`   // debug, info, warn, error
+	RunLog string `koanf:"run_log"` // JSONL file devgru run and serve append every run to ("" to not log runs)
 }
 
 // IDE integration configuration
@@ -73,6 +317,62 @@ type IDE struct {
 	Transport string `koanf:"transport"` // websocket or stdio
 	DiffTool  string `koanf:"diff_tool"` // auto, vscode, or disabled
 	Port      int    `koanf:"port"`      // WebSocket port (default: 8123)
+
+	BindAddress string `koanf:"bind_address"`             // interface to listen on (default: 127.0.0.1)
+	AuthToken   string `koanf:"auth_token" secret:"true"` // required from clients; mandatory off loopback
+
+	Context IDEContext `koanf:"context"`
+}
+
+// Serve configures the HTTP API started by `devgru serve`
+type Serve struct {
+	BindAddress string `koanf:"bind_address"`             // interface to listen on (default: 127.0.0.1)
+	Port        int    `koanf:"port"`                     // HTTP port (default: 8765)
+	AuthToken   string `koanf:"auth_token" secret:"true"` // bearer token required from clients; mandatory off loopback
+	MaxInFlight int    `koanf:"max_in_flight"`            // requests run at once (default: 2)
+	QueueSize   int    `koanf:"queue_size"`               // requests waiting for a turn before new ones get 429 (default: 8, -1 for none)
+	SavePlans   bool   `koanf:"save_plans"`               // save plans generated for /plan to plans/*.md, as the CLI does (default: false)
+}
+
+// IDEContext limits how much editor context is injected into prompts
+type IDEContext struct {
+	OpenFilesTokens int `koanf:"open_files_tokens"` // token budget for the open-files list
+	MaxOpenFiles    int `koanf:"max_open_files"`    // open files listed, before the token budget applies
+	MaxDiagnostics  int `koanf:"max_diagnostics"`   // most recent diagnostics kept and injected
+
+	MinSeverity string `koanf:"min_severity"` // least severe diagnostic kept: error, warning, info or hint
+}
+
+// FileReferences controls attaching workspace files mentioned in a prompt
+type FileReferences struct {
+	MaxTokens int `koanf:"max_tokens"` // token budget for attached files (default: 8000, -1 disables attaching)
+}
+
+// Prompt configures how prompts are prepared before they reach the workers
+type Prompt struct {
+	Preprocessors []string `koanf:"preprocessors"` // applied in order (default: template, ide_context, files; [] for none)
+}
+
+// Display configures how results are rendered
+type Display struct {
+	MaxWorkerChars int  `koanf:"max_worker_chars"` // preview length per worker in interactive mode (-1: no limit)
+	ShowPlanPrompt bool `koanf:"show_plan_prompt"` // repeat the originating request at the top of plans in interactive mode
+}
+
+// Cost configures the pre-flight cost check
+type Cost struct {
+	ConfirmAbove float64 `koanf:"confirm_above"` // ask before runs whose worst-case cost exceeds this many dollars (-1 never asks)
+}
+
+// Planning chooses the worker that drafts plans; execution still fans out to every worker
+type Planning struct {
+	WorkerID   string `koanf:"worker_id"`    // worker that generates plans; defaults to the first worker
+	StepByStep bool   `koanf:"step_by_step"` // interactive mode runs plan steps one at a time, pausing between them
+}
+
+// NeedsConfirmation reports whether a run with this worst-case cost should be confirmed first
+func (c Cost) NeedsConfirmation(maxCost float64) bool {
+	return c.ConfirmAbove >= 0 && maxCost > c.ConfirmAbove
 }
 
 // Load loads configuration from the specified file path
@@ -97,6 +397,8 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	config.resolveSecretsFile(configPath)
+
 	// Post-process and validate
 	if err := config.postProcess(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -120,11 +422,13 @@ func LoadDefault() (*Config, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("no config file found in default locations: %v", locations)
+	return nil, fmt.Errorf("%w in default locations: %v", ErrNoConfig, locations)
 }
 
 // postProcess handles validation and environment variable substitution
 func (c *Config) postProcess() error {
+	c.inferProviderKinds()
+
 	// Set defaults
 	c.setDefaults()
 
@@ -133,10 +437,71 @@ func (c *Config) postProcess() error {
 		return err
 	}
 
-	// Inject API keys from environment variables
-	c.injectAPIKeys()
+	// Inject API keys from the secrets file and environment variables
+	return c.injectAPIKeys()
+}
 
-	return nil
+// modelKinds maps model names to the provider kind serving them. A name
+// ending in "-" matches as a prefix; any other matches exactly or followed
+// by "-", so o3 covers o3-mini but not an unrelated o3de.
+var modelKinds = []struct {
+	name string
+	kind string
+}{
+	{"gpt-", "openai"},
+	{"chatgpt-", "openai"},
+	{"o1", "openai"},
+	{"o3", "openai"},
+	{"o4", "openai"},
+	{"text-embedding-", "openai"},
+	{"claude-", "anthropic"},
+}
+
+// defaultBaseURLs are the API endpoints used by providers whose kind was inferred
+var defaultBaseURLs = map[string]string{
+	"openai":    openai.DefaultBaseURL,
+	"anthropic": anthropic.DefaultBaseURL,
+}
+
+// KindForModel returns the provider kind serving a model, judged by its name,
+// or "" when the name isn't recognized
+func KindForModel(model string) string {
+	model = strings.ToLower(model)
+	for _, known := range modelKinds {
+		if strings.HasSuffix(known.name, "-") {
+			if strings.HasPrefix(model, known.name) {
+				return known.kind
+			}
+		} else if model == known.name || strings.HasPrefix(model, known.name+"-") {
+			return known.kind
+		}
+	}
+	return ""
+}
+
+// inferProviderKinds fills in the kind, and a missing base_url, of providers
+// that leave kind out but name a recognizable model. An explicit kind is
+// always kept; an unrecognized model still fails validation.
+func (c *Config) inferProviderKinds() {
+	for name, provider := range c.Providers {
+		if provider.Kind != "" {
+			continue
+		}
+		kind := KindForModel(provider.Model)
+		if kind == "" {
+			continue
+		}
+
+		provider.Kind = kind
+		if provider.BaseURL == "" {
+			provider.BaseURL = defaultBaseURLs[kind]
+		}
+		c.Providers[name] = provider
+
+		if c.Logging.Level != "warn" && c.Logging.Level != "error" {
+			fmt.Fprintf(os.Stderr, "devgru: provider %s has no kind; using %s for model %s (set kind to override)\n", name, kind, provider.Model)
+		}
+	}
 }
 
 // setDefaults sets sensible defaults for missing configuration
@@ -145,6 +510,9 @@ func (c *Config) setDefaults() {
 	if c.Cache.Dir == "" {
 		homeDir, _ := os.UserHomeDir()
 		c.Cache.Dir = filepath.Join(homeDir, ".devgru", "cache")
+	} else if rest, ok := strings.CutPrefix(c.Cache.Dir, "~/"); ok {
+		homeDir, _ := os.UserHomeDir()
+		c.Cache.Dir = filepath.Join(homeDir, rest)
 	}
 	if !c.Cache.Enabled {
 		c.Cache.Enabled = true
@@ -162,6 +530,52 @@ func (c *Config) setDefaults() {
 	if c.Consensus.Timeout == 0 {
 		c.Consensus.Timeout = 30 * time.Second
 	}
+	if c.Consensus.IdleTimeout == 0 {
+		c.Consensus.IdleTimeout = 20 * time.Second
+	}
+	if c.Consensus.TieBreaker == "" {
+		c.Consensus.TieBreaker = "order"
+	}
+	if c.Consensus.JudgeAggregation == "" {
+		c.Consensus.JudgeAggregation = "mean"
+	}
+	if c.Consensus.OnLowConfidence == "" {
+		c.Consensus.OnLowConfidence = LowConfidenceFlag
+	}
+	if c.Consensus.JudgeTimeout == 0 {
+		c.Consensus.JudgeTimeout = 15 * time.Second
+	}
+	if c.Consensus.JudgeRetries == 0 {
+		c.Consensus.JudgeRetries = 1
+	}
+	if c.Consensus.MaxResponseBytes == 0 {
+		c.Consensus.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if c.Consensus.JudgeConcurrency == 0 {
+		c.Consensus.JudgeConcurrency = DefaultJudgeConcurrency
+	}
+	if c.Consensus.Normalizer == "" {
+		c.Consensus.Normalizer = NormalizerExact
+	}
+	if c.Consensus.EmbeddingFallback == "" {
+		c.Consensus.EmbeddingFallback = EmbeddingFallbackLexical
+	}
+	if c.Consensus.SimilarityThreshold == 0 {
+		c.Consensus.SimilarityThreshold = DefaultSimilarity
+	}
+	c.Consensus.setPhaseDefaults(c.Consensus.Algorithm == "score_top1" && len(c.Judges) > 0)
+	if c.Consensus.EscalationTimeout == 0 {
+		// A second round asks workers and judges them again
+		c.Consensus.EscalationTimeout = c.Consensus.WorkerTimeout + c.Consensus.JudgingTimeout
+	}
+
+	// Circuit breaker defaults
+	if c.Breaker.Threshold == 0 {
+		c.Breaker.Threshold = 3
+	}
+	if c.Breaker.Cooldown == 0 {
+		c.Breaker.Cooldown = 30 * time.Second
+	}
 
 	// IDE defaults
 	if c.Ide.Transport == "" {
@@ -173,6 +587,58 @@ func (c *Config) setDefaults() {
 	if c.Ide.Port == 0 {
 		c.Ide.Port = 8123
 	}
+	if c.Ide.BindAddress == "" {
+		c.Ide.BindAddress = "127.0.0.1"
+	}
+	// HTTP API defaults
+	if c.Serve.BindAddress == "" {
+		c.Serve.BindAddress = "127.0.0.1"
+	}
+	if c.Serve.Port == 0 {
+		c.Serve.Port = 8765
+	}
+	if c.FileReferences.MaxTokens == 0 {
+		c.FileReferences.MaxTokens = DefaultFileRefTokens
+	}
+	if c.Prompt.Preprocessors == nil {
+		c.Prompt.Preprocessors = slices.Clone(DefaultPreprocessors)
+	}
+	if c.Serve.MaxInFlight == 0 {
+		c.Serve.MaxInFlight = 2
+	}
+	if c.Serve.QueueSize == 0 {
+		c.Serve.QueueSize = 8
+	}
+
+	if c.Ide.Context.OpenFilesTokens == 0 {
+		c.Ide.Context.OpenFilesTokens = DefaultOpenFilesTokens
+	}
+	if c.Ide.Context.MaxOpenFiles == 0 {
+		c.Ide.Context.MaxOpenFiles = DefaultMaxOpenFiles
+	}
+	if c.Ide.Context.MaxDiagnostics == 0 {
+		c.Ide.Context.MaxDiagnostics = DefaultMaxDiagnostics
+	}
+	if c.Ide.Context.MinSeverity == "" {
+		c.Ide.Context.MinSeverity = "warning"
+	}
+
+	// Display defaults
+	if c.Display.MaxWorkerChars == 0 {
+		c.Display.MaxWorkerChars = DefaultMaxWorkerChars
+	}
+
+	// Cost defaults
+	if c.Cost.ConfirmAbove == 0 {
+		c.Cost.ConfirmAbove = DefaultConfirmCostAbove
+	}
+
+	// Judge defaults
+	for i := range c.Judges {
+		if c.Judges[i].Kind == "" {
+			c.Judges[i].Kind = JudgeKindLLM
+		}
+	}
 
 	// Worker defaults
 	for i := range c.Workers {
@@ -207,9 +673,30 @@ func (c *Config) validate() error {
 		if _, exists := c.Providers[worker.Provider]; !exists {
 			return fmt.Errorf("worker %s references unknown provider %s", worker.ID, worker.Provider)
 		}
+		if worker.FallbackProvider != "" {
+			if _, exists := c.Providers[worker.FallbackProvider]; !exists {
+				return fmt.Errorf("worker %s references unknown fallback_provider %s", worker.ID, worker.FallbackProvider)
+			}
+			if worker.FallbackProvider == worker.Provider {
+				return fmt.Errorf("worker %s fallback_provider must differ from its provider", worker.ID)
+			}
+		}
+		if err := checkRawOptions("worker "+worker.ID, worker.RawOptions); err != nil {
+			return err
+		}
+		if err := checkSchema(worker.ResponseSchema, "response_schema"); err != nil {
+			return fmt.Errorf("worker %s %w", worker.ID, err)
+		}
 		if worker.Temperature < 0 || worker.Temperature > 2 {
 			return fmt.Errorf("worker %s temperature must be between 0 and 2", worker.ID)
 		}
+		if worker.Samples < 0 || worker.Samples > MaxSamples {
+			return fmt.Errorf("worker %s samples must be between 0 and %d", worker.ID, MaxSamples)
+		}
+	}
+
+	if c.Planning.WorkerID != "" && !slices.ContainsFunc(c.Workers, func(w Worker) bool { return w.ID == c.Planning.WorkerID }) {
+		return fmt.Errorf("planning worker_id references unknown worker %s", c.Planning.WorkerID)
 	}
 
 	// Validate judges (if any)
@@ -217,6 +704,13 @@ func (c *Config) validate() error {
 		if judge.ID == "" {
 			return fmt.Errorf("judge ID cannot be empty")
 		}
+		switch judge.Kind {
+		case JudgeKindLLM:
+		case JudgeKindCodeCheck:
+			continue // runs locally, no provider
+		default:
+			return fmt.Errorf("judge %s has invalid kind %s (valid: [llm code_check])", judge.ID, judge.Kind)
+		}
 		if judge.Provider == "" {
 			return fmt.Errorf("judge %s must specify a provider", judge.ID)
 		}
@@ -227,12 +721,25 @@ func (c *Config) validate() error {
 
 	// Validate provider configurations
 	for name, provider := range c.Providers {
-		if provider.Kind == "" {
-			return fmt.Errorf("provider %s must specify a kind", name)
-		}
 		if provider.Model == "" {
 			return fmt.Errorf("provider %s must specify a model", name)
 		}
+		if provider.Kind == "" {
+			return fmt.Errorf("provider %s must specify a kind; it can't be inferred from model %s", name, provider.Model)
+		}
+
+		if provider.RequestsPerMinute < 0 {
+			return fmt.Errorf("provider %s requests_per_minute cannot be negative", name)
+		}
+		if provider.StreamBufferBytes < 0 {
+			return fmt.Errorf("provider %s stream_buffer_bytes cannot be negative", name)
+		}
+		if err := checkRawOptions("provider "+name, provider.RawOptions); err != nil {
+			return err
+		}
+		if provider.MaxTokensParam != "" && provider.MaxTokensParam != "max_tokens" && provider.MaxTokensParam != "max_completion_tokens" {
+			return fmt.Errorf("provider %s max_tokens_param must be max_tokens or max_completion_tokens", name)
+		}
 
 		switch provider.Kind {
 		case "openai", "anthropic":
@@ -249,7 +756,7 @@ func (c *Config) validate() error {
 	}
 
 	// Validate consensus algorithm
-	validAlgorithms := []string{"majority", "score_top1", "embedding_cluster", "referee"}
+	validAlgorithms := []string{"majority", "score_top1", "json_merge", "embedding_cluster", "referee"}
 	valid := false
 	for _, alg := range validAlgorithms {
 		if c.Consensus.Algorithm == alg {
@@ -260,26 +767,242 @@ func (c *Config) validate() error {
 	if !valid {
 		return fmt.Errorf("invalid consensus algorithm: %s (valid: %v)", c.Consensus.Algorithm, validAlgorithms)
 	}
+	if c.Consensus.Algorithm == "json_merge" {
+		for _, worker := range c.Workers {
+			if worker.ResponseSchema == nil {
+				return fmt.Errorf("consensus algorithm json_merge needs JSON answers; worker %s has no response_schema", worker.ID)
+			}
+		}
+	}
+
+	if c.Consensus.IdleTimeout < 0 {
+		return fmt.Errorf("consensus idle_timeout cannot be negative")
+	}
+	if c.Consensus.JudgeTimeout < 0 {
+		return fmt.Errorf("consensus judge_timeout cannot be negative")
+	}
+	if c.Consensus.WorkerTimeout <= 0 {
+		return fmt.Errorf("consensus worker_timeout must be positive and leave time for judging within timeout %v", c.Consensus.Timeout)
+	}
+	if c.Consensus.JudgingTimeout < 0 || (c.Consensus.Algorithm == "score_top1" && len(c.Judges) > 0 && c.Consensus.JudgingTimeout == 0) {
+		return fmt.Errorf("consensus judging_timeout must be positive and leave time for workers within timeout %v", c.Consensus.Timeout)
+	}
+	if c.Consensus.WorkerTimeout+c.Consensus.JudgingTimeout > c.Consensus.Timeout {
+		return fmt.Errorf("consensus worker_timeout (%v) plus judging_timeout (%v) exceeds timeout (%v)",
+			c.Consensus.WorkerTimeout, c.Consensus.JudgingTimeout, c.Consensus.Timeout)
+	}
+	if c.Consensus.EscalationTimeout < 0 {
+		return fmt.Errorf("consensus escalation_timeout cannot be negative")
+	}
+	if c.Consensus.JudgeConcurrency < -1 {
+		return fmt.Errorf("consensus judge_concurrency must be -1 (no limit) or more")
+	}
+	if c.Consensus.TruncatedPenalty < 0 || c.Consensus.TruncatedPenalty > 10 {
+		return fmt.Errorf("consensus truncated_penalty must be between 0 and 10")
+	}
+	if c.Consensus.CostWeight < 0 || c.Consensus.CostWeight > 10 {
+		return fmt.Errorf("consensus cost_weight must be between 0 and 10")
+	}
+	if c.Consensus.LatencyWeight < 0 || c.Consensus.LatencyWeight > 10 {
+		return fmt.Errorf("consensus latency_weight must be between 0 and 10")
+	}
+	if c.Consensus.MinConfidence < 0 || c.Consensus.MinConfidence > 1 {
+		return fmt.Errorf("consensus min_confidence must be between 0 and 1")
+	}
+	switch c.Consensus.OnLowConfidence {
+	case LowConfidenceFlag:
+	case LowConfidenceResample, LowConfidenceEscalate:
+		if c.Consensus.MinConfidence <= 0 {
+			return fmt.Errorf("consensus on_low_confidence %s needs consensus.min_confidence", c.Consensus.OnLowConfidence)
+		}
+	default:
+		return fmt.Errorf("invalid consensus on_low_confidence: %s (valid: [%s %s %s])", c.Consensus.OnLowConfidence,
+			LowConfidenceFlag, LowConfidenceResample, LowConfidenceEscalate)
+	}
+	if c.Consensus.OnLowConfidence == LowConfidenceEscalate {
+		if c.Consensus.EscalationProvider == "" {
+			return fmt.Errorf("consensus on_low_confidence escalate needs consensus.escalation_provider")
+		}
+		if _, exists := c.Providers[c.Consensus.EscalationProvider]; !exists {
+			return fmt.Errorf("consensus escalation_provider references unknown provider: %s", c.Consensus.EscalationProvider)
+		}
+	}
+	switch c.Consensus.Normalizer {
+	case NormalizerExact, NormalizerTrimmed, NormalizerCode:
+	case NormalizerSemantic:
+		if c.Consensus.EmbeddingProvider == "" {
+			return fmt.Errorf("consensus normalizer semantic needs consensus.embedding_provider")
+		}
+		if p, exists := c.Providers[c.Consensus.EmbeddingProvider]; !exists {
+			return fmt.Errorf("consensus embedding_provider references unknown provider: %s", c.Consensus.EmbeddingProvider)
+		} else if p.Kind != "openai" && p.Kind != "ollama" {
+			return fmt.Errorf("consensus embedding_provider %s is a %s provider; only openai and ollama providers serve embeddings", c.Consensus.EmbeddingProvider, p.Kind)
+		}
+	default:
+		return fmt.Errorf("invalid consensus normalizer: %s (valid: [%s %s %s %s])", c.Consensus.Normalizer,
+			NormalizerExact, NormalizerTrimmed, NormalizerCode, NormalizerSemantic)
+	}
+	if c.Consensus.SimilarityThreshold < -1 || c.Consensus.SimilarityThreshold > 1 {
+		return fmt.Errorf("consensus similarity_threshold must be between -1 and 1")
+	}
+	if c.Consensus.EmbeddingFallback != EmbeddingFallbackLexical && c.Consensus.EmbeddingFallback != EmbeddingFallbackFail {
+		return fmt.Errorf("invalid consensus embedding_fallback: %s (valid: [%s %s])", c.Consensus.EmbeddingFallback,
+			EmbeddingFallbackLexical, EmbeddingFallbackFail)
+	}
+
+	// Validate IDE server address
+	if c.Ide.BindAddress != "localhost" {
+		ip := net.ParseIP(c.Ide.BindAddress)
+		if ip == nil {
+			return fmt.Errorf("invalid ide bind_address: %s (must be an IP address or localhost)", c.Ide.BindAddress)
+		}
+		if !ip.IsLoopback() && c.Ide.AuthToken == "" {
+			return fmt.Errorf("ide bind_address %s exposes the IDE server beyond this machine; set ide.auth_token", c.Ide.BindAddress)
+		}
+	}
+
+	// Validate HTTP API address
+	if c.Serve.BindAddress != "localhost" {
+		ip := net.ParseIP(c.Serve.BindAddress)
+		if ip == nil {
+			return fmt.Errorf("invalid serve bind_address: %s (must be an IP address or localhost)", c.Serve.BindAddress)
+		}
+		if !ip.IsLoopback() && c.Serve.AuthToken == "" {
+			return fmt.Errorf("serve bind_address %s exposes the API beyond this machine; set serve.auth_token", c.Serve.BindAddress)
+		}
+	}
+	if c.FileReferences.MaxTokens < -1 {
+		return fmt.Errorf("file_references max_tokens must be -1 (disabled) or more")
+	}
+	for i, name := range c.Prompt.Preprocessors {
+		if !slices.Contains(DefaultPreprocessors, name) {
+			return fmt.Errorf("unknown prompt preprocessor: %s (valid: %s)", name, strings.Join(DefaultPreprocessors, ", "))
+		}
+		if slices.Contains(c.Prompt.Preprocessors[:i], name) {
+			return fmt.Errorf("prompt preprocessor %s is listed twice", name)
+		}
+	}
+	for name, template := range c.Templates {
+		if name == "" || strings.ContainsAny(name, " \t\n/") {
+			return fmt.Errorf("template name %q must be a single word", name)
+		}
+		for _, command := range SlashCommands {
+			if strings.EqualFold(name, command) {
+				return fmt.Errorf("template name %s is taken by the built-in /%s command; rename the template", name, command)
+			}
+		}
+		if strings.TrimSpace(template) == "" {
+			return fmt.Errorf("template %s is empty", name)
+		}
+	}
+	if c.Serve.MaxInFlight < 1 {
+		return fmt.Errorf("serve max_in_flight must be at least 1")
+	}
+	if c.Serve.QueueSize < -1 {
+		return fmt.Errorf("serve queue_size must be -1 (no queue) or more")
+	}
+
+	// Validate IDE context limits
+	if c.Ide.Context.MaxOpenFiles < 0 || c.Ide.Context.MaxDiagnostics < 0 || c.Ide.Context.OpenFilesTokens < 0 {
+		return fmt.Errorf("ide context limits cannot be negative")
+	}
+	switch c.Ide.Context.MinSeverity {
+	case "error", "warning", "info", "hint":
+	default:
+		return fmt.Errorf("invalid ide context min_severity: %s (valid: [error warning info hint])", c.Ide.Context.MinSeverity)
+	}
+
+	// Validate tie-breaker
+	switch c.Consensus.TieBreaker {
+	case "order", "lowest_cost", "lowest_latency", "shortest", "longest":
+	case "priority":
+		if len(c.Consensus.Priority) == 0 {
+			return fmt.Errorf("tie_breaker priority requires a consensus.priority list of worker IDs")
+		}
+	default:
+		return fmt.Errorf("invalid consensus tie_breaker: %s (valid: [order lowest_cost lowest_latency priority shortest longest])", c.Consensus.TieBreaker)
+	}
+	for _, id := range c.Consensus.Priority {
+		if _, err := c.GetWorkerByID(id); err != nil {
+			return fmt.Errorf("consensus priority references unknown worker %s", id)
+		}
+	}
+
+	if c.Consensus.MaxParticipants < 0 {
+		return fmt.Errorf("consensus max_participants cannot be negative")
+	}
+
+	switch c.Consensus.JudgeAggregation {
+	case "mean", "median", "min", "max", "trimmed_mean":
+	default:
+		return fmt.Errorf("invalid consensus judge_aggregation: %s (valid: [mean median min max trimmed_mean])", c.Consensus.JudgeAggregation)
+	}
 
 	return nil
 }
 
-// injectAPIKeys populates API keys from environment variables
-func (c *Config) injectAPIKeys() {
+// injectAPIKeys populates API keys. The secrets file wins over the
+// environment, which wins over an inline api_key; in the secrets file an entry
+// named after the provider wins over its kind's variable (OPENAI_API_KEY).
+func (c *Config) injectAPIKeys() error {
+	var secrets map[string]string
+	if c.SecretsFile != "" {
+		var err error
+		if secrets, err = readSecrets(c.SecretsFile); err != nil {
+			return err
+		}
+	}
+
 	for name, provider := range c.Providers {
-		switch provider.Kind {
-		case "openai":
-			if key := os.Getenv("OPENAI_API_KEY"); key != "" {
-				provider.APIKey = key
-				c.Providers[name] = provider
-			}
-		case "anthropic":
-			if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
+		envVar := APIKeyEnvVar(provider.Kind)
+		if envVar == "" {
+			continue
+		}
+		for _, key := range []string{secrets[name], secrets[envVar], os.Getenv(envVar)} {
+			if key != "" {
 				provider.APIKey = key
 				c.Providers[name] = provider
+				break
 			}
 		}
 	}
+	return nil
+}
+
+// APIKeyEnvVar returns the environment variable holding the API key for a
+// provider kind, or "" for kinds that don't need one
+func APIKeyEnvVar(kind string) string {
+	switch kind {
+	case "openai":
+		return "OPENAI_API_KEY"
+	case "anthropic":
+		return "ANTHROPIC_API_KEY"
+	default:
+		return ""
+	}
+}
+
+// Planner returns the worker that generates plans: planning.worker_id when set,
+// otherwise the first worker
+func (c *Config) Planner() Worker {
+	for _, worker := range c.Workers {
+		if worker.ID == c.Planning.WorkerID {
+			return worker
+		}
+	}
+	return c.Workers[0]
+}
+
+// MissingAPIKeys returns the names of providers that need an API key but have none, sorted
+func (c *Config) MissingAPIKeys() []string {
+	var missing []string
+	for name, provider := range c.Providers {
+		if APIKeyEnvVar(provider.Kind) != "" && provider.APIKey == "" {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
 }
 
 // GetWorkerByID returns a worker by its ID
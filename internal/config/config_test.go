@@ -0,0 +1,226 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loadYAML loads a devgru.yaml holding yamlText, with a test API key
+func loadYAML(t *testing.T, yamlText string) (*Config, error) {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	path := filepath.Join(t.TempDir(), "devgru.yaml")
+	if err := os.WriteFile(path, []byte(yamlText), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+const phaseTestConfig = `
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: https://api.openai.com/v1
+workers:
+  - id: worker
+    provider: openai
+judges:
+  - id: judge
+    provider: openai
+    system_prompt: Score the answer.
+consensus:
+  algorithm: score_top1
+  timeout: 45s
+  judge_timeout: 15s
+`
+
+func TestSetPhaseDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		consensus   Consensus
+		judged      bool
+		wantWorker  time.Duration
+		wantJudging time.Duration
+	}{
+		{
+			name:        "judging gets one judge timeout",
+			consensus:   Consensus{Timeout: 45 * time.Second, JudgeTimeout: 15 * time.Second},
+			judged:      true,
+			wantWorker:  30 * time.Second,
+			wantJudging: 15 * time.Second,
+		},
+		{
+			name:        "judging gets at most half the run",
+			consensus:   Consensus{Timeout: 20 * time.Second, JudgeTimeout: 15 * time.Second},
+			judged:      true,
+			wantWorker:  10 * time.Second,
+			wantJudging: 10 * time.Second,
+		},
+		{
+			name:        "workers get what judging leaves",
+			consensus:   Consensus{Timeout: 45 * time.Second, JudgeTimeout: 15 * time.Second, JudgingTimeout: 5 * time.Second},
+			judged:      true,
+			wantWorker:  40 * time.Second,
+			wantJudging: 5 * time.Second,
+		},
+		{
+			name:        "judging gets what workers leave",
+			consensus:   Consensus{Timeout: 45 * time.Second, JudgeTimeout: 15 * time.Second, WorkerTimeout: 20 * time.Second},
+			judged:      true,
+			wantWorker:  20 * time.Second,
+			wantJudging: 25 * time.Second,
+		},
+		{
+			name:        "without judging workers get the whole run",
+			consensus:   Consensus{Timeout: 45 * time.Second, JudgeTimeout: 15 * time.Second},
+			judged:      false,
+			wantWorker:  45 * time.Second,
+			wantJudging: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.consensus
+			c.setPhaseDefaults(tt.judged)
+			if c.WorkerTimeout != tt.wantWorker || c.JudgingTimeout != tt.wantJudging {
+				t.Errorf("worker_timeout, judging_timeout = %v, %v; want %v, %v",
+					c.WorkerTimeout, c.JudgingTimeout, tt.wantWorker, tt.wantJudging)
+			}
+		})
+	}
+}
+
+func TestLoadSplitsTimeoutBetweenPhases(t *testing.T) {
+	cfg, err := loadYAML(t, phaseTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Consensus.WorkerTimeout != 30*time.Second || cfg.Consensus.JudgingTimeout != 15*time.Second {
+		t.Errorf("worker_timeout, judging_timeout = %v, %v; want 30s, 15s",
+			cfg.Consensus.WorkerTimeout, cfg.Consensus.JudgingTimeout)
+	}
+}
+
+func TestLoadRejectsPhaseBudgetsOverTimeout(t *testing.T) {
+	_, err := loadYAML(t, phaseTestConfig+"  worker_timeout: 35s\n  judging_timeout: 15s\n")
+	if err == nil {
+		t.Fatal("Load succeeded, want worker_timeout + judging_timeout > timeout rejected")
+	}
+	if !strings.Contains(err.Error(), "exceeds timeout") {
+		t.Errorf("error = %v, want it to say the budgets exceed timeout", err)
+	}
+}
+
+func TestLoadDefaultWrapsErrNoConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := LoadDefault()
+	if !errors.Is(err, ErrNoConfig) {
+		t.Fatalf("LoadDefault error = %v, want it to wrap ErrNoConfig", err)
+	}
+}
+
+func TestLoadChecksEmbeddingProviderKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		wantErr  bool
+	}{
+		{name: "openai", provider: "openai"},
+		{name: "ollama", provider: "local"},
+		{name: "anthropic", provider: "claude", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANTHROPIC_API_KEY", "test-key")
+			_, err := loadYAML(t, `
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: https://api.openai.com/v1
+  claude:
+    kind: anthropic
+    model: claude-3-5-sonnet-20241022
+    base_url: https://api.anthropic.com/v1
+  local:
+    kind: ollama
+    model: llama3.1
+    host: http://localhost:11434
+    embedding_model: nomic-embed-text
+workers:
+  - id: worker
+    provider: openai
+consensus:
+  algorithm: majority
+  normalizer: semantic
+  embedding_provider: `+tt.provider+"\n")
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Load error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsTemplatesNamedAfterCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "walkthrough"},
+		{name: "explain", wantErr: true},
+		{name: "Model", wantErr: true}, // commands are matched case-insensitively
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadYAML(t, `
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: https://api.openai.com/v1
+workers:
+  - id: worker
+    provider: openai
+consensus:
+  algorithm: majority
+templates:
+  `+tt.name+`: Explain {{file}}.
+`)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Load error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKindForModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{model: "gpt-4o-mini", want: "openai"},
+		{model: "o3", want: "openai"},
+		{model: "o3-mini", want: "openai"},
+		{model: "O1-Preview", want: "openai"},
+		{model: "o3de", want: ""},
+		{model: "o4x-large", want: ""},
+		{model: "claude-3-5-sonnet-latest", want: "anthropic"},
+		{model: "llama3.1", want: ""},
+	}
+	for _, tt := range tests {
+		if got := KindForModel(tt.model); got != tt.want {
+			t.Errorf("KindForModel(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoConfig is returned by LoadDefault when none of the default locations
+// holds a config file
+var ErrNoConfig = errors.New("no config file found")
+
+// SetupProvider is a provider chosen when creating a config
+type SetupProvider struct {
+	Kind  string // openai or anthropic
+	Model string
+}
+
+// SetupKinds are the provider kinds a new config can be created with
+var SetupKinds = []string{"openai", "anthropic"}
+
+// DefaultSetupModels are the models a new config suggests for each kind
+var DefaultSetupModels = map[string]string{
+	"openai":    "gpt-4o-mini",
+	"anthropic": "claude-3-5-sonnet-20241022",
+}
+
+// DetectedProviders returns a provider with its suggested model for each kind
+// whose API key is set in the environment, or OpenAI when none is
+func DetectedProviders() []SetupProvider {
+	var providers []SetupProvider
+	for _, kind := range SetupKinds {
+		if os.Getenv(APIKeyEnvVar(kind)) != "" {
+			providers = append(providers, SetupProvider{Kind: kind, Model: DefaultSetupModels[kind]})
+		}
+	}
+	if len(providers) == 0 {
+		providers = []SetupProvider{{Kind: "openai", Model: DefaultSetupModels["openai"]}}
+	}
+	return providers
+}
+
+// Scaffold renders a starter devgru.yaml for the given providers: a worker
+// on each (a creative and an analytical one when there is only one), a judge
+// on the first, and score_top1 consensus. API keys stay in the environment.
+func Scaffold(providers []SetupProvider) ([]byte, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("choose at least one provider")
+	}
+
+	var b strings.Builder
+	b.WriteString("# devgru.yaml - created by devgru init; see the devgru readme for every option\n\n")
+	b.WriteString("# API keys are read from the environment (OPENAI_API_KEY, ANTHROPIC_API_KEY)\n")
+	b.WriteString("providers:\n")
+	seen := make(map[string]bool)
+	for _, p := range providers {
+		baseURL, known := defaultBaseURLs[p.Kind]
+		if !known {
+			return nil, fmt.Errorf("unsupported provider kind %q (valid: %v)", p.Kind, SetupKinds)
+		}
+		if seen[p.Kind] {
+			return nil, fmt.Errorf("provider %s chosen twice", p.Kind)
+		}
+		seen[p.Kind] = true
+		if strings.TrimSpace(p.Model) == "" {
+			return nil, fmt.Errorf("provider %s needs a model", p.Kind)
+		}
+		fmt.Fprintf(&b, "  %s:\n    kind: %s\n    model: %s\n    base_url: %s\n", p.Kind, p.Kind, strings.TrimSpace(p.Model), baseURL)
+	}
+
+	b.WriteString("\nworkers:\n")
+	if len(providers) == 1 {
+		kind := providers[0].Kind
+		fmt.Fprintf(&b, "  - id: creative\n    provider: %s\n    temperature: 0.8\n    system_prompt: \"You are a creative assistant.\"\n\n", kind)
+		fmt.Fprintf(&b, "  - id: analytical\n    provider: %s\n    temperature: 0.2\n    system_prompt: \"You are an analytical assistant.\"\n", kind)
+	} else {
+		for i, p := range providers {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "  - id: %s-worker\n    provider: %s\n    temperature: 0.5\n", p.Kind, p.Kind)
+		}
+	}
+
+	fmt.Fprintf(&b, `
+judges:
+  - id: quality-judge
+    provider: %s
+    system_prompt: |
+      Grade responses 0-10 for accuracy and clarity.
+      Respond with: {"score": <int>, "reason": "<text>"}
+
+consensus:
+  algorithm: score_top1
+  min_score: 6
+  timeout: 45s
+`, providers[0].Kind)
+
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScaffoldLoads(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []SetupProvider
+		workers   []string
+	}{
+		{
+			name:      "one provider",
+			providers: []SetupProvider{{Kind: "openai", Model: "gpt-4o-mini"}},
+			workers:   []string{"creative", "analytical"},
+		},
+		{
+			name:      "two providers",
+			providers: []SetupProvider{{Kind: "anthropic", Model: " claude-3-5-sonnet-20241022 "}, {Kind: "openai", Model: "gpt-4o"}},
+			workers:   []string{"anthropic-worker", "openai-worker"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Scaffold(tt.providers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "devgru.yaml")
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatalf("scaffolded config doesn't load: %v\n%s", err, data)
+			}
+			if len(cfg.Workers) != len(tt.workers) {
+				t.Fatalf("%d workers, want %v", len(cfg.Workers), tt.workers)
+			}
+			for i, id := range tt.workers {
+				if cfg.Workers[i].ID != id {
+					t.Errorf("worker %d = %s, want %s", i, cfg.Workers[i].ID, id)
+				}
+			}
+			for _, p := range tt.providers {
+				provider, ok := cfg.Providers[p.Kind]
+				if !ok {
+					t.Fatalf("no %s provider", p.Kind)
+				}
+				if provider.Model != strings.TrimSpace(p.Model) {
+					t.Errorf("%s model = %q, want %q", p.Kind, provider.Model, p.Model)
+				}
+			}
+			if len(cfg.Judges) != 1 || cfg.Judges[0].Provider != tt.providers[0].Kind {
+				t.Errorf("judges = %+v, want one on %s", cfg.Judges, tt.providers[0].Kind)
+			}
+		})
+	}
+}
+
+func TestScaffoldRejectsBadChoices(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []SetupProvider
+	}{
+		{name: "none"},
+		{name: "unknown kind", providers: []SetupProvider{{Kind: "ollama", Model: "llama3.1"}}},
+		{name: "chosen twice", providers: []SetupProvider{{Kind: "openai", Model: "gpt-4o"}, {Kind: "openai", Model: "gpt-4o-mini"}}},
+		{name: "no model", providers: []SetupProvider{{Kind: "anthropic", Model: "  "}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if data, err := Scaffold(tt.providers); err == nil {
+				t.Errorf("want an error, got:\n%s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// readSecrets parses a secrets file: NAME=value lines, where NAME is a
+// provider name or an API key variable such as OPENAI_API_KEY. Blank lines,
+// # comments, an "export " prefix and quotes around the value are allowed,
+// so an existing .env file can be reused.
+func readSecrets(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open secrets_file: %w", err)
+	}
+	defer f.Close()
+
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			// Don't echo the line; it may hold a key
+			return nil, fmt.Errorf("secrets_file %s line %d: expected NAME=value", path, lineNum)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		secrets[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read secrets_file: %w", err)
+	}
+
+	if info, err := f.Stat(); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		fmt.Fprintf(os.Stderr, "devgru: secrets_file %s is readable by other users; run chmod 600 %s\n", path, path)
+	}
+	return secrets, nil
+}
+
+// resolveSecretsFile expands ~/ in secrets_file and makes a relative path
+// relative to the directory of the config file naming it
+func (c *Config) resolveSecretsFile(configPath string) {
+	if c.SecretsFile == "" {
+		return
+	}
+	if rest, ok := strings.CutPrefix(c.SecretsFile, "~/"); ok {
+		homeDir, _ := os.UserHomeDir()
+		c.SecretsFile = filepath.Join(homeDir, rest)
+	} else if !filepath.IsAbs(c.SecretsFile) {
+		c.SecretsFile = filepath.Join(filepath.Dir(configPath), c.SecretsFile)
+	}
+}
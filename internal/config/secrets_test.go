@@ -0,0 +1,109 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSecrets(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secrets.env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadSecrets(t *testing.T) {
+	path := writeSecrets(t, `
+# keys for devgru
+OPENAI_API_KEY=sk-plain
+export ANTHROPIC_API_KEY = "sk-quoted"
+local='single quoted'
+empty=
+url=https://example.com/?a=b
+`)
+
+	secrets, err := readSecrets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"OPENAI_API_KEY":    "sk-plain",
+		"ANTHROPIC_API_KEY": "sk-quoted",
+		"local":             "single quoted",
+		"empty":             "",
+		"url":               "https://example.com/?a=b",
+	}
+	if len(secrets) != len(want) {
+		t.Errorf("read %d secrets, want %d: %v", len(secrets), len(want), secrets)
+	}
+	for name, value := range want {
+		if got, ok := secrets[name]; !ok || got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestReadSecretsRejectsMalformedLines(t *testing.T) {
+	path := writeSecrets(t, "OPENAI_API_KEY=sk-ok\nsk-pasted-without-a-name\n")
+
+	_, err := readSecrets(path)
+	if err == nil {
+		t.Fatal("want an error for a line without NAME=")
+	}
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error = %v, want the line number", err)
+	}
+	if strings.Contains(err.Error(), "sk-pasted") {
+		t.Errorf("error = %v, want the line itself left out", err)
+	}
+}
+
+func TestReadSecretsMissingFile(t *testing.T) {
+	if _, err := readSecrets(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("want an error for a missing secrets_file")
+	}
+}
+
+func TestSecretsFileWinsOverEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	secrets := "OPENAI_API_KEY=from-file\nbackup=from-provider-entry\n"
+	if err := os.WriteFile(filepath.Join(dir, "secrets.env"), []byte(secrets), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "devgru.yaml")
+	if err := os.WriteFile(path, []byte(`
+secrets_file: secrets.env
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: https://api.openai.com/v1
+  backup:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: https://api.openai.com/v1
+workers:
+  - id: worker
+    provider: openai
+consensus:
+  algorithm: majority
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OPENAI_API_KEY", "from-env")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Providers["openai"].APIKey; got != "from-file" {
+		t.Errorf("openai key = %q, want the secrets file's OPENAI_API_KEY", got)
+	}
+	if got := cfg.Providers["backup"].APIKey; got != "from-provider-entry" {
+		t.Errorf("backup key = %q, want the entry named after the provider", got)
+	}
+}
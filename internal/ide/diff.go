@@ -0,0 +1,154 @@
+package ide
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/evisdrenova/devgru/internal/linediff"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// noNewline marks a patch line that has no newline at the end of the file
+const noNewline = "\\ No newline at end of file\n"
+
+// NewDiffResult builds a DiffResult with a unified patch between the two contents
+func NewDiffResult(file, origContent, newContent string) DiffResult {
+	return DiffResult{
+		File:        file,
+		OrigContent: origContent,
+		NewContent:  newContent,
+		Patch:       unifiedDiff(file, origContent, newContent),
+		Language:    strings.TrimPrefix(filepath.Ext(file), "."),
+	}
+}
+
+// unifiedDiff renders a unified diff of two texts, with diffContext lines of
+// context around each hunk, as git apply and patch accept it. Equal texts
+// give an empty diff.
+func unifiedDiff(file, a, b string) string {
+	aLines := splitLines(a)
+	bLines := splitLines(b)
+	// Lines keep their newline, so a last line without one differs from
+	// the same line with one
+	ops := linediff.Lines(len(aLines), len(bLines), func(i, j int) bool {
+		return aLines[i] == bLines[j]
+	})
+
+	origName := "a/" + file
+	if a == "" {
+		origName = "/dev/null"
+	}
+
+	var patch strings.Builder
+	for _, h := range hunks(ops) {
+		if patch.Len() == 0 {
+			fmt.Fprintf(&patch, "--- %s\n+++ b/%s\n", origName, file)
+		}
+		fmt.Fprintf(&patch, "@@ -%s +%s @@\n", hunkRange(h.aStart, h.aCount), hunkRange(h.bStart, h.bCount))
+
+		i, j := h.aStart, h.bStart
+		for _, op := range ops[h.start:h.end] {
+			switch op {
+			case linediff.Equal:
+				writePatchLine(&patch, ' ', aLines[i])
+				i++
+				j++
+			case linediff.Delete:
+				writePatchLine(&patch, '-', aLines[i])
+				i++
+			case linediff.Insert:
+				writePatchLine(&patch, '+', bLines[j])
+				j++
+			}
+		}
+	}
+	return patch.String()
+}
+
+// writePatchLine writes a line of a hunk, marking a missing final newline
+func writePatchLine(patch *strings.Builder, prefix byte, line string) {
+	patch.WriteByte(prefix)
+	patch.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		patch.WriteString("\n" + noNewline)
+	}
+}
+
+// hunk is a run of ops shown together, with the 0-based line each side starts at
+type hunk struct {
+	start, end     int // ops[start:end]
+	aStart, aCount int
+	bStart, bCount int
+}
+
+// hunks groups the changes in ops into hunks with diffContext lines of
+// context, merging changes whose contexts would touch or overlap
+func hunks(ops []linediff.Op) []hunk {
+	// aAt[i] and bAt[i] are the lines each side is at before ops[i]
+	aAt := make([]int, len(ops)+1)
+	bAt := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aAt[i+1], bAt[i+1] = aAt[i], bAt[i]
+		if op != linediff.Insert {
+			aAt[i+1]++
+		}
+		if op != linediff.Delete {
+			bAt[i+1]++
+		}
+	}
+
+	var result []hunk
+	for i := 0; i < len(ops); i++ {
+		if ops[i] == linediff.Equal {
+			continue
+		}
+		start := max(i-diffContext, 0)
+		if len(result) > 0 && start <= result[len(result)-1].end {
+			// Close enough to the previous hunk to share its context
+			start = result[len(result)-1].start
+			result = result[:len(result)-1]
+		}
+
+		// Take in the rest of this run of changes, then the trailing context
+		for i < len(ops) && ops[i] != linediff.Equal {
+			i++
+		}
+		end := min(i+diffContext, len(ops))
+
+		result = append(result, hunk{
+			start:  start,
+			end:    end,
+			aStart: aAt[start],
+			aCount: aAt[end] - aAt[start],
+			bStart: bAt[start],
+			bCount: bAt[end] - bAt[start],
+		})
+	}
+	return result
+}
+
+// hunkRange formats one side of a hunk header from its 0-based start line;
+// an empty side is numbered by the line before it, as diff does
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// splitLines splits text into lines, each keeping its newline; the last line
+// has none when the text doesn't end with one
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,98 @@
+package ide
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// numbered returns lines "line 1" through "line n", each ending in a newline
+func numbered(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d\n", i+1)
+	}
+	return lines
+}
+
+func TestUnifiedDiffAppliesWithGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	long := numbered(40)
+	edited := append([]string(nil), long...)
+	edited[4] = "changed 5\n"
+	edited[30] = "changed 31\n"
+
+	tests := []struct {
+		name      string
+		orig, new string
+		wantHunks int
+	}{
+		{name: "new file", orig: "", new: "package main\n", wantHunks: 1},
+		{name: "emptied file", orig: "a\nb\n", new: "", wantHunks: 1},
+		{name: "change in the middle", orig: "a\nb\nc\nd\ne\n", new: "a\nb\nC\nd\ne\n", wantHunks: 1},
+		{name: "insert at the start", orig: "a\nb\n", new: "start\na\nb\n", wantHunks: 1},
+		{name: "append at the end", orig: "a\nb\n", new: "a\nb\nend\n", wantHunks: 1},
+		{name: "distant changes get their own hunks", orig: strings.Join(long, ""), new: strings.Join(edited, ""), wantHunks: 2},
+		{name: "near changes share a hunk", orig: "1\n2\n3\n4\n5\n6\n7\n8\n9\n", new: "1\nX\n3\n4\n5\n6\n7\nY\n9\n", wantHunks: 1},
+		{name: "no newline in either", orig: "a\nb", new: "a\nc", wantHunks: 1},
+		{name: "newline added at the end", orig: "a\nb", new: "a\nb\n", wantHunks: 1},
+		{name: "newline removed at the end", orig: "a\nb\n", new: "a\nb", wantHunks: 1},
+		{name: "unchanged last line without newline", orig: "a\nb\nc\nd\ne", new: "A\nb\nc\nd\ne", wantHunks: 1},
+		{name: "everything different", orig: "a\nb\nc\n", new: "x\ny\n", wantHunks: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := unifiedDiff("file.txt", tt.orig, tt.new)
+			if got := strings.Count(patch, "\n@@ "); got != tt.wantHunks {
+				t.Errorf("%d hunks, want %d:\n%s", got, tt.wantHunks, patch)
+			}
+
+			dir := t.TempDir()
+			if tt.orig != "" {
+				if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte(tt.orig), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(dir, "change.patch"), []byte(patch), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, args := range [][]string{{"apply", "--check", "change.patch"}, {"apply", "change.patch"}} {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = dir
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %s: %v\n%s\npatch:\n%s", strings.Join(args, " "), err, out, patch)
+				}
+			}
+
+			got, err := os.ReadFile(filepath.Join(dir, "file.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.new {
+				t.Errorf("applied patch gives %q, want %q", got, tt.new)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffEqualTextsIsEmpty(t *testing.T) {
+	if patch := unifiedDiff("file.txt", "same\n", "same\n"); patch != "" {
+		t.Errorf("patch = %q, want none", patch)
+	}
+}
+
+func TestUnifiedDiffMarksMissingNewline(t *testing.T) {
+	patch := unifiedDiff("file.txt", "a\nb", "a\nb\n")
+	want := "--- a/file.txt\n+++ b/file.txt\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"
+	if patch != want {
+		t.Errorf("patch =\n%s\nwant\n%s", patch, want)
+	}
+}
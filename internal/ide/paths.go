@@ -0,0 +1,60 @@
+package ide
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// normalizePath turns a path from the editor into a clean absolute path.
+// Relative paths are resolved against root and may not climb out of it;
+// paths with control characters or a URL scheme are rejected outright.
+func normalizePath(path, root string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("empty path")
+	}
+	if strings.IndexFunc(path, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("path %q contains control characters", path)
+	}
+	if strings.Contains(path, "://") {
+		return "", fmt.Errorf("path %q is a URL, not a file path", path)
+	}
+
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	if root == "" {
+		return "", fmt.Errorf("relative path %q with no workspace root to resolve it against", path)
+	}
+
+	resolved := filepath.Join(root, path)
+	if !WithinWorkspace(root, resolved) {
+		return "", fmt.Errorf("relative path %q points outside the workspace", path)
+	}
+	return resolved, nil
+}
+
+// WithinWorkspace reports whether path is root or inside it. Both are cleaned
+// first, so "root/a/../../etc" is outside.
+func WithinWorkspace(root, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// DisplayPath shows path relative to the workspace root when it is inside
+// the workspace, and unchanged otherwise
+func (c *IDEContext) DisplayPath(path string) string {
+	if c == nil || c.WorkspaceRoot == "" || path == "" || !WithinWorkspace(c.WorkspaceRoot, path) {
+		return path
+	}
+	rel, err := filepath.Rel(c.WorkspaceRoot, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
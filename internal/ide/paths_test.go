@@ -0,0 +1,82 @@
+package ide
+
+import "testing"
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		root    string
+		want    string
+		wantErr bool
+	}{
+		{name: "absolute", path: "/work/a/../b.go", root: "/work", want: "/work/b.go"},
+		{name: "absolute without a root", path: "/work/b.go", want: "/work/b.go"},
+		{name: "relative", path: " cmd/main.go ", root: "/work", want: "/work/cmd/main.go"},
+		{name: "relative without a root", path: "cmd/main.go", wantErr: true},
+		{name: "climbs out of the workspace", path: "../etc/passwd", root: "/work", wantErr: true},
+		{name: "climbs out through a subdirectory", path: "a/../../etc", root: "/work", wantErr: true},
+		{name: "empty", path: "  ", root: "/work", wantErr: true},
+		{name: "control characters", path: "a\x00b.go", root: "/work", wantErr: true},
+		{name: "URL", path: "file:///work/b.go", root: "/work", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizePath(tt.path, tt.root)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("normalizePath(%q) = %q, want an error", tt.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("normalizePath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithinWorkspace(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/work", want: true},
+		{path: "/work/a/b.go", want: true},
+		{path: "/work/..hidden", want: true},
+		{path: "/workspace/b.go", want: false},
+		{path: "/work/a/../../etc", want: false},
+		{path: "/etc/passwd", want: false},
+	}
+	for _, tt := range tests {
+		if got := WithinWorkspace("/work/", tt.path); got != tt.want {
+			t.Errorf("WithinWorkspace(/work/, %s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	c := &IDEContext{WorkspaceRoot: "/work"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/work/cmd/main.go", want: "cmd/main.go"},
+		{path: "/other/main.go", want: "/other/main.go"},
+		{path: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := c.DisplayPath(tt.path); got != tt.want {
+			t.Errorf("DisplayPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	var none *IDEContext
+	if got := none.DisplayPath("/work/a.go"); got != "/work/a.go" {
+		t.Errorf("DisplayPath without context = %q, want the path unchanged", got)
+	}
+}
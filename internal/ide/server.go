@@ -2,10 +2,17 @@ package ide
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -23,6 +30,15 @@ func NewServer(config Config) *Server {
 	if config.Port == 0 {
 		config.Port = 8123
 	}
+	if config.BindAddress == "" {
+		config.BindAddress = "127.0.0.1"
+	}
+	if config.MaxDiagnostics == 0 {
+		config.MaxDiagnostics = 10
+	}
+	if config.MinSeverity == "" {
+		config.MinSeverity = "hint"
+	}
 
 	return &Server{
 		config:      config,
@@ -31,36 +47,92 @@ func NewServer(config Config) *Server {
 		broadcast:   make(chan []byte),
 		register:    make(chan *websocket.Conn),
 		unregister:  make(chan *websocket.Conn),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
 	}
 }
 
+// Addr returns the host:port the server listens on
+func (s *Server) Addr() string {
+	return net.JoinHostPort(s.config.BindAddress, strconv.Itoa(s.config.Port))
+}
+
+// isLoopback reports whether the server only accepts local connections
+func (s *Server) isLoopback() bool {
+	if s.config.BindAddress == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(s.config.BindAddress)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ErrPortInUse is returned when another process already holds the IDE server port
+var ErrPortInUse = errors.New("port already in use")
+
+// Listen binds the server's address without serving yet, so callers can report
+// bind failures (e.g. a second devgru instance on the same port) before going on
+func (s *Server) Listen() error {
+	if s.config.BindAddress != "localhost" && net.ParseIP(s.config.BindAddress) == nil {
+		return fmt.Errorf("invalid IDE bind address %q", s.config.BindAddress)
+	}
+	if !s.isLoopback() && s.config.AuthToken == "" {
+		return fmt.Errorf("refusing to bind IDE server to %s without an auth token", s.config.BindAddress)
+	}
+
+	listener, err := net.Listen("tcp", s.Addr())
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return fmt.Errorf("failed to bind IDE server to %s: %w", s.Addr(), ErrPortInUse)
+		}
+		return fmt.Errorf("failed to bind IDE server to %s: %w", s.Addr(), err)
+	}
+
+	s.listener = listener
+	return nil
+}
+
 // Start starts the WebSocket server
 func (s *Server) Start(ctx context.Context) error {
 	if !s.config.Enable {
 		return fmt.Errorf("IDE integration is disabled")
 	}
 
+	// Bind now unless the caller already did via Listen
+	if s.listener == nil {
+		if err := s.Listen(); err != nil {
+			return err
+		}
+	}
+	listener := s.listener
+
+	if !s.isLoopback() {
+		fmt.Fprintf(os.Stderr, "Warning: IDE server is listening on %s and reachable from other machines\n", s.Addr())
+	}
+
+	s.mu.Lock()
 	s.running = true
+	s.mu.Unlock()
 
 	// Start the hub
 	go s.run()
 
 	// Set up HTTP server
-	http.HandleFunc("/ws", s.handleWebSocket)
-	http.HandleFunc("/health", s.handleHealth)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/context", s.handleContext)
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf("127.0.0.1:%d", s.config.Port),
-		Handler: nil,
+		Handler: mux,
 	}
 
 	// Print handshake message for VS Code extension detection
 	fmt.Printf("%s\n", HandshakeMessage)
-	fmt.Printf("DevGru IDE server starting on ws://127.0.0.1:%d/ws\n", s.config.Port)
+	fmt.Printf("DevGru IDE server starting on ws://%s/ws\n", s.Addr())
 
 	// Start server in goroutine
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Printf("IDE server error: %v", err)
 		}
 	}()
@@ -68,53 +140,102 @@ func (s *Server) Start(ctx context.Context) error {
 	// Wait for context cancellation
 	<-ctx.Done()
 
-	// Graceful shutdown
+	// Graceful shutdown; closing done stops the hub, which says goodbye to
+	// and closes the WebSocket connections server.Shutdown doesn't track.
+	// Waiting for it means IsConnected is false as soon as Start returns.
+	s.mu.Lock()
 	s.running = false
+	s.mu.Unlock()
+	close(s.done)
+	<-s.stopped
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	return server.Shutdown(shutdownCtx)
 }
 
-// run handles the main server loop
+// authorized checks the client's token when the server requires one
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.AuthToken == "" {
+		return true
+	}
+
+	token := r.URL.Query().Get("token")
+	if bearer := r.Header.Get("Authorization"); strings.HasPrefix(bearer, "Bearer ") {
+		token = strings.TrimPrefix(bearer, "Bearer ")
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AuthToken)) == 1
+}
+
+// run handles the main server loop. It is the only goroutine that touches
+// the connections map, until done is closed.
 func (s *Server) run() {
-	for s.running {
+	defer close(s.stopped)
+
+	for {
 		select {
 		case conn := <-s.register:
 			s.connections[conn] = true
+			s.connected.Store(int32(len(s.connections)))
 
 		case conn := <-s.unregister:
-			if _, ok := s.connections[conn]; ok {
-				delete(s.connections, conn)
-				conn.Close()
-			}
+			s.dropConnection(conn)
 
 		case message := <-s.broadcast:
 			for conn := range s.connections {
-				select {
-				case <-time.After(10 * time.Second):
-					delete(s.connections, conn)
-					conn.Close()
-				default:
-					if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
-						delete(s.connections, conn)
-						conn.Close()
-					}
+				// A stalled extension is dropped instead of blocking every other client
+				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+					s.dropConnection(conn)
 				}
 			}
+
+		case <-s.done:
+			// Tell extensions the server is going away so they back off
+			// instead of reconnecting straight into a closed port
+			goodbye, _ := json.Marshal(Message{Type: "shutdown", Timestamp: time.Now(), Data: map[string]interface{}{}})
+			closeFrame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "devgru shutting down")
+			for conn := range s.connections {
+				conn.SetWriteDeadline(time.Now().Add(time.Second))
+				conn.WriteMessage(websocket.TextMessage, goodbye)
+				conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(time.Second))
+				s.dropConnection(conn)
+			}
+			return
 		}
 	}
 }
 
+// dropConnection closes and forgets a connection; only run may call it
+func (s *Server) dropConnection(conn *websocket.Conn) {
+	if _, ok := s.connections[conn]; !ok {
+		return
+	}
+	delete(s.connections, conn)
+	s.connected.Store(int32(len(s.connections)))
+	conn.Close()
+}
+
 // handleWebSocket handles WebSocket connections
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
 
-	s.register <- conn
+	select {
+	case s.register <- conn:
+	case <-s.done:
+		conn.Close()
+		return
+	}
 
 	// Handle messages from the extension
 	go s.handleMessages(conn)
@@ -130,10 +251,25 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleContext returns the current IDE context, for tools such as
+// `devgru ide test` that check what the server made of the editor's messages
+func (s *Server) handleContext(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.GetContext())
+}
+
 // handleMessages processes incoming messages from VS Code extension
 func (s *Server) handleMessages(conn *websocket.Conn) {
 	defer func() {
-		s.unregister <- conn
+		select {
+		case s.unregister <- conn:
+		case <-s.done: // run already closed every connection
+		}
 	}()
 
 	for {
@@ -152,6 +288,36 @@ func (s *Server) handleMessages(conn *websocket.Conn) {
 		}
 
 		s.processMessage(msg)
+
+		s.mu.RLock()
+		observer := s.observer
+		s.mu.RUnlock()
+		if observer != nil {
+			observer(msg)
+		}
+	}
+}
+
+// OnMessage registers a callback invoked with every message received from the
+// extension, after it has been applied to the context
+func (s *Server) OnMessage(fn func(Message)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observer = fn
+}
+
+// severityRank orders diagnostic severities from least to most important;
+// unknown severities rank as info
+func severityRank(severity string) int {
+	switch severity {
+	case "hint":
+		return 0
+	case "warning":
+		return 2
+	case "error":
+		return 3
+	default:
+		return 1
 	}
 }
 
@@ -160,11 +326,21 @@ func (s *Server) processMessage(msg Message) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Paths are normalized to clean absolute paths; messages carrying paths
+	// that can't be trusted are dropped whole
+	root := s.context.WorkspaceRoot
+
 	switch msg.Type {
 	case "selection":
 		var selection SelectionMessage
 		if data, _ := json.Marshal(msg.Data); data != nil {
 			json.Unmarshal(data, &selection)
+			file, err := normalizePath(selection.File, root)
+			if err != nil {
+				log.Printf("Ignoring selection message: %v", err)
+				break
+			}
+			selection.File = file
 			s.context.Selection = &selection
 			s.context.ActiveFile = selection.File
 		}
@@ -173,29 +349,64 @@ func (s *Server) processMessage(msg Message) {
 		var diagnostic DiagnosticMessage
 		if data, _ := json.Marshal(msg.Data); data != nil {
 			json.Unmarshal(data, &diagnostic)
+			file, err := normalizePath(diagnostic.File, root)
+			if err != nil {
+				log.Printf("Ignoring diagnostic message: %v", err)
+				break
+			}
+			diagnostic.File = file
+			if severityRank(diagnostic.Severity) < severityRank(s.config.MinSeverity) {
+				break
+			}
+
+			// A repeated report moves the existing entry to the end instead of duplicating it
+			for i, existing := range s.context.Diagnostics {
+				if existing.File == diagnostic.File && existing.Line == diagnostic.Line && existing.Message == diagnostic.Message {
+					s.context.Diagnostics = append(s.context.Diagnostics[:i], s.context.Diagnostics[i+1:]...)
+					break
+				}
+			}
 			s.context.Diagnostics = append(s.context.Diagnostics, diagnostic)
-			if len(s.context.Diagnostics) > 10 {
-				s.context.Diagnostics = s.context.Diagnostics[1:]
+			if excess := len(s.context.Diagnostics) - s.config.MaxDiagnostics; excess > 0 {
+				s.context.Diagnostics = s.context.Diagnostics[excess:]
 			}
 		}
 
 	case "fileChange":
 		if file, ok := msg.Data["file"].(string); ok {
-			s.context.ActiveFile = file
+			normalized, err := normalizePath(file, root)
+			if err != nil {
+				log.Printf("Ignoring fileChange message: %v", err)
+				break
+			}
+			s.context.ActiveFile = normalized
 		}
 		if s.context.Selection != nil && s.context.Selection.File != s.context.ActiveFile {
 			s.context.Selection = nil
 		}
 	case "workspace":
-		if root, ok := msg.Data["root"].(string); ok {
-			s.context.WorkspaceRoot = root
+		if newRoot, ok := msg.Data["root"].(string); ok && newRoot != "" {
+			normalized, err := normalizePath(newRoot, "")
+			if err != nil {
+				log.Printf("Ignoring workspace message: workspace root must be an absolute path: %v", err)
+				break
+			}
+			s.context.WorkspaceRoot = normalized
+			root = normalized
 		}
 		if files, ok := msg.Data["open_files"].([]interface{}); ok {
 			var openFiles []string
 			for _, f := range files {
-				if file, ok := f.(string); ok {
-					openFiles = append(openFiles, file)
+				file, ok := f.(string)
+				if !ok {
+					continue
+				}
+				normalized, err := normalizePath(file, root)
+				if err != nil {
+					log.Printf("Ignoring open file: %v", err)
+					continue
 				}
+				openFiles = append(openFiles, normalized)
 			}
 			s.context.OpenFiles = openFiles
 		}
@@ -231,16 +442,10 @@ func (s *Server) GetContext() *IDEContext {
 
 // SendDiff sends a diff to VS Code for display
 func (s *Server) SendDiff(diff DiffResult) error {
-	if !s.running {
+	if !s.IsRunning() {
 		return fmt.Errorf("IDE server not running")
 	}
 
-	// Print diff markers for extension to detect
-	fmt.Printf("%s\n", DiffStartMarker)
-	fmt.Printf("%s\n", diff.Patch)
-	fmt.Printf("%s\n", DiffEndMarker)
-
-	// Also send via WebSocket
 	message := Message{
 		Type:      "diff",
 		Timestamp: time.Now(),
@@ -261,14 +466,21 @@ func (s *Server) SendDiff(diff DiffResult) error {
 	select {
 	case s.broadcast <- messageBytes:
 		return nil
+	case <-s.done:
+		return fmt.Errorf("IDE server stopped")
 	case <-time.After(1 * time.Second):
 		return fmt.Errorf("timeout sending diff")
 	}
 }
 
-// IsConnected returns true if VS Code extension is connected
-func (s *Server) IsConnected() bool {
+// IsRunning reports whether the server has started and not yet shut down
+func (s *Server) IsRunning() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.connections) > 0
+	return s.running
+}
+
+// IsConnected returns true if VS Code extension is connected
+func (s *Server) IsConnected() bool {
+	return s.connected.Load() > 0
 }
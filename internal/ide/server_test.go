@@ -0,0 +1,77 @@
+package ide
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startTestServer runs a server on a free loopback port until the test ends
+func startTestServer(t *testing.T) *Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(Config{Enable: true, Port: listener.Addr().(*net.TCPAddr).Port})
+	s.listener = listener
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("Start: %v", err)
+		}
+	})
+
+	for !s.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	return s
+}
+
+// Run with -race: connects, disconnects and diffs sent at once must not race
+// on the connection set
+func TestConcurrentConnectDisconnectAndSendDiff(t *testing.T) {
+	s := startTestServer(t)
+	url := "ws://" + s.Addr() + "/ws"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+				if err != nil {
+					t.Errorf("dial: %v", err)
+					return
+				}
+				conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+				conn.ReadMessage()
+				conn.Close()
+			}
+		}()
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := s.SendDiff(DiffResult{File: "main.go", Patch: "+x"}); err != nil {
+					t.Errorf("SendDiff: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,7 +1,9 @@
 package ide
 
 import (
+	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -13,6 +15,12 @@ type Config struct {
 	Transport string `yaml:"transport"` // websocket or stdio
 	DiffTool  string `yaml:"diff_tool"` // auto, vscode, or disabled
 	Port      int    `yaml:"port"`      // WebSocket port (default: 8123)
+
+	BindAddress string `yaml:"bind_address"` // Interface to listen on (default: 127.0.0.1)
+	AuthToken   string `yaml:"auth_token"`   // Token clients must present, required off loopback
+
+	MaxDiagnostics int    `yaml:"max_diagnostics"` // Most recent diagnostics kept (default: 10)
+	MinSeverity    string `yaml:"min_severity"`    // Least severe diagnostic kept: error, warning, info or hint (default: hint)
 }
 
 // Message represents communication between CLI and IDE extension
@@ -72,20 +80,23 @@ type DiffResult struct {
 // HandshakeMessage is the magic token for VS Code extension detection
 const HandshakeMessage = "###DEVGRU_VSCODE_HANDSHAKE###"
 
-// DiffStartMarker marks the beginning of a diff block
-const DiffStartMarker = "<<<DEVGRU_DIFF_START>>>"
-
-// DiffEndMarker marks the end of a diff block
-const DiffEndMarker = "<<<DEVGRU_DIFF_END>>>"
-
 // Server handles WebSocket connections from VS Code extension
+//
+// The connections map is owned by the run goroutine: everything else adds,
+// removes or writes to connections only through the register, unregister and
+// broadcast channels, and reads the count through connected.
 type Server struct {
 	config      Config
+	listener    net.Listener
 	context     *IDEContext
 	connections map[*websocket.Conn]bool
+	connected   atomic.Int32 // len(connections), kept by run for other goroutines
 	broadcast   chan []byte
 	register    chan *websocket.Conn
 	unregister  chan *websocket.Conn
+	done        chan struct{} // closed on shutdown so run and its senders stop
+	stopped     chan struct{} // closed by run once every connection is closed
 	mu          sync.RWMutex
 	running     bool
+	observer    func(Message)
 }
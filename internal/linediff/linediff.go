@@ -0,0 +1,118 @@
+// Package linediff computes line-by-line edit scripts, for the patches of
+// proposed edits and the comparisons of two answers alike.
+package linediff
+
+import "slices"
+
+// Op is one step of an edit script turning one list of lines into another
+type Op int
+
+const (
+	Equal  Op = iota // the line is in both
+	Delete           // the line is only in the first list
+	Insert           // the line is only in the second list
+)
+
+// MaxEdits bounds the edit distance Lines searches for; lists further apart
+// are diffed as their differing middle replaced wholesale, which is still a
+// correct, if not the shortest, edit script
+const MaxEdits = 1000
+
+// Lines returns a shortest edit script turning n lines into m lines, given
+// which lines are equal, using Myers' algorithm. Lines more than MaxEdits
+// edits apart are diffed as a wholesale replacement of everything between
+// their common prefix and suffix.
+func Lines(n, m int, equal func(i, j int) bool) []Op {
+	prefix := 0
+	for prefix < n && prefix < m && equal(prefix, prefix) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && suffix < m-prefix && equal(n-1-suffix, m-1-suffix) {
+		suffix++
+	}
+
+	ops := make([]Op, 0, n+m)
+	for range prefix {
+		ops = append(ops, Equal)
+	}
+	ops = append(ops, myers(prefix, n-suffix, prefix, m-suffix, equal)...)
+	for range suffix {
+		ops = append(ops, Equal)
+	}
+	return ops
+}
+
+// myers diffs lines [aStart, aEnd) against [bStart, bEnd)
+func myers(aStart, aEnd, bStart, bEnd int, equal func(i, j int) bool) []Op {
+	n, m := aEnd-aStart, bEnd-bStart
+	limit := min(n+m, MaxEdits)
+
+	// v[offset+k] is the furthest x reached on diagonal k = x - y; trace
+	// keeps v as it was before each round d, to walk the path back
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down: insert a line of b
+			} else {
+				x = v[offset+k-1] + 1 // right: delete a line of a
+			}
+			y := x - k
+			for x < n && y < m && equal(aStart+x, bStart+y) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, offset, n, m)
+			}
+		}
+	}
+
+	// Too far apart to search: replace the whole range
+	ops := make([]Op, 0, n+m)
+	for range n {
+		ops = append(ops, Delete)
+	}
+	for range m {
+		ops = append(ops, Insert)
+	}
+	return ops
+}
+
+// backtrack walks the rounds of myers back from (n, m) to the edit script
+func backtrack(trace [][]int, offset, n, m int) []Op {
+	var ops []Op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			ops = append(ops, Equal)
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, Insert)
+			} else {
+				ops = append(ops, Delete)
+			}
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(ops)
+	return ops
+}
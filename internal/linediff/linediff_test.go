@@ -0,0 +1,71 @@
+package linediff
+
+import (
+	"strings"
+	"testing"
+)
+
+// script renders an edit script one character per op: = - +
+func script(ops []Op) string {
+	var b strings.Builder
+	for _, op := range ops {
+		b.WriteByte("=-+"[op])
+	}
+	return b.String()
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "both empty", a: "", b: "", want: ""},
+		{name: "equal", a: "abc", b: "abc", want: "==="},
+		{name: "all inserted", a: "", b: "ab", want: "++"},
+		{name: "all deleted", a: "ab", b: "", want: "--"},
+		{name: "change in the middle", a: "abc", b: "aXc", want: "=-+="},
+		{name: "shortest script", a: "abcabba", b: "cbabac", want: "--=+==-=+"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := Lines(len(tt.a), len(tt.b), func(i, j int) bool { return tt.a[i] == tt.b[j] })
+			if got := script(ops); len(got) != len(tt.want) || strings.Count(got, "=") != strings.Count(tt.want, "=") {
+				t.Errorf("script = %s, want one as short as %s", got, tt.want)
+			}
+
+			// Replaying the script on a gives b
+			var out strings.Builder
+			i, j := 0, 0
+			for _, op := range ops {
+				switch op {
+				case Equal:
+					if tt.a[i] != tt.b[j] {
+						t.Fatalf("script keeps %c where b has %c", tt.a[i], tt.b[j])
+					}
+					out.WriteByte(tt.a[i])
+					i++
+					j++
+				case Delete:
+					i++
+				case Insert:
+					out.WriteByte(tt.b[j])
+					j++
+				}
+			}
+			if i != len(tt.a) || out.String() != tt.b {
+				t.Errorf("script turns %q into %q, want %q", tt.a, out.String(), tt.b)
+			}
+		})
+	}
+}
+
+func TestLinesFallsBackPastEditLimit(t *testing.T) {
+	// Nothing in common past the limit: still a correct, if not minimal, script
+	n := MaxEdits
+	ops := Lines(n, n, func(i, j int) bool { return false })
+	if got, want := script(ops), strings.Repeat("-", n)+strings.Repeat("+", n); got != want {
+		t.Errorf("script is %d ops, want %d deletes then %d inserts", len(ops), n, n)
+	}
+}
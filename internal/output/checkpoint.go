@@ -0,0 +1,106 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// checkpointEntry is a line of a run checkpoint: one configured worker's
+// completed answers
+type checkpointEntry struct {
+	SchemaVersion int      `json:"schema_version"`
+	RunID         string   `json:"run_id"`
+	Prompt        string   `json:"prompt"`
+	Worker        string   `json:"worker"`
+	Results       []Worker `json:"results"`
+}
+
+// Checkpoint is what an interrupted run finished before it stopped
+type Checkpoint struct {
+	RunID   string
+	Prompt  string
+	Workers map[string][]runner.WorkerResult // completed answers by configured worker ID
+}
+
+// CheckpointPath returns where a run's checkpoint is kept under dir (the
+// cache directory). Check run IDs from users with ValidRunID first.
+func CheckpointPath(dir, runID string) string {
+	return filepath.Join(dir, "runs", runID+".jsonl")
+}
+
+// ValidRunID reports whether runID can name a checkpoint: up to 64 letters,
+// digits, - and _, so it can't point outside the cache directory
+func ValidRunID(runID string) bool {
+	if runID == "" || len(runID) > 64 {
+		return false
+	}
+	for _, c := range runID {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// AppendCheckpoint records a worker's completed answers in a run checkpoint,
+// creating it as needed
+func AppendCheckpoint(path, runID, prompt, workerID string, results []runner.WorkerResult) error {
+	entry := checkpointEntry{
+		SchemaVersion: SchemaVersion,
+		RunID:         runID,
+		Prompt:        prompt,
+		Worker:        workerID,
+		Results:       make([]Worker, len(results)),
+	}
+	for i, result := range results {
+		entry.Results[i] = fromWorkerResult(result)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return appendLine(path, line)
+}
+
+// ReadCheckpoint reads a run checkpoint. A last line cut short by a crash
+// is skipped; that worker simply runs again.
+func ReadCheckpoint(path string) (*Checkpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	checkpoint := &Checkpoint{Workers: make(map[string][]runner.WorkerResult)}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for scanner.Scan() {
+		var entry checkpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.SchemaVersion > SchemaVersion {
+			return nil, fmt.Errorf("checkpoint uses schema version %d, newer than supported version %d", entry.SchemaVersion, SchemaVersion)
+		}
+
+		checkpoint.RunID, checkpoint.Prompt = entry.RunID, entry.Prompt
+		results := make([]runner.WorkerResult, len(entry.Results))
+		for i, worker := range entry.Results {
+			results[i] = worker.toWorkerResult()
+		}
+		checkpoint.Workers[entry.Worker] = results
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(checkpoint.Workers) == 0 {
+		return nil, fmt.Errorf("checkpoint %s holds no completed workers", path)
+	}
+	return checkpoint, nil
+}
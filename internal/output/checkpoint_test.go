@@ -0,0 +1,25 @@
+package output
+
+import "testing"
+
+func TestValidRunID(t *testing.T) {
+	tests := []struct {
+		runID string
+		want  bool
+	}{
+		{runID: "3f9a1c0b7e2d", want: true},
+		{runID: "batch_2-of-7", want: true},
+		{runID: ""},
+		{runID: "../x"},
+		{runID: "a/b"},
+		{runID: `a\b`},
+		{runID: ".."},
+		{runID: "run id"},
+		{runID: "0123456789012345678901234567890123456789012345678901234567890123456789"},
+	}
+	for _, tt := range tests {
+		if got := ValidRunID(tt.runID); got != tt.want {
+			t.Errorf("ValidRunID(%q) = %v, want %v", tt.runID, got, tt.want)
+		}
+	}
+}
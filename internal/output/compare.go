@@ -0,0 +1,92 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// diffMarkers prefix each line of a plain-text comparison, like diff(1)'s
+// normal format: < only in the first answer, > only in the second
+var diffMarkers = map[runner.DiffKind]string{
+	runner.DiffBoth:  "  ",
+	runner.DiffLeft:  "< ",
+	runner.DiffRight: "> ",
+}
+
+// Comparison is the serialized line diff of two compared answers
+type Comparison struct {
+	Agreement float64          `json:"agreement"` // share of non-blank lines in common (0-1)
+	Lines     []ComparisonLine `json:"lines"`
+}
+
+// ComparisonLine is a line of a comparison; In is "both", "left" (only the
+// first answer has it) or "right"
+type ComparisonLine struct {
+	In   string `json:"in"`
+	Text string `json:"text"`
+}
+
+// diffSides name the answers a line is in, for JSON
+var diffSides = map[runner.DiffKind]string{
+	runner.DiffBoth:  "both",
+	runner.DiffLeft:  "left",
+	runner.DiffRight: "right",
+}
+
+// FromComparison converts a comparison, and the error Compare returned if
+// any, into the wire format: the run with the diff of the two answers,
+// which a failed comparison doesn't have
+func FromComparison(comparison *runner.Comparison, runErr error) Run {
+	run := FromRunResult(comparison.Result, runErr)
+	if runErr != nil {
+		return run
+	}
+
+	run.Comparison = &Comparison{
+		Agreement: comparison.Agreement,
+		Lines:     make([]ComparisonLine, len(comparison.Lines)),
+	}
+	for i, line := range comparison.Lines {
+		run.Comparison.Lines[i] = ComparisonLine{In: diffSides[line.Kind], Text: line.Text}
+	}
+	return run
+}
+
+// WriteComparisonJSON writes the comparison as indented JSON
+func WriteComparisonJSON(w io.Writer, comparison *runner.Comparison, runErr error) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(FromComparison(comparison, runErr))
+}
+
+// WriteComparison writes two workers' answers as a plain-text line diff,
+// headed by who answered and how much of the answers agree
+func WriteComparison(w io.Writer, comparison *runner.Comparison) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Prompt: %s\n", comparison.Result.Prompt)
+	fmt.Fprintf(&b, "< %s\n", compareLabel(comparison.Left()))
+	fmt.Fprintf(&b, "> %s\n", compareLabel(comparison.Right()))
+	fmt.Fprintf(&b, "Agreement: %.0f%% of lines\n\n", comparison.Agreement*100)
+	for _, line := range comparison.Lines {
+		b.WriteString(strings.TrimRight(diffMarkers[line.Kind]+line.Text, " ") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// compareLabel names a compared worker with its model and timing
+func compareLabel(worker runner.WorkerResult) string {
+	if worker.Stats == nil {
+		return worker.WorkerID
+	}
+	label := fmt.Sprintf("%s (%s, %v", worker.WorkerID, worker.Stats.Model, worker.Stats.Duration.Round(time.Millisecond))
+	if worker.TokensUsed != nil {
+		label += fmt.Sprintf(", %d tokens", worker.TokensUsed.TotalTokens)
+	}
+	return label + ")"
+}
@@ -0,0 +1,60 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+func TestWriteComparisonJSONIncludesTheDiff(t *testing.T) {
+	comparison := &runner.Comparison{
+		Result: &runner.RunResult{
+			Prompt:  "prompt",
+			Success: true,
+			Workers: []runner.WorkerResult{{WorkerID: "left", Content: "a\nb"}, {WorkerID: "right", Content: "a\nc"}},
+		},
+	}
+	comparison.Lines, comparison.Agreement = runner.DiffAnswers("a\nb", "a\nc")
+
+	var buf bytes.Buffer
+	if err := WriteComparisonJSON(&buf, comparison, nil); err != nil {
+		t.Fatal(err)
+	}
+	var run Run
+	if err := json.Unmarshal(buf.Bytes(), &run); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(run.Workers) != 2 {
+		t.Errorf("%d workers, want both answers", len(run.Workers))
+	}
+	if run.Comparison == nil {
+		t.Fatal("JSON has no comparison")
+	}
+	if run.Comparison.Agreement != 0.5 {
+		t.Errorf("agreement = %v, want 0.5", run.Comparison.Agreement)
+	}
+	want := []ComparisonLine{{In: "both", Text: "a"}, {In: "left", Text: "b"}, {In: "right", Text: "c"}}
+	if len(run.Comparison.Lines) != len(want) {
+		t.Fatalf("lines = %+v, want %+v", run.Comparison.Lines, want)
+	}
+	for i := range want {
+		if run.Comparison.Lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, run.Comparison.Lines[i], want[i])
+		}
+	}
+}
+
+func TestFromComparisonLeavesOutTheDiffOfAFailedRun(t *testing.T) {
+	comparison := &runner.Comparison{Result: &runner.RunResult{Prompt: "prompt"}}
+	run := FromComparison(comparison, errors.New("worker right failed"))
+	if run.Comparison != nil {
+		t.Errorf("comparison = %+v, want none for a failed run", run.Comparison)
+	}
+	if run.Error == "" {
+		t.Error("failed comparison has no error")
+	}
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// appendMu keeps runs appended concurrently (e.g. by devgru serve) on lines
+// of their own
+var appendMu sync.Mutex
+
+// AppendRun appends the run to a JSONL run log as a single line, creating the
+// file and its directories as needed. ReadRuns reads the log back.
+func AppendRun(path string, result *runner.RunResult, runErr error) error {
+	line, err := json.Marshal(FromRunResult(result, runErr))
+	if err != nil {
+		return err
+	}
+	return appendLine(path, line)
+}
+
+// appendLine appends a line to a JSONL file, creating the file and its
+// directories as needed
+func appendLine(path string, line []byte) error {
+	line = append(line, '\n')
+
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,118 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// WriteMarkdown writes the run as a human-readable Markdown report
+func WriteMarkdown(w io.Writer, result *runner.RunResult, runErr error) error {
+	run := FromRunResult(result, runErr)
+
+	var b strings.Builder
+	b.WriteString("# devgru run\n\n")
+	fmt.Fprintf(&b, "**Prompt:** %s\n\n", run.Prompt)
+
+	status := "succeeded"
+	if run.Consensus != nil && run.Consensus.LowConfidence {
+		status = "needs review (low consensus confidence)"
+	} else if !run.Success {
+		status = "failed"
+	}
+	fmt.Fprintf(&b, "- Status: %s\n", status)
+	if run.Error != "" {
+		fmt.Fprintf(&b, "- Error: %s\n", run.Error)
+	}
+	if !run.StartedAt.IsZero() {
+		fmt.Fprintf(&b, "- Started: %s\n", run.StartedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "- Duration: %v\n", msDuration(run.DurationMS))
+	fmt.Fprintf(&b, "- Tokens: %d\n", run.TotalTokens)
+	fmt.Fprintf(&b, "- Estimated cost: $%.6f\n", run.EstimatedCost)
+
+	if consensus := run.Consensus; consensus != nil {
+		b.WriteString("\n## Consensus\n\n")
+		fmt.Fprintf(&b, "- Algorithm: %s\n", consensus.Algorithm)
+		fmt.Fprintf(&b, "- Winner: %s\n", consensus.Winner)
+		fmt.Fprintf(&b, "- Confidence: %.2f\n", consensus.Confidence)
+		fmt.Fprintf(&b, "- Participants: %d\n", consensus.Participants)
+		if consensus.Reasoning != "" {
+			fmt.Fprintf(&b, "- Reasoning: %s\n", consensus.Reasoning)
+		}
+		fmt.Fprintf(&b, "\n%s\n", strings.TrimRight(consensus.Content, "\n"))
+	}
+
+	if len(run.Workers) > 0 {
+		b.WriteString("\n## Workers\n")
+	}
+	for _, worker := range run.Workers {
+		writeMarkdownWorker(&b, worker)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownWorker(b *strings.Builder, worker Worker) {
+	fmt.Fprintf(b, "\n### %s\n\n", worker.ID)
+
+	if worker.Model != "" {
+		fmt.Fprintf(b, "- Model: %s", worker.Model)
+		if worker.Provider != "" {
+			fmt.Fprintf(b, " (provider %s)", worker.Provider)
+		}
+		b.WriteString("\n")
+	}
+	if worker.FallbackFrom != "" {
+		fmt.Fprintf(b, "- Fallback from: %s\n", worker.FallbackFrom)
+	}
+	if len(worker.Tags) > 0 {
+		keys := make([]string, 0, len(worker.Tags))
+		for key := range worker.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			pairs[i] = key + "=" + worker.Tags[key]
+		}
+		fmt.Fprintf(b, "- Tags: %s\n", strings.Join(pairs, ", "))
+	}
+	fmt.Fprintf(b, "- Duration: %v\n", msDuration(worker.DurationMS))
+	if worker.Tokens != nil {
+		fmt.Fprintf(b, "- Tokens: %d (%d prompt, %d completion)\n", worker.Tokens.Total, worker.Tokens.Prompt, worker.Tokens.Completion)
+	}
+	fmt.Fprintf(b, "- Estimated cost: $%.6f\n", worker.EstimatedCost)
+	switch {
+	case worker.Score != nil:
+		fmt.Fprintf(b, "- Score: %.1f/10\n", *worker.Score)
+	case worker.Unscored:
+		b.WriteString("- Score: unscored (judges failed)\n")
+	}
+	if worker.Truncated {
+		b.WriteString("- Cut off at max_tokens\n")
+	}
+
+	for _, judge := range worker.Judges {
+		if judge.Error != "" {
+			fmt.Fprintf(b, "  - Judge %s failed: %s\n", judge.ID, judge.Error)
+			continue
+		}
+		fmt.Fprintf(b, "  - Judge %s: %d/10 — %s\n", judge.ID, judge.Score, judge.Reason)
+	}
+
+	if worker.Error != "" {
+		fmt.Fprintf(b, "\n**Error:** %s\n", worker.Error)
+		return
+	}
+	fmt.Fprintf(b, "\n%s\n", strings.TrimRight(worker.Content, "\n"))
+}
+
+func msDuration(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
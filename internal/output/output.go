@@ -0,0 +1,262 @@
+// Package output owns devgru's machine-readable JSON format for run results.
+//
+// The wire types here are decoupled from runner.RunResult so the in-memory
+// types can change freely. Field names are stable, errors are strings (with
+// an ErrorInfo alongside for runs and workers) and optional fields are
+// omitted when empty. Adding fields is not a breaking
+// change; renaming, removing or changing the meaning of a field is, and must
+// bump SchemaVersion.
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// SchemaVersion is the version of the JSON shape written by this package
+const SchemaVersion = 1
+
+// Run is the serialized form of a run
+type Run struct {
+	SchemaVersion int        `json:"schema_version"`
+	RunID         string     `json:"run_id,omitempty"`
+	Prompt        string     `json:"prompt"`
+	PromptHash    string     `json:"prompt_hash,omitempty"` // PromptHash(Prompt), to match runs to prompts
+	Success       bool       `json:"success"`
+	Error         string     `json:"error,omitempty"`
+	ErrorInfo     *ErrorInfo `json:"error_info,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	DurationMS    int64      `json:"duration_ms"`
+	TotalTokens   int        `json:"total_tokens"`
+	EstimatedCost float64    `json:"estimated_cost"`
+	Workers       []Worker   `json:"workers"`
+	Consensus     *Consensus `json:"consensus,omitempty"`
+
+	Comparison *Comparison `json:"comparison,omitempty"` // devgru run --compare only
+}
+
+// Worker is the serialized result of one worker
+type Worker struct {
+	ID            string            `json:"id"`
+	Provider      string            `json:"provider,omitempty"`
+	FallbackFrom  string            `json:"fallback_from,omitempty"` // primary provider when a fallback answered
+	PrimaryError  string            `json:"primary_error,omitempty"` // why the primary provider was given up on
+	Model         string            `json:"model,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"` // user-defined labels from the worker config
+	Content       string            `json:"content"`
+	Error         string            `json:"error,omitempty"`
+	ErrorInfo     *ErrorInfo        `json:"error_info,omitempty"`
+	DurationMS    int64             `json:"duration_ms"`
+	FirstTokenMS  int64             `json:"first_token_ms,omitempty"` // time to the first streamed text
+	Tokens        *Tokens           `json:"tokens,omitempty"`
+	EstimatedCost float64           `json:"estimated_cost"`
+	Score         *float64          `json:"score,omitempty"` // average judge score, absent when not judged
+	Unscored      bool              `json:"unscored,omitempty"`
+	Truncated     bool              `json:"truncated,omitempty"`  // cut off by its max_tokens limit
+	RequestID     string            `json:"request_id,omitempty"` // provider's id for the request, for support tickets
+
+	StructuredContent interface{} `json:"structured_content,omitempty"` // parsed answer of a worker with a response_schema
+	Judges            []Judge     `json:"judges,omitempty"`
+}
+
+// ErrorInfo is the structured form of an error, for scripts that need to
+// know why something failed rather than just that it did
+type ErrorInfo struct {
+	Type    string `json:"type"`    // provider error type (auth, rate_limit, timeout, ...), consensus failure (no_successful_workers, ...) or unknown
+	Message string `json:"message"` // same as the error string next to it
+}
+
+// Tokens is the serialized token usage of a request
+type Tokens struct {
+	Prompt        int `json:"prompt"`
+	Completion    int `json:"completion"`
+	Total         int `json:"total"`
+	CacheCreation int `json:"cache_creation,omitempty"`
+	CacheRead     int `json:"cache_read,omitempty"`
+}
+
+// Judge is the serialized evaluation of a worker by one judge
+type Judge struct {
+	ID         string `json:"id"`
+	Score      int    `json:"score"`
+	Reason     string `json:"reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Attempts   int    `json:"attempts,omitempty"`
+	Corrected  bool   `json:"corrected,omitempty"` // needed a re-prompt for valid JSON
+}
+
+// Consensus is the serialized consensus outcome
+type Consensus struct {
+	Algorithm    string  `json:"algorithm"`
+	Winner       string  `json:"winner"`
+	Content      string  `json:"content"`
+	Confidence   float64 `json:"confidence"`
+	Reasoning    string  `json:"reasoning,omitempty"`
+	Participants int     `json:"participants"`
+
+	LowConfidence bool `json:"low_confidence,omitempty"`
+}
+
+// FromRunResult converts a run result, and the error the run returned if
+// any, into the wire format
+func FromRunResult(result *runner.RunResult, runErr error) Run {
+	run := Run{
+		SchemaVersion: SchemaVersion,
+		Workers:       []Worker{},
+		Error:         errorString(runErr),
+		ErrorInfo:     errorInfo(runErr),
+	}
+	if result == nil {
+		return run
+	}
+
+	run.RunID = result.RunID
+	run.Prompt = result.Prompt
+	run.PromptHash = PromptHash(result.Prompt)
+	run.Success = result.Success && runErr == nil
+	run.StartedAt = result.StartTime
+	run.DurationMS = result.TotalDuration.Milliseconds()
+	run.TotalTokens = result.TotalTokens
+	run.EstimatedCost = result.EstimatedCost
+
+	for _, worker := range result.Workers {
+		run.Workers = append(run.Workers, fromWorkerResult(worker))
+	}
+
+	if consensus := result.Consensus; consensus != nil {
+		run.Consensus = &Consensus{
+			Algorithm:    consensus.Algorithm,
+			Winner:       consensus.Winner,
+			Content:      consensus.Content,
+			Confidence:   consensus.Confidence,
+			Reasoning:    consensus.Reasoning,
+			Participants: consensus.Participants,
+
+			LowConfidence: consensus.LowConfidence,
+		}
+	}
+
+	return run
+}
+
+// PromptHash identifies a prompt across runs and devgru versions: the hex
+// SHA-256 of its text
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// WriteJSON writes the run as indented JSON
+func WriteJSON(w io.Writer, result *runner.RunResult, runErr error) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(FromRunResult(result, runErr))
+}
+
+func fromWorkerResult(worker runner.WorkerResult) Worker {
+	out := Worker{
+		ID:        worker.WorkerID,
+		Content:   worker.Content,
+		Error:     errorString(worker.Error),
+		ErrorInfo: errorInfo(worker.Error),
+		Unscored:  worker.Unscored,
+		Truncated: worker.Truncated(),
+	}
+
+	if provider, ok := worker.Metadata["provider"].(string); ok {
+		out.Provider = provider
+	}
+	if worker.Stats != nil {
+		out.RequestID = worker.Stats.RequestID
+	}
+	out.StructuredContent = worker.StructuredContent
+	if fallbackFrom, ok := worker.Metadata["fallback_from"].(string); ok {
+		out.FallbackFrom = fallbackFrom
+		out.PrimaryError, _ = worker.Metadata["primary_error"].(string)
+	}
+	if tags, ok := worker.Metadata["tags"].(map[string]string); ok {
+		out.Tags = tags
+	}
+
+	if worker.Stats != nil {
+		out.Model = worker.Stats.Model
+		out.DurationMS = worker.Stats.Duration.Milliseconds()
+		out.FirstTokenMS = worker.Stats.TimeToFirstToken.Milliseconds()
+		out.EstimatedCost = worker.Stats.EstimatedCost
+	}
+
+	if tokens := worker.TokensUsed; tokens != nil {
+		out.Tokens = &Tokens{
+			Prompt:        tokens.PromptTokens,
+			Completion:    tokens.CompletionTokens,
+			Total:         tokens.TotalTokens,
+			CacheCreation: tokens.CacheCreationTokens,
+			CacheRead:     tokens.CacheReadTokens,
+		}
+	}
+
+	if len(worker.JudgeResults) > 0 {
+		score := worker.AverageScore
+		out.Score = &score
+	}
+	for _, judge := range worker.JudgeResults {
+		out.Judges = append(out.Judges, Judge{
+			ID:         judge.JudgeID,
+			Score:      judge.Score,
+			Reason:     judge.Reason,
+			Error:      errorString(judge.Error),
+			DurationMS: judge.Duration.Milliseconds(),
+			Attempts:   judge.Attempts,
+			Corrected:  judge.Corrected,
+		})
+	}
+
+	return out
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// consensusErrorTypes are the ErrorInfo types of the runner's consensus failures
+var consensusErrorTypes = []struct {
+	err      error
+	typeName string
+}{
+	{runner.ErrNoSuccessfulWorkers, "no_successful_workers"},
+	{runner.ErrBelowMinScore, "below_min_score"},
+	{runner.ErrUnknownAlgorithm, "unknown_algorithm"},
+}
+
+// errorInfo types an error by the provider error or consensus failure it
+// wraps, if any
+func errorInfo(err error) *ErrorInfo {
+	if err == nil {
+		return nil
+	}
+
+	info := &ErrorInfo{Type: string(provider.ErrorTypeUnknown), Message: err.Error()}
+	var provErr *provider.ProviderError
+	if errors.As(err, &provErr) && provErr.Type != "" {
+		info.Type = string(provErr.Type)
+		return info
+	}
+	for _, consensusErr := range consensusErrorTypes {
+		if errors.Is(err, consensusErr.err) {
+			info.Type = consensusErr.typeName
+			break
+		}
+	}
+	return info
+}
@@ -0,0 +1,155 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// ReadRuns reads every run in r, written one after another by WriteJSON (e.g.
+// appended to a log with `devgru run --format json >> runs.jsonl`). Runs from
+// a newer schema version are rejected rather than misread.
+func ReadRuns(r io.Reader) ([]Run, error) {
+	decoder := json.NewDecoder(r)
+
+	var runs []Run
+	for {
+		var run Run
+		if err := decoder.Decode(&run); err != nil {
+			if errors.Is(err, io.EOF) {
+				return runs, nil
+			}
+			return nil, fmt.Errorf("failed to read run %d: %w", len(runs)+1, err)
+		}
+		if run.SchemaVersion > SchemaVersion {
+			return nil, fmt.Errorf("run %d uses schema version %d, newer than supported version %d", len(runs)+1, run.SchemaVersion, SchemaVersion)
+		}
+		runs = append(runs, run)
+	}
+}
+
+// ToRunResult rebuilds a run result from its wire form so it can be shown in
+// the results view again. Fields the wire format doesn't carry stay empty.
+func (run Run) ToRunResult() *runner.RunResult {
+	result := &runner.RunResult{
+		RunID:         run.RunID,
+		Prompt:        run.Prompt,
+		TotalDuration: time.Duration(run.DurationMS) * time.Millisecond,
+		TotalTokens:   run.TotalTokens,
+		EstimatedCost: run.EstimatedCost,
+		Success:       run.Success,
+		StartTime:     run.StartedAt,
+	}
+	result.EndTime = result.StartTime.Add(result.TotalDuration)
+
+	for _, worker := range run.Workers {
+		result.Workers = append(result.Workers, worker.toWorkerResult())
+	}
+
+	if consensus := run.Consensus; consensus != nil {
+		result.Consensus = &runner.Consensus{
+			Algorithm:    consensus.Algorithm,
+			Winner:       consensus.Winner,
+			Content:      consensus.Content,
+			Confidence:   consensus.Confidence,
+			Reasoning:    consensus.Reasoning,
+			Participants: consensus.Participants,
+
+			LowConfidence: consensus.LowConfidence,
+		}
+	}
+
+	return result
+}
+
+func (worker Worker) toWorkerResult() runner.WorkerResult {
+	out := runner.WorkerResult{
+		WorkerID: worker.ID,
+		Content:  worker.Content,
+		Error:    workerError(worker),
+		Unscored: worker.Unscored,
+		Metadata: make(map[string]interface{}),
+		Stats: &provider.Stats{
+			Provider:      worker.Provider,
+			Model:         worker.Model,
+			Duration:      time.Duration(worker.DurationMS) * time.Millisecond,
+			EstimatedCost: worker.EstimatedCost,
+			Success:       worker.Error == "",
+
+			TimeToFirstToken: time.Duration(worker.FirstTokenMS) * time.Millisecond,
+		},
+	}
+	if worker.Truncated {
+		out.Stats.FinishReason = provider.FinishReasonLength
+	}
+	out.StructuredContent = worker.StructuredContent
+	if worker.RequestID != "" {
+		out.Stats.RequestID = worker.RequestID
+		out.Metadata["request_id"] = worker.RequestID
+	}
+
+	if worker.Provider != "" {
+		out.Metadata["provider"] = worker.Provider
+	}
+	if worker.FallbackFrom != "" {
+		out.Metadata["fallback_from"] = worker.FallbackFrom
+		out.Metadata["primary_error"] = worker.PrimaryError
+	}
+	if len(worker.Tags) > 0 {
+		out.Metadata["tags"] = worker.Tags
+	}
+
+	if tokens := worker.Tokens; tokens != nil {
+		out.TokensUsed = &provider.TokenUsage{
+			PromptTokens:        tokens.Prompt,
+			CompletionTokens:    tokens.Completion,
+			TotalTokens:         tokens.Total,
+			CacheCreationTokens: tokens.CacheCreation,
+			CacheReadTokens:     tokens.CacheRead,
+		}
+		out.Stats.TokensUsed = out.TokensUsed
+	}
+
+	if worker.Score != nil {
+		out.AverageScore = *worker.Score
+	}
+	for _, judge := range worker.Judges {
+		out.JudgeResults = append(out.JudgeResults, runner.JudgeResult{
+			JudgeID:   judge.ID,
+			WorkerID:  worker.ID,
+			Score:     judge.Score,
+			Reason:    judge.Reason,
+			Error:     stringError(judge.Error),
+			Duration:  time.Duration(judge.DurationMS) * time.Millisecond,
+			Attempts:  judge.Attempts,
+			Corrected: judge.Corrected,
+		})
+	}
+
+	return out
+}
+
+// workerError restores a worker's error, typed again when its ErrorInfo
+// names a provider error type
+func workerError(worker Worker) error {
+	if worker.Error == "" || worker.ErrorInfo == nil || worker.ErrorInfo.Type == string(provider.ErrorTypeUnknown) {
+		return stringError(worker.Error)
+	}
+	return &provider.ProviderError{
+		Provider: worker.Provider,
+		Type:     provider.ErrorType(worker.ErrorInfo.Type),
+		Message:  worker.Error,
+	}
+}
+
+func stringError(message string) error {
+	if message == "" {
+		return nil
+	}
+	return errors.New(message)
+}
@@ -0,0 +1,516 @@
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// apiVersion is the Anthropic API version sent with every request
+const apiVersion = "2023-06-01"
+
+// defaultMaxTokens is used when the caller doesn't set MaxTokens, which Anthropic requires
+const defaultMaxTokens = 1024
+
+// Client implements the Provider interface for Anthropic
+type Client struct {
+	baseURL       string
+	apiKey        string
+	model         string
+	httpClient    *http.Client
+	name          string
+	promptCaching bool
+}
+
+// DefaultBaseURL is the API endpoint used when no base_url is configured
+const DefaultBaseURL = "https://api.anthropic.com/v1"
+
+// NewClient creates a new Anthropic provider client
+func NewClient(config provider.ProviderConfig) (*Client, error) {
+	if config.APIKey == "" {
+		return nil, &provider.ProviderError{
+			Provider: "anthropic",
+			Type:     provider.ErrorTypeAuth,
+			Message:  "API key is required",
+		}
+	}
+
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	return &Client{
+		baseURL:       strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:        config.APIKey,
+		model:         config.Model,
+		name:          fmt.Sprintf("anthropic-%s", config.Model),
+		promptCaching: config.Options["prompt_caching"] == "true",
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}, nil
+}
+
+// Ask implements the Provider interface
+func (c *Client) Ask(ctx context.Context, prompt string, opts provider.Options) (<-chan provider.Response, error) {
+	responseChan := make(chan provider.Response, 10)
+
+	go func() {
+		defer close(responseChan)
+		c.sendRequest(ctx, prompt, opts, responseChan)
+	}()
+
+	return responseChan, nil
+}
+
+// GetName returns the provider name
+func (c *Client) GetName() string {
+	return c.name
+}
+
+// GetModel returns the model name
+func (c *Client) GetModel() string {
+	return c.model
+}
+
+// EstimateTokens provides a rough token estimate for Claude models
+func (c *Client) EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	// Claude tokenizers average roughly 3.5 characters per token for English text
+	estimate := int(float64(len(text)) / 3.5)
+	if estimate < 1 {
+		estimate = 1
+	}
+
+	return estimate
+}
+
+// Close cleans up resources
+func (c *Client) Close() error {
+	return nil
+}
+
+// Warm implements provider.Warmer by opening a connection to the API host
+func (c *Client) Warm(ctx context.Context) error {
+	return provider.WarmConnection(ctx, c.httpClient, c.baseURL)
+}
+
+// sendRequest handles the actual request to Anthropic
+func (c *Client) sendRequest(ctx context.Context, prompt string, opts provider.Options, responseChan chan<- provider.Response) {
+	reqBytes, err := json.Marshal(c.buildRequestBody(prompt, opts))
+	if err != nil {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider: "anthropic",
+				Type:     provider.ErrorTypeValidation,
+				Message:  "failed to marshal request",
+				Cause:    err,
+			},
+		})
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/messages", bytes.NewReader(reqBytes))
+	if err != nil {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider: "anthropic",
+				Type:     provider.ErrorTypeValidation,
+				Message:  "failed to create request",
+				Cause:    err,
+			},
+		})
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+	if opts.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider: "anthropic",
+				Type:     provider.ErrorTypeNetwork,
+				Message:  "request failed",
+				Cause:    err,
+			},
+		})
+		return
+	}
+	defer resp.Body.Close()
+
+	// Anthropic tags every response with a request-id worth quoting to their support
+	requestID := resp.Header.Get("Request-Id")
+
+	if resp.StatusCode != http.StatusOK {
+		c.handleErrorResponse(ctx, resp, requestID, responseChan)
+		return
+	}
+
+	if opts.Stream {
+		c.handleStreamingResponse(ctx, resp.Body, requestID, opts.MaxResponseBytes, responseChan)
+	} else {
+		c.handleNonStreamingResponse(ctx, resp.Body, requestID, opts.MaxResponseBytes, responseChan)
+	}
+}
+
+// buildRequestBody constructs the Anthropic Messages API request body. With
+// prompt caching enabled, the system prompt and stable context are marked as
+// cacheable prefixes so repeated runs only pay for them once.
+func (c *Client) buildRequestBody(prompt string, opts provider.Options) map[string]interface{} {
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	var content []contentBlock
+	if opts.Context != "" {
+		content = append(content, c.textBlock(opts.Context, true))
+	}
+	content = append(content, c.textBlock(prompt, false))
+
+	reqBody := map[string]interface{}{
+		"model":       c.model,
+		"max_tokens":  maxTokens,
+		"temperature": opts.Temperature,
+		"stream":      opts.Stream,
+		"messages": []map[string]interface{}{
+			{
+				"role":    "user",
+				"content": content,
+			},
+		},
+	}
+
+	if opts.SystemPrompt != "" {
+		reqBody["system"] = []contentBlock{c.textBlock(opts.SystemPrompt, true)}
+	}
+
+	provider.MergeRawOptions(reqBody, opts.RawOptions)
+	return reqBody
+}
+
+// textBlock builds a text content block, marking it cacheable when requested and enabled
+func (c *Client) textBlock(text string, cacheable bool) contentBlock {
+	block := contentBlock{Type: "text", Text: text}
+	if cacheable && c.promptCaching {
+		block.CacheControl = &cacheControl{Type: "ephemeral"}
+	}
+	return block
+}
+
+// handleStreamingResponse processes Server-Sent Events from Anthropic
+func (c *Client) handleStreamingResponse(ctx context.Context, body io.Reader, requestID string, maxBytes int, responseChan chan<- provider.Response) {
+	// Lines are only limited by maxBytes, so a delta carrying a large piece
+	// of generated code can't cut the stream short the way a Scanner would
+	reader := bufio.NewReader(body)
+	lineLimit := provider.BodyLimit(maxBytes)
+	var usage anthropicUsage
+	var stopReason string
+	var readErr error
+
+	for readErr == nil {
+		var raw string
+		raw, readErr = provider.ReadLine(reader, lineLimit)
+
+		// Stop reading as soon as the caller gives up on the stream
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(readErr, provider.ErrResponseTooLarge) {
+			send(ctx, responseChan, provider.Response{
+				Error: &provider.ProviderError{
+					Provider:  "anthropic",
+					RequestID: requestID,
+					Type:      provider.ErrorTypeValidation,
+					Message:   fmt.Sprintf("response exceeded the %d byte limit", maxBytes),
+					Cause:     readErr,
+				},
+			})
+			return
+		}
+
+		line := strings.TrimSpace(raw)
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			// Skip malformed events
+			continue
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				usage = event.Message.Usage
+			}
+
+		case "content_block_delta":
+			if event.Delta.Text != "" {
+				if !send(ctx, responseChan, provider.Response{Delta: event.Delta.Text}) {
+					return
+				}
+			}
+
+		case "message_delta":
+			if event.Usage != nil {
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
+			if event.Delta.StopReason != "" {
+				stopReason = event.Delta.StopReason
+			}
+
+		case "message_stop":
+			send(ctx, responseChan, provider.Response{
+				Done:         true,
+				RequestID:    requestID,
+				TokensUsed:   usage.toTokenUsage(),
+				FinishReason: finishReason(stopReason),
+			})
+			return
+
+		case "error":
+			send(ctx, responseChan, provider.Response{
+				Error: &provider.ProviderError{
+					Provider:  "anthropic",
+					RequestID: requestID,
+					Type:      provider.ErrorTypeServerError,
+					Message:   event.Error.Message,
+				},
+			})
+			return
+		}
+	}
+
+	// A cancelled request surfaces as a read error; nobody is listening anymore
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Report read failures instead of pretending the stream completed
+	if readErr != io.EOF {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider:  "anthropic",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeNetwork,
+				Message:   "error reading stream",
+				Cause:     readErr,
+			},
+		})
+		return
+	}
+
+	// If the stream ended without message_stop, still send final response
+	send(ctx, responseChan, provider.Response{
+		Done:         true,
+		RequestID:    requestID,
+		TokensUsed:   usage.toTokenUsage(),
+		FinishReason: finishReason(stopReason),
+	})
+}
+
+// finishReason maps Anthropic's stop_reason onto the shared finish reasons
+func finishReason(stopReason string) string {
+	if stopReason == "max_tokens" {
+		return provider.FinishReasonLength
+	}
+	return stopReason
+}
+
+// handleNonStreamingResponse processes a complete response from Anthropic
+func (c *Client) handleNonStreamingResponse(ctx context.Context, body io.Reader, requestID string, maxBytes int, responseChan chan<- provider.Response) {
+	bodyBytes, err := provider.ReadBody(body, maxBytes)
+	if errors.Is(err, provider.ErrResponseTooLarge) {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider:  "anthropic",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeValidation,
+				Message:   fmt.Sprintf("response exceeded the %d byte limit", maxBytes),
+				Cause:     err,
+			},
+		})
+		return
+	}
+	if err != nil {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider:  "anthropic",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeNetwork,
+				Message:   "failed to read response body",
+				Cause:     err,
+			},
+		})
+		return
+	}
+
+	var response anthropicResponse
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider:  "anthropic",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeValidation,
+				Message:   "failed to parse response",
+				Cause:     err,
+			},
+		})
+		return
+	}
+
+	var content strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+
+	// Send the complete content as a single response
+	send(ctx, responseChan, provider.Response{
+		Delta:        content.String(),
+		Done:         true,
+		RequestID:    requestID,
+		TokensUsed:   response.Usage.toTokenUsage(),
+		FinishReason: finishReason(response.StopReason),
+	})
+}
+
+// handleErrorResponse processes error responses from Anthropic
+func (c *Client) handleErrorResponse(ctx context.Context, resp *http.Response, requestID string, responseChan chan<- provider.Response) {
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, provider.MaxErrorBodyBytes))
+
+	var errorResp anthropicErrorResponse
+	json.Unmarshal(bodyBytes, &errorResp)
+
+	errorType := provider.ErrorTypeServerError
+	message := fmt.Sprintf("HTTP %d", resp.StatusCode)
+
+	switch resp.StatusCode {
+	case 401, 403:
+		errorType = provider.ErrorTypeAuth
+		message = "invalid API key"
+	case 429:
+		errorType = provider.ErrorTypeRateLimit
+		message = "rate limit exceeded"
+	case 400:
+		errorType = provider.ErrorTypeValidation
+		message = "invalid request"
+	}
+
+	if errorResp.Error.Message != "" {
+		message = errorResp.Error.Message
+	}
+
+	send(ctx, responseChan, provider.Response{
+		Error: &provider.ProviderError{
+			Provider:  "anthropic",
+			RequestID: requestID,
+			Type:      errorType,
+			Message:   message,
+		},
+	})
+}
+
+// send delivers a response unless the context is cancelled first, so the
+// request goroutine never blocks on a consumer that has stopped reading
+func send(ctx context.Context, responseChan chan<- provider.Response, response provider.Response) bool {
+	select {
+	case responseChan <- response:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Anthropic API request and response structures
+type cacheControl struct {
+	Type string `json:"type"`
+}
+
+type contentBlock struct {
+	Type         string        `json:"type"`
+	Text         string        `json:"text"`
+	CacheControl *cacheControl `json:"cache_control,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// toTokenUsage converts Anthropic usage, where input_tokens excludes cached
+// tokens, into a TokenUsage whose PromptTokens counts the whole prompt
+func (u anthropicUsage) toTokenUsage() *provider.TokenUsage {
+	promptTokens := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &provider.TokenUsage{
+		PromptTokens:        promptTokens,
+		CompletionTokens:    u.OutputTokens,
+		TotalTokens:         promptTokens + u.OutputTokens,
+		CacheCreationTokens: u.CacheCreationInputTokens,
+		CacheReadTokens:     u.CacheReadInputTokens,
+	}
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      anthropicUsage `json:"usage"`
+}
+
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type anthropicErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
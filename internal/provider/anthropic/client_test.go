@@ -0,0 +1,105 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+	"github.com/evisdrenova/devgru/internal/provider/providertest"
+)
+
+func TestCancellationConformance(t *testing.T) {
+	server := providertest.NewStreamingServer()
+	defer server.Close()
+
+	client, err := NewClient(provider.ProviderConfig{
+		Kind:    "anthropic",
+		Model:   "claude-3-5-sonnet-20241022",
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := providertest.CheckCancellation(client, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.WaitDisconnect(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// streamBody builds an SSE stream with one content_block_delta per text
+func streamBody(t *testing.T, texts ...string) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":5}}}\n\n")
+	for _, text := range texts {
+		event, err := json.Marshal(map[string]interface{}{
+			"type":  "content_block_delta",
+			"delta": map[string]string{"type": "text_delta", "text": text},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&b, "event: content_block_delta\ndata: %s\n\n", event)
+	}
+	b.WriteString("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	return b.String()
+}
+
+func TestHandleStreamingResponseReadsLongLines(t *testing.T) {
+	long := strings.Repeat("x", 100<<10) // well past bufio.Scanner's 64KB token limit
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		wantErr  error
+	}{
+		{name: "no limit", maxBytes: 0},
+		{name: "within limit", maxBytes: 1 << 20},
+		{name: "past limit", maxBytes: 1 << 10, wantErr: provider.ErrResponseTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{}
+			responseChan := make(chan provider.Response, 10)
+			c.handleStreamingResponse(context.Background(), strings.NewReader(streamBody(t, "start ", long)), "req", tt.maxBytes, responseChan)
+			close(responseChan)
+
+			var content strings.Builder
+			var done bool
+			var err error
+			for response := range responseChan {
+				content.WriteString(response.Delta)
+				done = done || response.Done
+				if response.Error != nil {
+					err = response.Error
+				}
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !done {
+				t.Error("stream never finished")
+			}
+			if content.String() != "start "+long {
+				t.Errorf("got %d bytes of content, want %d", content.Len(), len("start ")+len(long))
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package factories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// BreakerConfig controls when a provider's circuit breaker trips
+type BreakerConfig struct {
+	Threshold int           // consecutive auth/network failures before tripping (0 disables)
+	Cooldown  time.Duration // how long a tripped breaker rejects requests before a trial
+}
+
+// DefaultBreakerConfig returns sensible circuit breaker defaults
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		Threshold: 3,
+		Cooldown:  30 * time.Second,
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker tracks consecutive failures for a single provider
+type circuitBreaker struct {
+	state     breakerState
+	failures  int
+	lastError error
+	openedAt  time.Time
+	trialSent bool
+}
+
+// tripsBreaker reports whether an error means the provider itself is unusable
+func tripsBreaker(err error) bool {
+	var provErr *provider.ProviderError
+	if !errors.As(err, &provErr) {
+		return false
+	}
+	return provErr.Type == provider.ErrorTypeAuth || provErr.Type == provider.ErrorTypeNetwork
+}
+
+// breakerRegistry holds the circuit breakers for all managed providers
+type breakerRegistry struct {
+	config   BreakerConfig
+	breakers map[string]*circuitBreaker
+	mu       sync.Mutex
+}
+
+func newBreakerRegistry(config BreakerConfig) *breakerRegistry {
+	return &breakerRegistry{
+		config:   config,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+// allow returns an error when the provider's breaker is open; once the cooldown
+// has passed a single trial request is let through (half-open)
+func (br *breakerRegistry) allow(name string) error {
+	if br.config.Threshold <= 0 {
+		return nil
+	}
+
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	cb, exists := br.breakers[name]
+	if !exists {
+		return nil
+	}
+
+	switch cb.state {
+	case breakerOpen:
+		if time.Since(cb.openedAt) < br.config.Cooldown {
+			return br.openError(name, cb)
+		}
+		cb.state = breakerHalfOpen
+		cb.trialSent = true
+		return nil
+
+	case breakerHalfOpen:
+		// Only one trial request at a time while half-open
+		if cb.trialSent {
+			return br.openError(name, cb)
+		}
+		cb.trialSent = true
+		return nil
+	}
+
+	return nil
+}
+
+// record updates the provider's breaker with the outcome of a request
+func (br *breakerRegistry) record(name string, err error) {
+	if br.config.Threshold <= 0 {
+		return
+	}
+
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	cb, exists := br.breakers[name]
+	if !exists {
+		cb = &circuitBreaker{}
+		br.breakers[name] = cb
+	}
+
+	// A caller giving up says nothing about the provider's health
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		cb.trialSent = false
+		return
+	}
+
+	if !tripsBreaker(err) {
+		// Any answer from the provider (even an invalid request) proves it is reachable
+		cb.state = breakerClosed
+		cb.failures = 0
+		cb.trialSent = false
+		return
+	}
+
+	cb.failures++
+	cb.lastError = err
+	if cb.state == breakerHalfOpen || cb.failures >= br.config.Threshold {
+		cb.state = breakerOpen
+		cb.openedAt = time.Now()
+		cb.trialSent = false
+	}
+}
+
+// release gives back a half-open trial that was let through but never sent,
+// so the next request can be the trial instead
+func (br *breakerRegistry) release(name string) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	if cb, exists := br.breakers[name]; exists {
+		cb.trialSent = false
+	}
+}
+
+// openError builds the fast-fail error returned while a breaker is open
+func (br *breakerRegistry) openError(name string, cb *circuitBreaker) error {
+	retryIn := br.config.Cooldown - time.Since(cb.openedAt)
+	if retryIn < 0 {
+		retryIn = 0
+	}
+
+	return &provider.ProviderError{
+		Provider: name,
+		Type:     provider.ErrorTypeUnavailable,
+		Message:  fmt.Sprintf("provider %s skipped after %d consecutive failures (retry in %v)", name, cb.failures, retryIn.Round(time.Second)),
+		Cause:    cb.lastError,
+	}
+}
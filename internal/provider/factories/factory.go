@@ -1,10 +1,16 @@
 package factories
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/evisdrenova/devgru/internal/provider"
+	"github.com/evisdrenova/devgru/internal/provider/anthropic"
+	"github.com/evisdrenova/devgru/internal/provider/ollama"
 	"github.com/evisdrenova/devgru/internal/provider/openai"
 )
 
@@ -32,13 +38,11 @@ func (f *DefaultFactory) CreateProvider(config provider.ProviderConfig) (provide
 	case "openai":
 		return openai.NewClient(config)
 
-	// case "anthropic":
-	// 	// TODO: Implement Anthropic provider
-	// 	return nil, fmt.Errorf("anthropic provider not yet implemented")
+	case "anthropic":
+		return anthropic.NewClient(config)
 
-	// case "ollama":
-	// 	// TODO: Implement Ollama provider
-	// 	return nil, fmt.Errorf("ollama provider not yet implemented")
+	case "ollama":
+		return ollama.NewClient(config)
 
 	default:
 		return nil, &provider.ProviderError{
@@ -49,19 +53,88 @@ func (f *DefaultFactory) CreateProvider(config provider.ProviderConfig) (provide
 	}
 }
 
+// ProviderKindInfo describes how to configure a provider kind
+type ProviderKindInfo struct {
+	Kind           string       // kind name used in config, e.g. openai
+	RequiredFields []string     // provider config fields that must be set (api_key, base_url, host)
+	DefaultBaseURL string       // endpoint used when base_url is empty, "" if there is none
+	Options        []KindOption // kind-specific settings the provider understands
+}
+
+// KindOption describes a kind-specific provider setting
+type KindOption struct {
+	Name        string
+	Description string
+}
+
+// kindInfos lists every supported kind; SupportedKinds and DescribeKind both read it
+var kindInfos = []ProviderKindInfo{
+	{
+		Kind:           "openai",
+		RequiredFields: []string{"api_key"},
+		DefaultBaseURL: openai.DefaultBaseURL,
+		Options: []KindOption{
+			{Name: "send_temperature", Description: "whether the model accepts a temperature (built in for known models)"},
+			{Name: "max_tokens_param", Description: "output token limit parameter: max_tokens or max_completion_tokens (built in for known models)"},
+			{Name: "structured_outputs", Description: "whether the model enforces a response_schema in strict mode (built in for known models)"},
+			{Name: "embedding_model", Description: "model used for embeddings, e.g. by the semantic majority normalizer (default text-embedding-3-small)"},
+			{Name: "stream_buffer_bytes", Description: "read buffer for streamed responses; lines of any length are read (default 65536)"},
+		},
+	},
+	{
+		Kind:           "anthropic",
+		RequiredFields: []string{"api_key"},
+		DefaultBaseURL: anthropic.DefaultBaseURL,
+		Options: []KindOption{
+			{Name: "prompt_caching", Description: "cache the system prompt and project context across requests"},
+		},
+	},
+	{
+		Kind:           "ollama",
+		RequiredFields: []string{"host"},
+		Options: []KindOption{
+			{Name: "embedding_model", Description: "model used for embeddings, e.g. by the semantic majority normalizer (default nomic-embed-text)"},
+		},
+	},
+}
+
 // SupportedKinds returns the list of supported provider kinds
 func (f *DefaultFactory) SupportedKinds() []string {
-	return []string{
-		"openai",
-		// "anthropic", // TODO: Uncomment when implemented
-		// "ollama",    // TODO: Uncomment when implemented
+	kinds := make([]string, 0, len(kindInfos))
+	for _, info := range kindInfos {
+		kinds = append(kinds, info.Kind)
+	}
+	return kinds
+}
+
+// DescribeKind returns the configuration descriptor for a provider kind
+func (f *DefaultFactory) DescribeKind(kind string) (ProviderKindInfo, error) {
+	for _, info := range kindInfos {
+		if info.Kind == kind {
+			return info, nil
+		}
+	}
+
+	return ProviderKindInfo{}, &provider.ProviderError{
+		Provider: kind,
+		Type:     provider.ErrorTypeValidation,
+		Message:  fmt.Sprintf("unsupported provider kind: %s (supported: %v)", kind, f.SupportedKinds()),
 	}
 }
 
 // ProviderManager manages multiple providers and provides utilities
 type ProviderManager struct {
 	factory   provider.Factory
-	providers map[string]provider.Provider
+	providers map[string]provider.Provider // as handed out, wrapped by the interceptors
+	created   map[string]provider.Provider // as the factory made them
+	failed    map[string]error             // why providers the factory couldn't make failed
+	breakers  *breakerRegistry
+
+	interceptors []provider.Interceptor // applied to every provider's Ask, first outermost
+
+	limiters      map[string]*rateLimiter // provider name -> limiter, shared per API key
+	sharedLimiter map[string]*rateLimiter // share key -> limiter
+	limitersMu    sync.Mutex
 }
 
 // NewProviderManager creates a new provider manager
@@ -69,28 +142,133 @@ func NewProviderManager(factory provider.Factory) *ProviderManager {
 	return &ProviderManager{
 		factory:   factory,
 		providers: make(map[string]provider.Provider),
+		created:   make(map[string]provider.Provider),
+		failed:    make(map[string]error),
+		breakers:  newBreakerRegistry(DefaultBreakerConfig()),
+
+		limiters:      make(map[string]*rateLimiter),
+		sharedLimiter: make(map[string]*rateLimiter),
 	}
 }
 
-// CreateProviders creates all providers from a config map
+// SetBreakerConfig replaces the circuit breaker settings for all providers
+func (pm *ProviderManager) SetBreakerConfig(config BreakerConfig) {
+	pm.breakers = newBreakerRegistry(config)
+}
+
+// Allow returns a fast-fail error if the named provider's circuit breaker is open
+func (pm *ProviderManager) Allow(name string) error {
+	return pm.breakers.allow(name)
+}
+
+// RecordResult feeds the outcome of a request to the named provider's circuit breaker
+func (pm *ProviderManager) RecordResult(name string, err error) {
+	pm.breakers.record(name, err)
+}
+
+// Release tells the named provider's circuit breaker that a request Allow let
+// through was never sent, without counting it as a success or a failure
+func (pm *ProviderManager) Release(name string) {
+	pm.breakers.release(name)
+}
+
+// SetRateLimit caps the named provider at rpm requests per minute (0 means
+// unlimited). Providers registered with the same shareKey, typically their API
+// key, draw from one limiter; when their limits differ the lowest applies.
+func (pm *ProviderManager) SetRateLimit(name, shareKey string, rpm int) {
+	if rpm <= 0 {
+		return
+	}
+
+	pm.limitersMu.Lock()
+	defer pm.limitersMu.Unlock()
+
+	limiter, exists := pm.sharedLimiter[shareKey]
+	if !exists || rpm < limiter.rpm {
+		replaced := limiter
+		limiter = newRateLimiter(rpm)
+		pm.sharedLimiter[shareKey] = limiter
+		for other, l := range pm.limiters {
+			if l == replaced {
+				pm.limiters[other] = limiter
+			}
+		}
+	}
+	pm.limiters[name] = limiter
+}
+
+// Wait blocks until the named provider's rate limit allows another request,
+// returning early with the context's error if ctx is done first
+func (pm *ProviderManager) Wait(ctx context.Context, name string) error {
+	pm.limitersMu.Lock()
+	limiter := pm.limiters[name]
+	pm.limitersMu.Unlock()
+
+	if limiter == nil {
+		return nil
+	}
+	return limiter.wait(ctx)
+}
+
+// CreateProviders creates all providers from a config map. A provider that
+// can't be created doesn't stop the others; the returned error lists every
+// failure, by provider name, and CreationErrors has them one by one.
 func (pm *ProviderManager) CreateProviders(configs map[string]provider.ProviderConfig) error {
+	var names []string
 	for name, config := range configs {
-		provider, err := pm.factory.CreateProvider(config)
+		created, err := pm.factory.CreateProvider(config)
 		if err != nil {
-			return fmt.Errorf("failed to create provider %s: %w", name, err)
+			pm.failed[name] = err
+			names = append(names, name)
+			continue
 		}
-		pm.providers[name] = provider
+		delete(pm.failed, name)
+		pm.created[name] = created
+		pm.providers[name] = provider.Intercept(created, name, pm.interceptors)
 	}
-	return nil
+
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("failed to create provider %s: %w", name, pm.failed[name]))
+	}
+	return errors.Join(errs...)
 }
 
-// GetProvider returns a provider by name
+// CreationErrors returns why each provider the factory couldn't create failed,
+// by provider name
+func (pm *ProviderManager) CreationErrors() map[string]error {
+	return pm.failed
+}
+
+// Use adds an interceptor around the Ask of every provider, those already
+// created included, inside the ones added before it. Add interceptors before
+// sending requests; providers handed out earlier aren't rewrapped.
+func (pm *ProviderManager) Use(interceptor provider.Interceptor) {
+	pm.interceptors = append(pm.interceptors, interceptor)
+	for name, created := range pm.created {
+		pm.providers[name] = provider.Intercept(created, name, pm.interceptors)
+	}
+}
+
+// GetProvider returns a provider by name. A provider that couldn't be created
+// or was never configured is reported as unavailable, with the reason.
 func (pm *ProviderManager) GetProvider(name string) (provider.Provider, error) {
 	prov, exists := pm.providers[name]
-	if !exists {
-		return nil, fmt.Errorf("provider %s not found", name)
+	if exists {
+		return prov, nil
+	}
+
+	unavailable := &provider.ProviderError{
+		Provider: name,
+		Type:     provider.ErrorTypeUnavailable,
+		Message:  fmt.Sprintf("provider %s is unavailable: it isn't configured", name),
 	}
-	return prov, nil
+	if err, failed := pm.failed[name]; failed {
+		unavailable.Message = fmt.Sprintf("provider %s is unavailable: it could not be created", name)
+		unavailable.Cause = err
+	}
+	return nil, unavailable
 }
 
 // GetAllProviders returns all managed providers
@@ -102,7 +280,7 @@ func (pm *ProviderManager) GetAllProviders() map[string]provider.Provider {
 func (pm *ProviderManager) CloseAll() error {
 	var errors []error
 
-	for name, prov := range pm.providers {
+	for name, prov := range pm.created {
 		if err := prov.Close(); err != nil {
 			errors = append(errors, fmt.Errorf("failed to close provider %s: %w", name, err))
 		}
@@ -150,14 +328,14 @@ func ValidateProvider(config provider.ProviderConfig) error {
 			}
 		}
 
-	// case "ollama":
-	// 	if config.Host == "" {
-	// 		return &provider.ProviderError{
-	// 			Provider: config.Kind,
-	// 			Type:     provider.ErrorTypeValidation,
-	// 			Message:  "host is required for ollama",
-	// 		}
-	// 	}
+	case "ollama":
+		if config.Host == "" {
+			return &provider.ProviderError{
+				Provider: config.Kind,
+				Type:     provider.ErrorTypeValidation,
+				Message:  "host is required for ollama",
+			}
+		}
 
 	default:
 		return &provider.ProviderError{
@@ -0,0 +1,60 @@
+package factories
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by every provider using the same API key
+type rateLimiter struct {
+	rpm      int
+	rate     float64 // tokens added per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+	mu       sync.Mutex
+}
+
+// newRateLimiter allows rpm requests per minute, in bursts of up to a sixth of
+// that so a full minute's quota isn't spent in the first second
+func newRateLimiter(rpm int) *rateLimiter {
+	capacity := max(float64(rpm)/6, 1)
+	return &rateLimiter{
+		rpm:      rpm,
+		rate:     float64(rpm) / 60,
+		capacity: capacity,
+		tokens:   capacity,
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a request may be sent or ctx is done. Each caller reserves
+// its slot up front, so waiters are released in the order they arrived.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	deficit := -l.tokens
+	l.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(deficit / l.rate * float64(time.Second)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Hand the reserved slot back for the callers still waiting
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package provider
+
+import "context"
+
+// AskFunc has the signature of Provider.Ask
+type AskFunc func(ctx context.Context, prompt string, opts Options) (<-chan Response, error)
+
+// Interceptor wraps a provider's Ask to observe or change its requests and
+// responses: logging bodies, redacting secrets, serving fixtures and the like.
+// name is the provider's name in the config. An interceptor calls next to
+// pass the request on, or answers itself without calling it; when it relays
+// next's channel it must keep Ask's contract and close its own channel
+// promptly once ctx is cancelled.
+type Interceptor func(name string, next AskFunc) AskFunc
+
+// Intercept returns p with its Ask wrapped by interceptors, the first one
+// outermost. The result implements the same optional interfaces (Warmer,
+// SchemaEnforcer, MultiSampler, Embedder) as p, passing them through to p;
+// only Ask is intercepted.
+func Intercept(p Provider, name string, interceptors []Interceptor) Provider {
+	if len(interceptors) == 0 {
+		return p
+	}
+
+	ask := AskFunc(p.Ask)
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		ask = interceptors[i](name, ask)
+	}
+	return withOptionalInterfaces(&interceptedProvider{Provider: p, ask: ask}, p)
+}
+
+// interceptedProvider is a provider whose Ask goes through interceptors
+type interceptedProvider struct {
+	Provider
+	ask AskFunc
+}
+
+func (p *interceptedProvider) Ask(ctx context.Context, prompt string, opts Options) (<-chan Response, error) {
+	return p.ask(ctx, prompt, opts)
+}
+
+// withOptionalInterfaces returns wrapped extended with the optional
+// interfaces inner implements, and no others, so type assertions on the
+// result find the same capabilities as on inner
+func withOptionalInterfaces(wrapped *interceptedProvider, inner Provider) Provider {
+	w, isWarmer := inner.(Warmer)
+	s, isEnforcer := inner.(SchemaEnforcer)
+	m, isSampler := inner.(MultiSampler)
+	e, isEmbedder := inner.(Embedder)
+
+	switch {
+	case isWarmer && isEnforcer && isSampler && isEmbedder:
+		return struct {
+			*interceptedProvider
+			Warmer
+			SchemaEnforcer
+			MultiSampler
+			Embedder
+		}{wrapped, w, s, m, e}
+	case isWarmer && isEnforcer && isSampler:
+		return struct {
+			*interceptedProvider
+			Warmer
+			SchemaEnforcer
+			MultiSampler
+		}{wrapped, w, s, m}
+	case isWarmer && isEnforcer && isEmbedder:
+		return struct {
+			*interceptedProvider
+			Warmer
+			SchemaEnforcer
+			Embedder
+		}{wrapped, w, s, e}
+	case isWarmer && isSampler && isEmbedder:
+		return struct {
+			*interceptedProvider
+			Warmer
+			MultiSampler
+			Embedder
+		}{wrapped, w, m, e}
+	case isEnforcer && isSampler && isEmbedder:
+		return struct {
+			*interceptedProvider
+			SchemaEnforcer
+			MultiSampler
+			Embedder
+		}{wrapped, s, m, e}
+	case isWarmer && isEnforcer:
+		return struct {
+			*interceptedProvider
+			Warmer
+			SchemaEnforcer
+		}{wrapped, w, s}
+	case isWarmer && isSampler:
+		return struct {
+			*interceptedProvider
+			Warmer
+			MultiSampler
+		}{wrapped, w, m}
+	case isWarmer && isEmbedder:
+		return struct {
+			*interceptedProvider
+			Warmer
+			Embedder
+		}{wrapped, w, e}
+	case isEnforcer && isSampler:
+		return struct {
+			*interceptedProvider
+			SchemaEnforcer
+			MultiSampler
+		}{wrapped, s, m}
+	case isEnforcer && isEmbedder:
+		return struct {
+			*interceptedProvider
+			SchemaEnforcer
+			Embedder
+		}{wrapped, s, e}
+	case isSampler && isEmbedder:
+		return struct {
+			*interceptedProvider
+			MultiSampler
+			Embedder
+		}{wrapped, m, e}
+	case isWarmer:
+		return struct {
+			*interceptedProvider
+			Warmer
+		}{wrapped, w}
+	case isEnforcer:
+		return struct {
+			*interceptedProvider
+			SchemaEnforcer
+		}{wrapped, s}
+	case isSampler:
+		return struct {
+			*interceptedProvider
+			MultiSampler
+		}{wrapped, m}
+	case isEmbedder:
+		return struct {
+			*interceptedProvider
+			Embedder
+		}{wrapped, e}
+	default:
+		return wrapped
+	}
+}
@@ -0,0 +1,155 @@
+package provider_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+	"github.com/evisdrenova/devgru/internal/provider/openai"
+	"github.com/evisdrenova/devgru/internal/provider/providertest"
+)
+
+// embeddingProvider is a mock that can also warm up and embed, but has no
+// schema enforcement or native sampling
+type embeddingProvider struct {
+	*providertest.MockProvider
+	warmed bool
+}
+
+func (p *embeddingProvider) Warm(ctx context.Context) error {
+	p.warmed = true
+	return nil
+}
+
+func (p *embeddingProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float64{float64(len(text))}
+	}
+	return vectors, nil
+}
+
+// upperCase is an interceptor that upper-cases every delta
+func upperCase(name string, next provider.AskFunc) provider.AskFunc {
+	return func(ctx context.Context, prompt string, opts provider.Options) (<-chan provider.Response, error) {
+		upstream, err := next(ctx, prompt, opts)
+		if err != nil {
+			return nil, err
+		}
+		out := make(chan provider.Response)
+		go func() {
+			defer close(out)
+			for resp := range upstream {
+				resp.Delta = strings.ToUpper(resp.Delta)
+				select {
+				case out <- resp:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return out, nil
+	}
+}
+
+func collect(t *testing.T, p provider.Provider) string {
+	t.Helper()
+	ch, err := p.Ask(context.Background(), "prompt", provider.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for resp := range ch {
+		b.WriteString(resp.Delta)
+	}
+	return b.String()
+}
+
+func TestInterceptWrapsAsk(t *testing.T) {
+	mock := providertest.NewMockProvider("hello ", "world")
+	mock.Interval = 0
+
+	if p := provider.Intercept(mock, "mock", nil); p != provider.Provider(mock) {
+		t.Error("without interceptors the provider should be returned as is")
+	}
+
+	p := provider.Intercept(mock, "mock", []provider.Interceptor{upperCase})
+	if got := collect(t, p); got != "HELLO WORLD" {
+		t.Errorf("content = %q, want it through the interceptor", got)
+	}
+	if p.GetName() != "mock" || p.GetModel() != "mock-model" {
+		t.Errorf("name, model = %s, %s; want the inner provider's", p.GetName(), p.GetModel())
+	}
+}
+
+func TestInterceptKeepsOnlyInnerInterfaces(t *testing.T) {
+	mock := providertest.NewMockProvider("answer")
+	mock.Interval = 0
+
+	plain := provider.Intercept(mock, "mock", []provider.Interceptor{upperCase})
+	if _, ok := plain.(provider.Warmer); ok {
+		t.Error("intercepted mock is a Warmer")
+	}
+	if _, ok := plain.(provider.SchemaEnforcer); ok {
+		t.Error("intercepted mock is a SchemaEnforcer")
+	}
+	if _, ok := plain.(provider.MultiSampler); ok {
+		t.Error("intercepted mock is a MultiSampler")
+	}
+	if _, ok := plain.(provider.Embedder); ok {
+		t.Error("intercepted mock is an Embedder")
+	}
+
+	inner := &embeddingProvider{MockProvider: mock}
+	p := provider.Intercept(inner, "mock", []provider.Interceptor{upperCase})
+	if _, ok := p.(provider.SchemaEnforcer); ok {
+		t.Error("intercepted embedder is a SchemaEnforcer")
+	}
+	if _, ok := p.(provider.MultiSampler); ok {
+		t.Error("intercepted embedder is a MultiSampler")
+	}
+
+	warmer, ok := p.(provider.Warmer)
+	if !ok {
+		t.Fatal("intercepted embedder lost Warm")
+	}
+	if err := warmer.Warm(context.Background()); err != nil || !inner.warmed {
+		t.Errorf("Warm = %v, warmed %v; want it passed to the inner provider", err, inner.warmed)
+	}
+
+	embedder, ok := p.(provider.Embedder)
+	if !ok {
+		t.Fatal("intercepted embedder lost Embed")
+	}
+	vectors, err := embedder.Embed(context.Background(), []string{"ab", "abcd"})
+	if err != nil || len(vectors) != 2 || vectors[1][0] != 4 {
+		t.Errorf("Embed = %v, %v; want the inner provider's vectors", vectors, err)
+	}
+
+	// Ask still goes through the interceptors
+	if got := collect(t, p); got != "ANSWER" {
+		t.Errorf("content = %q, want it through the interceptor", got)
+	}
+}
+
+func TestInterceptKeepsEveryInterfaceOfOpenAI(t *testing.T) {
+	client, err := openai.NewClient(provider.ProviderConfig{Kind: "openai", Model: "gpt-4o-mini", BaseURL: "http://127.0.0.1:1", APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := provider.Intercept(client, "openai", []provider.Interceptor{upperCase})
+	if _, ok := p.(provider.Warmer); !ok {
+		t.Error("intercepted openai client lost Warm")
+	}
+	if _, ok := p.(provider.SchemaEnforcer); !ok {
+		t.Error("intercepted openai client lost EnforcesResponseSchema")
+	}
+	if sampler, ok := p.(provider.MultiSampler); !ok || !sampler.SupportsN() {
+		t.Error("intercepted openai client lost SupportsN")
+	}
+	if _, ok := p.(provider.Embedder); !ok {
+		t.Error("intercepted openai client lost Embed")
+	}
+}
@@ -0,0 +1,397 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// DefaultEmbeddingModel is used by Embed unless the embedding_model option is set
+const DefaultEmbeddingModel = "nomic-embed-text"
+
+// Client implements the Provider interface for a local Ollama server
+type Client struct {
+	host           string
+	model          string
+	embeddingModel string // model used by Embed
+	httpClient     *http.Client
+	name           string
+}
+
+// NewClient creates a new Ollama provider client. Host is the server's
+// address, e.g. http://localhost:11434; a bare host:port means http.
+func NewClient(config provider.ProviderConfig) (*Client, error) {
+	if config.Host == "" {
+		return nil, &provider.ProviderError{
+			Provider: "ollama",
+			Type:     provider.ErrorTypeValidation,
+			Message:  "host is required",
+		}
+	}
+
+	host := strings.TrimSuffix(config.Host, "/")
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	embeddingModel := config.Options["embedding_model"]
+	if embeddingModel == "" {
+		embeddingModel = DefaultEmbeddingModel
+	}
+
+	return &Client{
+		host:           host,
+		model:          config.Model,
+		embeddingModel: embeddingModel,
+		name:           fmt.Sprintf("ollama-%s", config.Model),
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}, nil
+}
+
+// Ask implements the Provider interface
+func (c *Client) Ask(ctx context.Context, prompt string, opts provider.Options) (<-chan provider.Response, error) {
+	responseChan := make(chan provider.Response, 10)
+
+	go func() {
+		defer close(responseChan)
+		c.sendRequest(ctx, prompt, opts, responseChan)
+	}()
+
+	return responseChan, nil
+}
+
+// GetName returns the provider name
+func (c *Client) GetName() string {
+	return c.name
+}
+
+// GetModel returns the model name
+func (c *Client) GetModel() string {
+	return c.model
+}
+
+// EstimateTokens uses the simple 4-chars-per-token heuristic
+func (c *Client) EstimateTokens(text string) int {
+	return provider.EstimateTokensSimple(text)
+}
+
+// Close cleans up resources
+func (c *Client) Close() error {
+	return nil
+}
+
+// Warm implements provider.Warmer by opening a connection to the server
+func (c *Client) Warm(ctx context.Context) error {
+	return provider.WarmConnection(ctx, c.httpClient, c.host)
+}
+
+// Embed implements provider.Embedder using /api/embeddings, which embeds one
+// text per request
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		var response struct {
+			Embedding []float64 `json:"embedding"`
+		}
+		if err := c.post(ctx, "/api/embeddings", map[string]interface{}{
+			"model":  c.embeddingModel,
+			"prompt": text,
+		}, &response); err != nil {
+			return nil, err
+		}
+		if len(response.Embedding) == 0 {
+			return nil, &provider.ProviderError{
+				Provider: "ollama",
+				Type:     provider.ErrorTypeServerError,
+				Message:  fmt.Sprintf("embeddings response for input %d is empty; is %s an embedding model?", i, c.embeddingModel),
+			}
+		}
+		embeddings[i] = response.Embedding
+	}
+	return embeddings, nil
+}
+
+// post sends a JSON request and decodes the JSON response into out
+func (c *Client) post(ctx context.Context, path string, body map[string]interface{}, out interface{}) error {
+	resp, err := c.do(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errorFromResponse(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &provider.ProviderError{
+			Provider: "ollama",
+			Type:     provider.ErrorTypeValidation,
+			Message:  "failed to parse response",
+			Cause:    err,
+		}
+	}
+	return nil
+}
+
+// do sends a JSON POST request to the server
+func (c *Client) do(ctx context.Context, path string, body map[string]interface{}) (*http.Response, error) {
+	reqBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider: "ollama",
+			Type:     provider.ErrorTypeValidation,
+			Message:  "failed to marshal request",
+			Cause:    err,
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.host+path, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider: "ollama",
+			Type:     provider.ErrorTypeValidation,
+			Message:  "failed to create request",
+			Cause:    err,
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider: "ollama",
+			Type:     provider.ErrorTypeNetwork,
+			Message:  "request failed",
+			Cause:    err,
+		}
+	}
+	return resp, nil
+}
+
+// sendRequest asks /api/chat, streaming or not
+func (c *Client) sendRequest(ctx context.Context, prompt string, opts provider.Options, responseChan chan<- provider.Response) {
+	resp, err := c.do(ctx, "/api/chat", c.buildRequestBody(prompt, opts))
+	if err != nil {
+		send(ctx, responseChan, provider.Response{Error: err})
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		send(ctx, responseChan, provider.Response{Error: errorFromResponse(resp)})
+		return
+	}
+
+	if opts.Stream {
+		c.handleStreamingResponse(ctx, resp.Body, opts.MaxResponseBytes, responseChan)
+		return
+	}
+
+	bodyBytes, err := provider.ReadBody(resp.Body, opts.MaxResponseBytes)
+	if err == nil {
+		var chunk chatChunk
+		if err = json.Unmarshal(bodyBytes, &chunk); err == nil {
+			send(ctx, responseChan, chunk.final(chunk.Message.Content))
+			return
+		}
+	}
+	send(ctx, responseChan, provider.Response{Error: readError(err, opts.MaxResponseBytes)})
+}
+
+// buildRequestBody constructs the /api/chat request body
+func (c *Client) buildRequestBody(prompt string, opts provider.Options) map[string]interface{} {
+	var messages []map[string]string
+	if opts.SystemPrompt != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": opts.SystemPrompt})
+	}
+	if opts.Context != "" {
+		messages = append(messages, map[string]string{"role": "user", "content": opts.Context})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": prompt})
+
+	options := map[string]interface{}{
+		"temperature": opts.Temperature,
+	}
+	if opts.MaxTokens > 0 {
+		options["num_predict"] = opts.MaxTokens
+	}
+	if opts.Seed != nil {
+		options["seed"] = *opts.Seed
+	}
+
+	reqBody := map[string]interface{}{
+		"model":    c.model,
+		"messages": messages,
+		"stream":   opts.Stream,
+		"options":  options,
+	}
+	if opts.ResponseSchema != nil {
+		reqBody["format"] = opts.ResponseSchema
+	}
+
+	provider.MergeRawOptions(reqBody, opts.RawOptions)
+	return reqBody
+}
+
+// handleStreamingResponse reads the newline-delimited JSON chunks /api/chat streams
+func (c *Client) handleStreamingResponse(ctx context.Context, body io.Reader, maxBytes int, responseChan chan<- provider.Response) {
+	reader := bufio.NewReader(body)
+	lineLimit := provider.BodyLimit(maxBytes)
+	var readErr error
+
+	for readErr == nil {
+		var raw string
+		raw, readErr = provider.ReadLine(reader, lineLimit)
+
+		// Stop reading as soon as the caller gives up on the stream
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(readErr, provider.ErrResponseTooLarge) {
+			send(ctx, responseChan, provider.Response{Error: readError(readErr, maxBytes)})
+			return
+		}
+
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		var chunk chatChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			// Skip malformed chunks
+			continue
+		}
+		if chunk.Error != "" {
+			send(ctx, responseChan, provider.Response{Error: &provider.ProviderError{
+				Provider: "ollama",
+				Type:     provider.ErrorTypeServerError,
+				Message:  chunk.Error,
+			}})
+			return
+		}
+		if chunk.Done {
+			send(ctx, responseChan, chunk.final(chunk.Message.Content))
+			return
+		}
+		if chunk.Message.Content != "" && !send(ctx, responseChan, provider.Response{Delta: chunk.Message.Content}) {
+			return
+		}
+	}
+
+	// A cancelled request surfaces as a read error; nobody is listening anymore
+	if ctx.Err() != nil {
+		return
+	}
+	if readErr != io.EOF {
+		send(ctx, responseChan, provider.Response{Error: &provider.ProviderError{
+			Provider: "ollama",
+			Type:     provider.ErrorTypeNetwork,
+			Message:  "error reading stream",
+			Cause:    readErr,
+		}})
+		return
+	}
+	send(ctx, responseChan, provider.Response{Error: &provider.ProviderError{
+		Provider: "ollama",
+		Type:     provider.ErrorTypeNetwork,
+		Message:  "stream ended before the answer was done",
+	}})
+}
+
+// readError explains a failed read of a non-streamed answer
+func readError(err error, maxBytes int) error {
+	if errors.Is(err, provider.ErrResponseTooLarge) {
+		return &provider.ProviderError{
+			Provider: "ollama",
+			Type:     provider.ErrorTypeValidation,
+			Message:  fmt.Sprintf("response exceeded the %d byte limit", maxBytes),
+			Cause:    err,
+		}
+	}
+	return &provider.ProviderError{
+		Provider: "ollama",
+		Type:     provider.ErrorTypeValidation,
+		Message:  "failed to parse response",
+		Cause:    err,
+	}
+}
+
+// errorFromResponse turns an error status into a ProviderError; Ollama says
+// what went wrong (e.g. a model that isn't pulled) in the body's error field
+func errorFromResponse(resp *http.Response) error {
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, provider.MaxErrorBodyBytes))
+	var errorResp struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(bodyBytes, &errorResp)
+
+	errorType := provider.ErrorTypeServerError
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
+		errorType = provider.ErrorTypeValidation
+	}
+	message := fmt.Sprintf("HTTP %d", resp.StatusCode)
+	if errorResp.Error != "" {
+		message = errorResp.Error
+	}
+	return &provider.ProviderError{
+		Provider: "ollama",
+		Type:     errorType,
+		Message:  message,
+	}
+}
+
+// send delivers a response unless the context is cancelled first, so the
+// request goroutine never blocks on a consumer that has stopped reading
+func send(ctx context.Context, responseChan chan<- provider.Response, response provider.Response) bool {
+	select {
+	case responseChan <- response:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// chatChunk is a /api/chat response, or one line of a streamed one
+type chatChunk struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+// final builds the response ending an answer from the last chunk
+func (chunk chatChunk) final(delta string) provider.Response {
+	// Ollama's done_reason is "length" when num_predict cut the answer off,
+	// matching provider.FinishReasonLength
+	return provider.Response{
+		Delta: delta,
+		Done:  true,
+		TokensUsed: &provider.TokenUsage{
+			PromptTokens:     chunk.PromptEvalCount,
+			CompletionTokens: chunk.EvalCount,
+			TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+		},
+		FinishReason: chunk.DoneReason,
+	}
+}
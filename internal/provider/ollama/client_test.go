@@ -0,0 +1,227 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+	"github.com/evisdrenova/devgru/internal/provider/providertest"
+)
+
+func TestCancellationConformance(t *testing.T) {
+	server := providertest.NewStreamingServer()
+	defer server.Close()
+
+	client, err := NewClient(provider.ProviderConfig{
+		Kind:  "ollama",
+		Model: "llama3.1",
+		Host:  server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := providertest.CheckCancellation(client, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.WaitDisconnect(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmbedSendsOneRequestPerText(t *testing.T) {
+	var models, prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Model  string `json:"model"`
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		models = append(models, body.Model)
+		prompts = append(prompts, body.Prompt)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"embedding": []float64{float64(len(body.Prompt)), 1},
+		})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    string
+	}{
+		{name: "default model", want: DefaultEmbeddingModel},
+		{name: "embedding_model option", options: map[string]string{"embedding_model": "mxbai-embed-large"}, want: "mxbai-embed-large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			models, prompts = nil, nil
+			client, err := NewClient(provider.ProviderConfig{
+				Kind:    "ollama",
+				Model:   "llama3.1",
+				Host:    server.URL,
+				Options: tt.options,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			embeddings, err := client.Embed(context.Background(), []string{"a", "bbb"})
+			if err != nil {
+				t.Fatalf("Embed: %v", err)
+			}
+			if len(embeddings) != 2 || embeddings[0][0] != 1 || embeddings[1][0] != 3 {
+				t.Errorf("embeddings = %v, want one per text in order", embeddings)
+			}
+			if strings.Join(prompts, ",") != "a,bbb" {
+				t.Errorf("prompts = %v, want one request per text", prompts)
+			}
+			for _, model := range models {
+				if model != tt.want {
+					t.Errorf("model = %q, want %q", model, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbedReportsServerErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantType provider.ErrorType
+		wantMsg  string
+	}{
+		{name: "model not pulled", status: http.StatusNotFound, body: `{"error":"model \"nomic-embed-text\" not found, try pulling it first"}`, wantType: provider.ErrorTypeValidation, wantMsg: "try pulling it first"},
+		{name: "empty embedding", status: http.StatusOK, body: `{"embedding":[]}`, wantType: provider.ErrorTypeServerError, wantMsg: "is empty"},
+		{name: "server error", status: http.StatusInternalServerError, body: `oops`, wantType: provider.ErrorTypeServerError, wantMsg: "HTTP 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(provider.ProviderConfig{Kind: "ollama", Model: "llama3.1", Host: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = client.Embed(context.Background(), []string{"text"})
+			var providerErr *provider.ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("error = %v, want a ProviderError", err)
+			}
+			if providerErr.Type != tt.wantType || !strings.Contains(providerErr.Message, tt.wantMsg) {
+				t.Errorf("error = %s %q, want %s containing %q", providerErr.Type, providerErr.Message, tt.wantType, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestAskStreamsChatChunks(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hello"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":", world"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":7,"eval_count":2}`)
+	}))
+	defer server.Close()
+
+	// A bare host:port is taken as http
+	client, err := NewClient(provider.ProviderConfig{
+		Kind:  "ollama",
+		Model: "llama3.1",
+		Host:  strings.TrimPrefix(server.URL, "http://") + "/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses, err := client.Ask(context.Background(), "prompt", provider.Options{
+		Stream:       true,
+		SystemPrompt: "be brief",
+		MaxTokens:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content strings.Builder
+	var final provider.Response
+	for response := range responses {
+		if response.Error != nil {
+			t.Fatalf("unexpected error: %v", response.Error)
+		}
+		content.WriteString(response.Delta)
+		if response.Done {
+			final = response
+		}
+	}
+
+	if content.String() != "Hello, world" {
+		t.Errorf("content = %q, want %q", content.String(), "Hello, world")
+	}
+	if final.TokensUsed == nil || final.TokensUsed.TotalTokens != 9 {
+		t.Errorf("usage = %+v, want 9 total tokens", final.TokensUsed)
+	}
+	if final.FinishReason != provider.FinishReasonLength {
+		t.Errorf("finish reason = %q, want %q", final.FinishReason, provider.FinishReasonLength)
+	}
+
+	messages, _ := request["messages"].([]interface{})
+	if len(messages) != 2 {
+		t.Fatalf("sent %d messages, want the system prompt and the prompt", len(messages))
+	}
+	options, _ := request["options"].(map[string]interface{})
+	if options["num_predict"] != float64(2) {
+		t.Errorf("num_predict = %v, want 2", options["num_predict"])
+	}
+}
+
+func TestAskReportsStreamCutShort(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"content":"partial"},"done":false}`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(provider.ProviderConfig{Kind: "ollama", Model: "llama3.1", Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses, err := client.Ask(context.Background(), "prompt", provider.Options{Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lastErr error
+	for response := range responses {
+		if response.Error != nil {
+			lastErr = response.Error
+		}
+	}
+	if lastErr == nil {
+		t.Fatal("stream ended without done and no error was reported")
+	}
+}
@@ -0,0 +1,67 @@
+package openai
+
+import "strings"
+
+// Capabilities describes which request parameters a model accepts
+type Capabilities struct {
+	Temperature       bool   // the model accepts a sampling temperature
+	MaxTokensParam    string // name of the output token limit: max_tokens or max_completion_tokens
+	StructuredOutputs bool   // the model accepts a strict json_schema response_format
+}
+
+// defaultCapabilities applies to every model not listed in modelCapabilities.
+// Structured outputs are off: older models and many OpenAI-compatible servers
+// reject a json_schema response_format.
+var defaultCapabilities = Capabilities{
+	Temperature:    true,
+	MaxTokensParam: "max_tokens",
+}
+
+// modelCapabilities lists models, by name prefix, that differ from the
+// defaults. Reasoning models fix their own temperature and only accept
+// max_completion_tokens; the first matching prefix wins, so o1-mini and
+// o1-preview, which predate structured outputs, come before o1.
+var modelCapabilities = []struct {
+	prefix       string
+	capabilities Capabilities
+}{
+	{"o1-mini", Capabilities{Temperature: false, MaxTokensParam: "max_completion_tokens"}},
+	{"o1-preview", Capabilities{Temperature: false, MaxTokensParam: "max_completion_tokens"}},
+	{"o1", Capabilities{Temperature: false, MaxTokensParam: "max_completion_tokens", StructuredOutputs: true}},
+	{"o3", Capabilities{Temperature: false, MaxTokensParam: "max_completion_tokens", StructuredOutputs: true}},
+	{"o4", Capabilities{Temperature: false, MaxTokensParam: "max_completion_tokens", StructuredOutputs: true}},
+	{"gpt-5", Capabilities{Temperature: false, MaxTokensParam: "max_completion_tokens", StructuredOutputs: true}},
+	{"gpt-4o", Capabilities{Temperature: true, MaxTokensParam: "max_tokens", StructuredOutputs: true}},
+	{"gpt-4.1", Capabilities{Temperature: true, MaxTokensParam: "max_tokens", StructuredOutputs: true}},
+}
+
+// CapabilitiesFor returns the built-in capabilities of a model
+func CapabilitiesFor(model string) Capabilities {
+	for _, entry := range modelCapabilities {
+		if strings.HasPrefix(model, entry.prefix) {
+			return entry.capabilities
+		}
+	}
+	return defaultCapabilities
+}
+
+// withOverrides applies the send_temperature, max_tokens_param and
+// structured_outputs provider options on top of the built-in capabilities
+func (c Capabilities) withOverrides(options map[string]string) Capabilities {
+	switch options["send_temperature"] {
+	case "true":
+		c.Temperature = true
+	case "false":
+		c.Temperature = false
+	}
+	switch options["structured_outputs"] {
+	case "true":
+		c.StructuredOutputs = true
+	case "false":
+		c.StructuredOutputs = false
+	}
+	if param := options["max_tokens_param"]; param != "" {
+		c.MaxTokensParam = param
+	}
+	return c
+}
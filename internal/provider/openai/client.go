@@ -5,9 +5,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,8 +23,22 @@ type Client struct {
 	model      string
 	httpClient *http.Client
 	name       string
+
+	capabilities      Capabilities // request parameters the model accepts
+	embeddingModel    string       // model used by Embed
+	streamBufferBytes int          // read buffer for streamed responses
 }
 
+// DefaultBaseURL is the API endpoint used when no base_url is configured
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// DefaultEmbeddingModel is used by Embed unless the embedding_model option is set
+const DefaultEmbeddingModel = "text-embedding-3-small"
+
+// DefaultStreamBufferBytes is the read buffer for streamed responses unless
+// the stream_buffer_bytes option is set
+const DefaultStreamBufferBytes = 64 << 10
+
 // NewClient creates a new OpenAI provider client
 func NewClient(config provider.ProviderConfig) (*Client, error) {
 	if config.APIKey == "" {
@@ -34,7 +50,7 @@ func NewClient(config provider.ProviderConfig) (*Client, error) {
 	}
 
 	if config.BaseURL == "" {
-		config.BaseURL = "https://api.openai.com/v1"
+		config.BaseURL = DefaultBaseURL
 	}
 
 	timeout := config.Timeout
@@ -42,6 +58,24 @@ func NewClient(config provider.ProviderConfig) (*Client, error) {
 		timeout = 60 * time.Second
 	}
 
+	embeddingModel := config.Options["embedding_model"]
+	if embeddingModel == "" {
+		embeddingModel = DefaultEmbeddingModel
+	}
+
+	streamBufferBytes := DefaultStreamBufferBytes
+	if value := config.Options["stream_buffer_bytes"]; value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return nil, &provider.ProviderError{
+				Provider: "openai",
+				Type:     provider.ErrorTypeValidation,
+				Message:  fmt.Sprintf("invalid stream_buffer_bytes %q", value),
+			}
+		}
+		streamBufferBytes = size
+	}
+
 	return &Client{
 		baseURL: config.BaseURL,
 		apiKey:  config.APIKey,
@@ -50,6 +84,9 @@ func NewClient(config provider.ProviderConfig) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: timeout,
 		},
+		capabilities:      CapabilitiesFor(config.Model).withOverrides(config.Options),
+		embeddingModel:    embeddingModel,
+		streamBufferBytes: streamBufferBytes,
 	}, nil
 }
 
@@ -118,33 +155,138 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// Warm implements provider.Warmer by opening a connection to the API host
+func (c *Client) Warm(ctx context.Context) error {
+	return provider.WarmConnection(ctx, c.httpClient, c.baseURL)
+}
+
+// EnforcesResponseSchema implements provider.SchemaEnforcer via a strict
+// response_format, for models with structured outputs
+func (c *Client) EnforcesResponseSchema() bool {
+	return c.capabilities.StructuredOutputs
+}
+
+// SupportsN implements provider.MultiSampler via the n request parameter
+func (c *Client) SupportsN() bool {
+	return true
+}
+
+// Embed implements provider.Embedder using the embeddings endpoint
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	reqBytes, err := json.Marshal(map[string]interface{}{
+		"model": c.embeddingModel,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider: "openai",
+			Type:     provider.ErrorTypeValidation,
+			Message:  "failed to marshal embeddings request",
+			Cause:    err,
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/embeddings", bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider: "openai",
+			Type:     provider.ErrorTypeValidation,
+			Message:  "failed to create embeddings request",
+			Cause:    err,
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &provider.ProviderError{
+			Provider: "openai",
+			Type:     provider.ErrorTypeNetwork,
+			Message:  "embeddings request failed",
+			Cause:    err,
+		}
+	}
+	defer resp.Body.Close()
+
+	requestID := resp.Header.Get("X-Request-Id")
+	if resp.StatusCode != http.StatusOK {
+		var errorResp openAIErrorResponse
+		json.NewDecoder(resp.Body).Decode(&errorResp)
+		message := fmt.Sprintf("embeddings request failed: HTTP %d", resp.StatusCode)
+		if errorResp.Error.Message != "" {
+			message = errorResp.Error.Message
+		}
+		return nil, &provider.ProviderError{
+			Provider:  "openai",
+			RequestID: requestID,
+			Type:      provider.ErrorTypeServerError,
+			Message:   message,
+		}
+	}
+
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, &provider.ProviderError{
+			Provider:  "openai",
+			RequestID: requestID,
+			Type:      provider.ErrorTypeValidation,
+			Message:   "failed to parse embeddings response",
+			Cause:     err,
+		}
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for _, item := range response.Data {
+		if item.Index >= 0 && item.Index < len(embeddings) {
+			embeddings[item.Index] = item.Embedding
+		}
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, &provider.ProviderError{
+				Provider:  "openai",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeServerError,
+				Message:   fmt.Sprintf("embeddings response is missing input %d", i),
+			}
+		}
+	}
+	return embeddings, nil
+}
+
 // streamRequest handles the actual streaming request to OpenAI
 func (c *Client) streamRequest(ctx context.Context, prompt string, opts provider.Options, responseChan chan<- provider.Response) {
 	reqBody := c.buildRequestBody(prompt, opts)
 
 	reqBytes, err := json.Marshal(reqBody)
 	if err != nil {
-		responseChan <- provider.Response{
+		send(ctx, responseChan, provider.Response{
 			Error: &provider.ProviderError{
 				Provider: "openai",
 				Type:     provider.ErrorTypeValidation,
 				Message:  "failed to marshal request",
 				Cause:    err,
 			},
-		}
+		})
 		return
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(reqBytes))
 	if err != nil {
-		responseChan <- provider.Response{
+		send(ctx, responseChan, provider.Response{
 			Error: &provider.ProviderError{
 				Provider: "openai",
 				Type:     provider.ErrorTypeValidation,
 				Message:  "failed to create request",
 				Cause:    err,
 			},
-		}
+		})
 		return
 	}
 
@@ -156,27 +298,30 @@ func (c *Client) streamRequest(ctx context.Context, prompt string, opts provider
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		responseChan <- provider.Response{
+		send(ctx, responseChan, provider.Response{
 			Error: &provider.ProviderError{
 				Provider: "openai",
 				Type:     provider.ErrorTypeNetwork,
 				Message:  "request failed",
 				Cause:    err,
 			},
-		}
+		})
 		return
 	}
 	defer resp.Body.Close()
 
+	// The provider's id for this request, quoted in errors and stats for support tickets
+	requestID := resp.Header.Get("X-Request-Id")
+
 	if resp.StatusCode != http.StatusOK {
-		c.handleErrorResponse(resp, responseChan)
+		c.handleErrorResponse(ctx, resp, requestID, responseChan)
 		return
 	}
 
 	if opts.Stream {
-		c.handleStreamingResponse(resp.Body, responseChan)
+		c.handleStreamingResponse(ctx, resp.Body, requestID, opts.MaxResponseBytes, responseChan)
 	} else {
-		c.handleNonStreamingResponse(resp.Body, responseChan)
+		c.handleNonStreamingResponse(ctx, resp.Body, requestID, opts.MaxResponseBytes, responseChan)
 	}
 }
 
@@ -189,6 +334,16 @@ func (c *Client) buildRequestBody(prompt string, opts provider.Options) map[stri
 		},
 	}
 
+	// Send stable context as its own message ahead of the prompt
+	if opts.Context != "" {
+		messages = append([]map[string]string{
+			{
+				"role":    "user",
+				"content": opts.Context,
+			},
+		}, messages...)
+	}
+
 	// Add system message if provided
 	if opts.SystemPrompt != "" {
 		messages = append([]map[string]string{
@@ -200,10 +355,14 @@ func (c *Client) buildRequestBody(prompt string, opts provider.Options) map[stri
 	}
 
 	reqBody := map[string]interface{}{
-		"model":       c.model,
-		"messages":    messages,
-		"temperature": opts.Temperature,
-		"stream":      opts.Stream,
+		"model":    c.model,
+		"messages": messages,
+		"stream":   opts.Stream,
+	}
+
+	// Leave out parameters the model would reject with a 400
+	if c.capabilities.Temperature {
+		reqBody["temperature"] = opts.Temperature
 	}
 
 	// Add stream_options to get usage data in streaming mode
@@ -214,20 +373,68 @@ func (c *Client) buildRequestBody(prompt string, opts provider.Options) map[stri
 	}
 
 	if opts.MaxTokens > 0 {
-		reqBody["max_tokens"] = opts.MaxTokens
+		reqBody[c.capabilities.MaxTokensParam] = opts.MaxTokens
+	}
+
+	if opts.N > 1 {
+		reqBody["n"] = opts.N
+	}
+
+	if opts.Seed != nil {
+		reqBody["seed"] = *opts.Seed
+	}
+
+	// Structured outputs: the model answers with JSON matching the schema.
+	// Models without them would reject the request, so the schema is left out.
+	if opts.ResponseSchema != nil && c.capabilities.StructuredOutputs {
+		reqBody["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "response",
+				"strict": true,
+				"schema": opts.ResponseSchema,
+			},
+		}
 	}
 
+	provider.MergeRawOptions(reqBody, opts.RawOptions)
 	return reqBody
 }
 
 // handleStreamingResponse processes Server-Sent Events from OpenAI
-func (c *Client) handleStreamingResponse(body io.Reader, responseChan chan<- provider.Response) {
-	scanner := bufio.NewScanner(body)
+func (c *Client) handleStreamingResponse(ctx context.Context, body io.Reader, requestID string, maxBytes int, responseChan chan<- provider.Response) {
+	// Lines are only limited by maxBytes, so a chunk carrying a large piece
+	// of generated code can't cut the stream short the way a Scanner would
+	reader := bufio.NewReaderSize(body, c.streamBufferBytes)
+	lineLimit := provider.BodyLimit(maxBytes)
 	var totalTokens *provider.TokenUsage
 	var contentBuilder strings.Builder
+	var finishReason string
+	var readErr error
+
+	for readErr == nil {
+		var raw string
+		raw, readErr = provider.ReadLine(reader, lineLimit)
+
+		// Stop reading as soon as the caller gives up on the stream
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(readErr, provider.ErrResponseTooLarge) {
+			send(ctx, responseChan, provider.Response{
+				Error: &provider.ProviderError{
+					Provider:  "openai",
+					RequestID: requestID,
+					Type:      provider.ErrorTypeValidation,
+					Message:   fmt.Sprintf("response exceeded the %d byte limit", maxBytes),
+					Cause:     readErr,
+				},
+			})
+			return
+		}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		line := strings.TrimSpace(raw)
 
 		if line == "" {
 			continue
@@ -245,11 +452,13 @@ func (c *Client) handleStreamingResponse(body io.Reader, responseChan chan<- pro
 				}
 			}
 
-			responseChan <- provider.Response{
-				Delta:      "",
-				Done:       true,
-				TokensUsed: totalTokens,
-			}
+			send(ctx, responseChan, provider.Response{
+				Delta:        "",
+				Done:         true,
+				RequestID:    requestID,
+				TokensUsed:   totalTokens,
+				FinishReason: finishReason,
+			})
 			return
 		}
 
@@ -265,32 +474,62 @@ func (c *Client) handleStreamingResponse(body io.Reader, responseChan chan<- pro
 			continue
 		}
 
-		// Process the chunk
-		if len(chunk.Choices) > 0 {
-			choice := chunk.Choices[0]
-
+		// Process the chunk; with n above 1 each choice has its own index
+		for _, choice := range chunk.Choices {
 			// Send content delta and accumulate content
 			if choice.Delta.Content != "" {
 				contentBuilder.WriteString(choice.Delta.Content)
-				responseChan <- provider.Response{
-					Delta: choice.Delta.Content,
-					Done:  false,
+				if !send(ctx, responseChan, provider.Response{
+					Delta:  choice.Delta.Content,
+					Done:   false,
+					Choice: choice.Index,
+				}) {
+					return
 				}
 			}
 
-			// Check for completion
+			// Check for completion; the first choice reports its finish
+			// reason with the final response, later ones as they end
 			if choice.FinishReason != nil {
-				// This is the final chunk, try to get usage info
-				if chunk.Usage != nil {
-					totalTokens = &provider.TokenUsage{
-						PromptTokens:     chunk.Usage.PromptTokens,
-						CompletionTokens: chunk.Usage.CompletionTokens,
-						TotalTokens:      chunk.Usage.TotalTokens,
+				if choice.Index > 0 {
+					if !send(ctx, responseChan, provider.Response{Choice: choice.Index, FinishReason: *choice.FinishReason}) {
+						return
 					}
+					continue
 				}
+				finishReason = *choice.FinishReason
 				// Don't return here - wait for [DONE] message
 			}
 		}
+
+		// Usage comes with the final chunk, which has no choices when
+		// include_usage is set
+		if chunk.Usage != nil {
+			totalTokens = &provider.TokenUsage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+	}
+
+	// A cancelled request surfaces as a read error; nobody is listening anymore
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Report read failures instead of pretending the stream completed
+	if readErr != io.EOF {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider:  "openai",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeNetwork,
+				Message:   "error reading stream",
+				Cause:     readErr,
+			},
+		})
+		return
 	}
 
 	// If we exit the loop without seeing [DONE], still send final response
@@ -304,64 +543,81 @@ func (c *Client) handleStreamingResponse(body io.Reader, responseChan chan<- pro
 		}
 	}
 
-	responseChan <- provider.Response{
-		Delta:      "",
-		Done:       true,
-		TokensUsed: totalTokens,
-	}
-
-	if err := scanner.Err(); err != nil {
-		responseChan <- provider.Response{
-			Error: &provider.ProviderError{
-				Provider: "openai",
-				Type:     provider.ErrorTypeNetwork,
-				Message:  "error reading stream",
-				Cause:    err,
-			},
-		}
-	}
+	send(ctx, responseChan, provider.Response{
+		Delta:        "",
+		Done:         true,
+		RequestID:    requestID,
+		TokensUsed:   totalTokens,
+		FinishReason: finishReason,
+	})
 }
 
 // handleNonStreamingResponse processes a complete response from OpenAI
-func (c *Client) handleNonStreamingResponse(body io.Reader, responseChan chan<- provider.Response) {
+func (c *Client) handleNonStreamingResponse(ctx context.Context, body io.Reader, requestID string, maxBytes int, responseChan chan<- provider.Response) {
 	var response openAIResponse
 
-	bodyBytes, err := io.ReadAll(body)
+	bodyBytes, err := provider.ReadBody(body, maxBytes)
+	if errors.Is(err, provider.ErrResponseTooLarge) {
+		send(ctx, responseChan, provider.Response{
+			Error: &provider.ProviderError{
+				Provider:  "openai",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeValidation,
+				Message:   fmt.Sprintf("response exceeded the %d byte limit", maxBytes),
+				Cause:     err,
+			},
+		})
+		return
+	}
 	if err != nil {
-		responseChan <- provider.Response{
+		send(ctx, responseChan, provider.Response{
 			Error: &provider.ProviderError{
-				Provider: "openai",
-				Type:     provider.ErrorTypeNetwork,
-				Message:  "failed to read response body",
-				Cause:    err,
+				Provider:  "openai",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeNetwork,
+				Message:   "failed to read response body",
+				Cause:     err,
 			},
-		}
+		})
 		return
 	}
 
 	if err := json.Unmarshal(bodyBytes, &response); err != nil {
-		responseChan <- provider.Response{
+		send(ctx, responseChan, provider.Response{
 			Error: &provider.ProviderError{
-				Provider: "openai",
-				Type:     provider.ErrorTypeValidation,
-				Message:  "failed to parse response",
-				Cause:    err,
+				Provider:  "openai",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeValidation,
+				Message:   "failed to parse response",
+				Cause:     err,
 			},
-		}
+		})
 		return
 	}
 
 	if len(response.Choices) == 0 {
-		responseChan <- provider.Response{
+		send(ctx, responseChan, provider.Response{
 			Error: &provider.ProviderError{
-				Provider: "openai",
-				Type:     provider.ErrorTypeServerError,
-				Message:  "no choices in response",
+				Provider:  "openai",
+				RequestID: requestID,
+				Type:      provider.ErrorTypeServerError,
+				Message:   "no choices in response",
 			},
-		}
+		})
 		return
 	}
 
+	// Completions past the first go ahead of the final response, which ends the request
+	for i, choice := range response.Choices[1:] {
+		if !send(ctx, responseChan, provider.Response{
+			Delta:        choice.Message.Content,
+			Choice:       i + 1,
+			FinishReason: choice.FinishReason,
+		}) {
+			return
+		}
+	}
+
 	content := response.Choices[0].Message.Content
 	var tokenUsage *provider.TokenUsage
 
@@ -374,16 +630,18 @@ func (c *Client) handleNonStreamingResponse(body io.Reader, responseChan chan<-
 	}
 
 	// Send the complete content as a single response
-	responseChan <- provider.Response{
-		Delta:      content,
-		Done:       true,
-		TokensUsed: tokenUsage,
-	}
+	send(ctx, responseChan, provider.Response{
+		Delta:        content,
+		Done:         true,
+		RequestID:    requestID,
+		TokensUsed:   tokenUsage,
+		FinishReason: response.Choices[0].FinishReason,
+	})
 }
 
 // handleErrorResponse processes error responses from OpenAI
-func (c *Client) handleErrorResponse(resp *http.Response, responseChan chan<- provider.Response) {
-	bodyBytes, _ := io.ReadAll(resp.Body)
+func (c *Client) handleErrorResponse(ctx context.Context, resp *http.Response, requestID string, responseChan chan<- provider.Response) {
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, provider.MaxErrorBodyBytes))
 
 	var errorResp openAIErrorResponse
 	json.Unmarshal(bodyBytes, &errorResp)
@@ -407,12 +665,24 @@ func (c *Client) handleErrorResponse(resp *http.Response, responseChan chan<- pr
 		message = errorResp.Error.Message
 	}
 
-	responseChan <- provider.Response{
+	send(ctx, responseChan, provider.Response{
 		Error: &provider.ProviderError{
-			Provider: "openai",
-			Type:     errorType,
-			Message:  message,
+			Provider:  "openai",
+			RequestID: requestID,
+			Type:      errorType,
+			Message:   message,
 		},
+	})
+}
+
+// send delivers a response unless the context is cancelled first, so the
+// request goroutine never blocks on a consumer that has stopped reading
+func send(ctx context.Context, responseChan chan<- provider.Response, response provider.Response) bool {
+	select {
+	case responseChan <- response:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
 
@@ -433,6 +703,7 @@ type openAIResponse struct {
 
 type openAIStreamChunk struct {
 	Choices []struct {
+		Index int `json:"index"`
 		Delta struct {
 			Content string `json:"content"`
 		} `json:"delta"`
@@ -0,0 +1,119 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+	"github.com/evisdrenova/devgru/internal/provider/providertest"
+)
+
+func TestCancellationConformance(t *testing.T) {
+	server := providertest.NewStreamingServer()
+	defer server.Close()
+
+	client, err := NewClient(provider.ProviderConfig{
+		Kind:    "openai",
+		Model:   "gpt-4o-mini",
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := providertest.CheckCancellation(client, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.WaitDisconnect(0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResponseSchemaOnlyForStructuredOutputs(t *testing.T) {
+	schema := map[string]interface{}{"type": "object"}
+	tests := []struct {
+		model   string
+		options map[string]string
+		want    bool
+	}{
+		{model: "gpt-4o-mini", want: true},
+		{model: "gpt-4.1", want: true},
+		{model: "o3-mini", want: true},
+		{model: "o1-mini", want: false},
+		{model: "gpt-3.5-turbo", want: false},
+		{model: "llama3", want: false},
+		{model: "llama3", options: map[string]string{"structured_outputs": "true"}, want: true},
+		{model: "gpt-4o", options: map[string]string{"structured_outputs": "false"}, want: false},
+	}
+	for _, tt := range tests {
+		client, err := NewClient(provider.ProviderConfig{Kind: "openai", Model: tt.model, APIKey: "test-key", Options: tt.options})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := client.EnforcesResponseSchema(); got != tt.want {
+			t.Errorf("%s %v: EnforcesResponseSchema = %v, want %v", tt.model, tt.options, got, tt.want)
+		}
+
+		format, sent := client.buildRequestBody("prompt", provider.Options{ResponseSchema: schema})["response_format"].(map[string]interface{})
+		if sent != tt.want {
+			t.Errorf("%s %v: response_format sent = %v, want %v", tt.model, tt.options, sent, tt.want)
+			continue
+		}
+		if sent && format["json_schema"].(map[string]interface{})["strict"] != true {
+			t.Errorf("%s: json_schema = %v, want strict mode", tt.model, format["json_schema"])
+		}
+	}
+}
+
+func BenchmarkHandleStreamingResponse(b *testing.B) {
+	// ~200KB of deltas, like a long code-generation answer
+	var body strings.Builder
+	chunk, _ := json.Marshal(map[string]interface{}{
+		"choices": []map[string]interface{}{
+			{"delta": map[string]string{"content": strings.Repeat("func main() {} ", 8)}},
+		},
+	})
+	for body.Len() < 200<<10 {
+		fmt.Fprintf(&body, "data: %s\n\n", chunk)
+	}
+	body.WriteString("data: [DONE]\n\n")
+	stream := body.String()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, stream)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(provider.ProviderConfig{
+		Kind:    "openai",
+		Model:   "gpt-4o-mini",
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	for b.Loop() {
+		responseChan, err := client.Ask(context.Background(), "prompt", provider.Options{Stream: true})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for response := range responseChan {
+			if response.Error != nil {
+				b.Fatal(response.Error)
+			}
+		}
+	}
+}
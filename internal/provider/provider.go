@@ -1,13 +1,23 @@
 package provider
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // Provider defines the interface for all LLM providers
 type Provider interface {
-	// Ask sends a prompt to the LLM and returns a channel of streaming responses
+	// Ask sends a prompt to the LLM and returns a channel of streaming responses.
+	// Implementations must close the channel when the request finishes, and once
+	// ctx is cancelled they must stop reading from the upstream, stop sending,
+	// and close the channel promptly without waiting for a consumer.
 	Ask(ctx context.Context, prompt string, opts Options) (<-chan Response, error)
 
 	// GetName returns the provider name for identification
@@ -23,12 +33,145 @@ type Provider interface {
 	Close() error
 }
 
+// Warmer is implemented by providers that can open their connection ahead of
+// the first request, so that request doesn't pay for TLS and connection setup
+type Warmer interface {
+	Warm(ctx context.Context) error
+}
+
+// SchemaEnforcer is implemented by providers that can make the model answer
+// with JSON matching Options.ResponseSchema
+type SchemaEnforcer interface {
+	EnforcesResponseSchema() bool
+}
+
+// MultiSampler is implemented by providers that can generate several
+// completions of one prompt in a single request (Options.N), so the prompt
+// is only paid for once
+type MultiSampler interface {
+	SupportsN() bool
+}
+
+// Embedder is implemented by providers that can turn text into embedding
+// vectors, one per input, in input order
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// WarmConnection sends a HEAD request to url so client's transport keeps an
+// idle, already-negotiated connection to the host. Any HTTP status counts as
+// success; only failing to connect is an error.
+func WarmConnection(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	// Draining and closing the body returns the connection to the idle pool
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
 // Options contains parameters for the LLM request
 type Options struct {
 	Temperature  float64 `json:"temperature"`
 	MaxTokens    int     `json:"max_tokens"`
 	SystemPrompt string  `json:"system_prompt,omitempty"`
 	Stream       bool    `json:"stream"`
+
+	// Context is stable material (e.g. project context) sent ahead of the
+	// prompt; providers that support prompt caching may cache it
+	Context string `json:"context,omitempty"`
+
+	// RawOptions are extra request body fields the provider doesn't model
+	// itself (e.g. OpenAI user or reasoning_effort), passed through as-is
+	RawOptions map[string]interface{} `json:"raw_options,omitempty"`
+
+	// ResponseSchema is a JSON Schema the answer should match; providers that
+	// implement SchemaEnforcer send it natively, the rest ignore it
+	ResponseSchema map[string]interface{} `json:"response_schema,omitempty"`
+
+	// N is how many completions to generate (0 or 1 for one); providers that
+	// implement MultiSampler stream the extra ones on Response.Choice 1 and
+	// up, the rest ignore it
+	N int `json:"n,omitempty"`
+
+	// Seed asks for deterministic sampling when set; providers that support
+	// it (e.g. OpenAI) send it, the rest ignore it. Determinism is best
+	// effort even then.
+	Seed *int64 `json:"seed,omitempty"`
+
+	// MaxResponseBytes is the most answer text the caller keeps (0: no
+	// limit). StreamCollector.MaxBytes enforces it on the text; providers
+	// also stop reading bodies and stream lines far larger than it (see
+	// ReadBody), so a runaway response can't exhaust memory first.
+	MaxResponseBytes int `json:"max_response_bytes,omitempty"`
+}
+
+// MaxErrorBodyBytes is the most of an error response body providers read
+const MaxErrorBodyBytes = 64 << 10
+
+// bodyOverheadBytes is the room a response body gets, beyond twice the text
+// limit, for its JSON envelope and escaping
+const bodyOverheadBytes = 64 << 10
+
+// ErrResponseTooLarge is returned by ReadBody for a body past its limit
+var ErrResponseTooLarge = errors.New("response too large")
+
+// BodyLimit returns the most bytes of raw response (a body, or a stream
+// line) a provider reads for an answer of at most maxBytes of text; 0 means
+// no limit
+func BodyLimit(maxBytes int) int {
+	if maxBytes <= 0 {
+		return 0
+	}
+	return 2*maxBytes + bodyOverheadBytes
+}
+
+// ReadBody reads a whole response body, failing with ErrResponseTooLarge
+// rather than reading past BodyLimit(maxBytes)
+func ReadBody(body io.Reader, maxBytes int) ([]byte, error) {
+	limit := BodyLimit(maxBytes)
+	if limit == 0 {
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	if err == nil && len(data) > limit {
+		return nil, fmt.Errorf("%w: body exceeded %d bytes", ErrResponseTooLarge, limit)
+	}
+	return data, err
+}
+
+// ReadLine reads through the next newline like ReadString, but fails with
+// ErrResponseTooLarge once the line passes limit bytes (0: no limit), so a
+// stream line is neither cut short nor read without bound
+func ReadLine(reader *bufio.Reader, limit int) (string, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		if limit > 0 && len(line) > limit {
+			return "", fmt.Errorf("%w: stream line exceeded %d bytes", ErrResponseTooLarge, limit)
+		}
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return string(line), err
+		}
+	}
+}
+
+// MergeRawOptions adds raw options to a request body; fields the provider
+// already set always win, so raw options can't clobber the core request
+func MergeRawOptions(body map[string]interface{}, raw map[string]interface{}) {
+	for key, value := range raw {
+		if _, exists := body[key]; !exists {
+			body[key] = value
+		}
+	}
 }
 
 // Response represents a single chunk of the streaming response
@@ -42,6 +185,20 @@ type Response struct {
 	// TokensUsed contains token usage information (populated on final response)
 	TokensUsed *TokenUsage `json:"tokens_used,omitempty"`
 
+	// FinishReason says why generation stopped (populated on final response
+	// when the provider reports it); FinishReasonLength means it was cut off
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// RequestID is the provider's id for the request (e.g. OpenAI's
+	// x-request-id header), populated on the final response
+	RequestID string `json:"request_id,omitempty"`
+
+	// Choice is the completion this chunk belongs to when Options.N asked
+	// for several. Chunks for choice 0 work as usual; later choices carry
+	// their text in Delta and their FinishReason once they end, and never
+	// set Done, which always ends the whole request.
+	Choice int `json:"choice,omitempty"`
+
 	// Error contains any error that occurred
 	Error error `json:"error,omitempty"`
 
@@ -54,21 +211,31 @@ type TokenUsage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// Prompt tokens written to / served from the provider's prompt cache,
+	// included in PromptTokens but billed at different rates
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+	CacheReadTokens     int `json:"cache_read_tokens,omitempty"`
 }
 
 // ProviderError represents errors specific to provider operations
 type ProviderError struct {
-	Provider string
-	Type     ErrorType
-	Message  string
-	Cause    error
+	Provider  string
+	Type      ErrorType
+	Message   string
+	Cause     error
+	RequestID string // the provider's id for the failed request, when it sent one
 }
 
 func (e *ProviderError) Error() string {
+	message := e.Message
 	if e.Cause != nil {
-		return e.Message + ": " + e.Cause.Error()
+		message += ": " + e.Cause.Error()
+	}
+	if e.RequestID != "" {
+		message += " (request id " + e.RequestID + ")"
 	}
-	return e.Message
+	return message
 }
 
 func (e *ProviderError) Unwrap() error {
@@ -86,9 +253,14 @@ const (
 	ErrorTypeNetwork     ErrorType = "network"      // Network connectivity
 	ErrorTypeValidation  ErrorType = "validation"   // Invalid request parameters
 	ErrorTypeServerError ErrorType = "server_error" // Provider server error
+	ErrorTypeUnavailable ErrorType = "unavailable"  // Skipped by the circuit breaker, or not created
 	ErrorTypeUnknown     ErrorType = "unknown"      // Unexpected error
 )
 
+// FinishReasonLength is the finish reason of a response cut off by its
+// max_tokens limit; providers normalize their own equivalent to it
+const FinishReasonLength = "length"
+
 // Stats contains performance and cost information for a provider request
 type Stats struct {
 	Provider      string        `json:"provider"`
@@ -100,6 +272,17 @@ type Stats struct {
 	EstimatedCost float64       `json:"estimated_cost"`
 	Success       bool          `json:"success"`
 	Error         error         `json:"error,omitempty"`
+	FinishReason  string        `json:"finish_reason,omitempty"`
+	RequestID     string        `json:"request_id,omitempty"` // provider's id for the request, for support tickets
+
+	// TimeToFirstToken is how long after StartTime the first text arrived,
+	// zero if none did
+	TimeToFirstToken time.Duration `json:"time_to_first_token,omitempty"`
+}
+
+// Truncated reports whether the response was cut off by its max_tokens limit
+func (s *Stats) Truncated() bool {
+	return s != nil && s.FinishReason == FinishReasonLength
 }
 
 // ProviderConfig contains configuration for initializing providers
@@ -120,12 +303,34 @@ type Factory interface {
 	SupportedKinds() []string
 }
 
+// Choice is a completion past the first, collected from a request that
+// asked for several with Options.N
+type Choice struct {
+	Content      string
+	FinishReason string
+}
+
 // StreamCollector is a utility for collecting streaming responses
 type StreamCollector struct {
 	Content    string
 	TokensUsed *TokenUsage
 	Stats      *Stats
 	Error      error
+
+	// Extra holds the completions past the first, Extra[i] being
+	// Response.Choice i+1; TokensUsed covers them all
+	Extra []Choice
+
+	// IdleTimeout is the maximum time allowed between chunks (0 disables it)
+	IdleTimeout time.Duration
+
+	// MaxBytes caps the accumulated Content (0 disables it); a response that
+	// grows past it fails with an ErrorTypeValidation, keeping what fit
+	MaxBytes int
+
+	// OnDelta, when set, is called with each non-empty chunk of text as it
+	// arrives, on the collecting goroutine; Content still accumulates it all
+	OnDelta func(delta string)
 }
 
 // NewStreamCollector creates a new stream collector
@@ -137,13 +342,24 @@ func NewStreamCollector() *StreamCollector {
 	}
 }
 
-// Collect reads all responses from a channel and accumulates the content
+// Collect reads all responses from a channel and accumulates the content.
+// When IdleTimeout is set and no chunk arrives within it, Collect gives up with
+// an ErrorTypeTimeout; the caller should then cancel the request context.
 func (sc *StreamCollector) Collect(ctx context.Context, responseChan <-chan Response) {
 	defer func() {
 		sc.Stats.EndTime = time.Now()
 		sc.Stats.Duration = sc.Stats.EndTime.Sub(sc.Stats.StartTime)
 	}()
 
+	// A nil timer channel never fires, which leaves the idle check disabled
+	var idleTimer *time.Timer
+	var idle <-chan time.Time
+	if sc.IdleTimeout > 0 {
+		idleTimer = time.NewTimer(sc.IdleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
 	for {
 		select {
 		case response, ok := <-responseChan:
@@ -157,17 +373,56 @@ func (sc *StreamCollector) Collect(ctx context.Context, responseChan <-chan Resp
 				sc.Error = response.Error
 				sc.Stats.Error = response.Error
 				sc.Stats.Success = false
+				var provErr *ProviderError
+				if errors.As(response.Error, &provErr) && provErr.RequestID != "" {
+					sc.Stats.RequestID = provErr.RequestID
+				}
+				return
+			}
+
+			if response.Choice > 0 {
+				if !sc.collectExtra(response) {
+					return
+				}
+				if idleTimer != nil {
+					idleTimer.Reset(sc.IdleTimeout)
+				}
+				continue
+			}
+
+			// Refuse to buffer runaway responses
+			if sc.MaxBytes > 0 && len(sc.Content)+len(response.Delta) > sc.MaxBytes {
+				sc.Content += truncateUTF8(response.Delta, sc.MaxBytes-len(sc.Content))
+				sc.Error = &ProviderError{
+					Provider: sc.Stats.Provider,
+					Type:     ErrorTypeValidation,
+					Message:  fmt.Sprintf("response exceeded the %d byte limit", sc.MaxBytes),
+				}
+				sc.Stats.Error = sc.Error
+				sc.Stats.Success = false
 				return
 			}
 
 			// Accumulate content
+			if sc.Stats.TimeToFirstToken == 0 && response.Delta != "" {
+				sc.Stats.TimeToFirstToken = time.Since(sc.Stats.StartTime)
+			}
 			sc.Content += response.Delta
+			if sc.OnDelta != nil && response.Delta != "" {
+				sc.OnDelta(response.Delta)
+			}
 
 			// Capture final token usage
 			if response.TokensUsed != nil {
 				sc.TokensUsed = response.TokensUsed
 				sc.Stats.TokensUsed = response.TokensUsed
 			}
+			if response.FinishReason != "" {
+				sc.Stats.FinishReason = response.FinishReason
+			}
+			if response.RequestID != "" {
+				sc.Stats.RequestID = response.RequestID
+			}
 
 			// Check if done
 			if response.Done {
@@ -175,6 +430,21 @@ func (sc *StreamCollector) Collect(ctx context.Context, responseChan <-chan Resp
 				return
 			}
 
+			// Any chunk proves the stream is alive
+			if idleTimer != nil {
+				idleTimer.Reset(sc.IdleTimeout)
+			}
+
+		case <-idle:
+			sc.Error = &ProviderError{
+				Provider: sc.Stats.Provider,
+				Type:     ErrorTypeTimeout,
+				Message:  fmt.Sprintf("stream stalled: no data received for %v", sc.IdleTimeout),
+			}
+			sc.Stats.Error = sc.Error
+			sc.Stats.Success = false
+			return
+
 		case <-ctx.Done():
 			sc.Error = ctx.Err()
 			sc.Stats.Error = ctx.Err()
@@ -184,11 +454,48 @@ func (sc *StreamCollector) Collect(ctx context.Context, responseChan <-chan Resp
 	}
 }
 
+// collectExtra adds a chunk of a completion past the first to Extra,
+// holding it to MaxBytes like the first; it reports whether to keep going
+func (sc *StreamCollector) collectExtra(response Response) bool {
+	for len(sc.Extra) < response.Choice {
+		sc.Extra = append(sc.Extra, Choice{})
+	}
+	choice := &sc.Extra[response.Choice-1]
+
+	if sc.MaxBytes > 0 && len(choice.Content)+len(response.Delta) > sc.MaxBytes {
+		sc.Error = &ProviderError{
+			Provider: sc.Stats.Provider,
+			Type:     ErrorTypeValidation,
+			Message:  fmt.Sprintf("completion %d exceeded the %d byte limit", response.Choice+1, sc.MaxBytes),
+		}
+		sc.Stats.Error = sc.Error
+		sc.Stats.Success = false
+		return false
+	}
+
+	choice.Content += response.Delta
+	if response.FinishReason != "" {
+		choice.FinishReason = response.FinishReason
+	}
+	return true
+}
+
 // EstimateTokensSimple provides a rough token estimate (4 chars ≈ 1 token)
 func EstimateTokensSimple(text string) int {
 	return len(text) / 4
 }
 
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 // EstimateCost calculates estimated cost based on token usage and model pricing
 func EstimateCost(model string, tokens *TokenUsage) float64 {
 	if tokens == nil {
@@ -200,16 +507,27 @@ func EstimateCost(model string, tokens *TokenUsage) float64 {
 		input  float64
 		output float64
 	}{
-		"gpt-4o":          {5.00, 15.00},
-		"gpt-4o-mini":     {0.15, 0.60},
-		"gpt-4":           {30.00, 60.00},
-		"gpt-3.5-turbo":   {0.50, 1.50},
-		"claude-3-opus":   {15.00, 75.00},
-		"claude-3-sonnet": {3.00, 15.00},
-		"claude-3-haiku":  {0.25, 1.25},
+		"gpt-4o":            {5.00, 15.00},
+		"gpt-4o-mini":       {0.15, 0.60},
+		"gpt-4":             {30.00, 60.00},
+		"gpt-3.5-turbo":     {0.50, 1.50},
+		"claude-3-opus":     {15.00, 75.00},
+		"claude-3-sonnet":   {3.00, 15.00},
+		"claude-3-haiku":    {0.25, 1.25},
+		"claude-3-5-sonnet": {3.00, 15.00},
+		"claude-3-5-haiku":  {0.80, 4.00},
 	}
 
 	prices, exists := pricing[model]
+	if !exists {
+		// Dated model IDs (e.g. claude-3-5-sonnet-20241022) use their family's pricing
+		longest := 0
+		for name, p := range pricing {
+			if strings.HasPrefix(model, name+"-") && len(name) > longest {
+				prices, exists, longest = p, true, len(name)
+			}
+		}
+	}
 	if !exists {
 		// Default to mid-range pricing if model not found
 		prices = struct {
@@ -218,7 +536,11 @@ func EstimateCost(model string, tokens *TokenUsage) float64 {
 		}{3.00, 15.00}
 	}
 
-	inputCost := float64(tokens.PromptTokens) * prices.input / 1_000_000
+	// Cache writes cost 25% more than regular input, cache reads 90% less
+	uncachedTokens := tokens.PromptTokens - tokens.CacheCreationTokens - tokens.CacheReadTokens
+	inputCost := (float64(uncachedTokens) +
+		float64(tokens.CacheCreationTokens)*1.25 +
+		float64(tokens.CacheReadTokens)*0.10) * prices.input / 1_000_000
 	outputCost := float64(tokens.CompletionTokens) * prices.output / 1_000_000
 
 	return inputCost + outputCost
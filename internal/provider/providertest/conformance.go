@@ -0,0 +1,166 @@
+// Package providertest provides a mock provider and shared conformance checks
+// that every provider.Provider implementation is expected to pass.
+package providertest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// DefaultCloseTimeout is how long a provider may take to close its response
+// channel after the request context is cancelled
+const DefaultCloseTimeout = 2 * time.Second
+
+// CheckCancellation starts a streaming request, cancels it after the first
+// delta arrives, and verifies the provider stops sending and closes the
+// response channel within closeTimeout without the consumer draining it.
+func CheckCancellation(p provider.Provider, closeTimeout time.Duration) error {
+	if closeTimeout == 0 {
+		closeTimeout = DefaultCloseTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	responseChan, err := p.Ask(ctx, "cancellation conformance check", provider.Options{
+		MaxTokens: 64,
+		Stream:    true,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: ask failed: %w", p.GetName(), err)
+	}
+
+	// Wait for the stream to be underway before cancelling
+	select {
+	case response, ok := <-responseChan:
+		if !ok {
+			return fmt.Errorf("%s: response channel closed before any delta", p.GetName())
+		}
+		if response.Error != nil {
+			return fmt.Errorf("%s: stream failed before cancellation: %w", p.GetName(), response.Error)
+		}
+		if response.Done {
+			return fmt.Errorf("%s: stream completed before it could be cancelled", p.GetName())
+		}
+	case <-time.After(closeTimeout):
+		return fmt.Errorf("%s: no delta received within %v", p.GetName(), closeTimeout)
+	}
+
+	cancel()
+
+	// Stop reading entirely: the provider must not depend on the consumer to exit
+	time.Sleep(closeTimeout)
+
+	for {
+		select {
+		case _, ok := <-responseChan:
+			if !ok {
+				return nil
+			}
+		default:
+			return fmt.Errorf("%s: response channel still open %v after cancellation", p.GetName(), closeTimeout)
+		}
+	}
+}
+
+// StreamingServer streams deltas forever, in OpenAI's format from
+// /chat/completions, Anthropic's from /messages and Ollama's from /api/chat,
+// so a client can only finish by cancelling the request
+type StreamingServer struct {
+	*httptest.Server
+
+	Interval time.Duration // delay between chunks
+
+	disconnected chan struct{}
+	once         sync.Once
+}
+
+// NewStreamingServer starts a server serving /chat/completions, /messages and /api/chat
+func NewStreamingServer() *StreamingServer {
+	s := &StreamingServer{
+		Interval:     10 * time.Millisecond,
+		disconnected: make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handleStream))
+	return s
+}
+
+// handleStream streams chunks until the client goes away
+func (s *StreamingServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	var event []byte
+	switch r.URL.Path {
+	case "/chat/completions":
+		event, _ = json.Marshal(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"delta": map[string]string{"content": "tick "}},
+			},
+		})
+	case "/messages":
+		event, _ = json.Marshal(map[string]interface{}{
+			"type":  "content_block_delta",
+			"delta": map[string]string{"type": "text_delta", "text": "tick "},
+		})
+	case "/api/chat":
+		event, _ = json.Marshal(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": "tick "},
+		})
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		line := fmt.Sprintf("data: %s\n\n", event)
+		if r.URL.Path == "/api/chat" {
+			// Ollama streams newline-delimited JSON rather than SSE
+			line = string(event) + "\n"
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			s.markDisconnected()
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-time.After(s.Interval):
+		case <-r.Context().Done():
+			s.markDisconnected()
+			return
+		}
+	}
+}
+
+func (s *StreamingServer) markDisconnected() {
+	s.once.Do(func() { close(s.disconnected) })
+}
+
+// WaitDisconnect verifies the client abandoned the upstream request
+func (s *StreamingServer) WaitDisconnect(timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = DefaultCloseTimeout
+	}
+
+	select {
+	case <-s.disconnected:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("client still connected %v after cancellation", timeout)
+	}
+}
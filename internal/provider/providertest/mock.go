@@ -0,0 +1,92 @@
+package providertest
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// MockProvider is an in-memory provider that streams a fixed set of chunks
+type MockProvider struct {
+	Name     string
+	Model    string
+	Chunks   []string
+	Interval time.Duration // delay between chunks
+}
+
+// NewMockProvider creates a mock provider that streams the given chunks
+func NewMockProvider(chunks ...string) *MockProvider {
+	return &MockProvider{
+		Name:     "mock",
+		Model:    "mock-model",
+		Chunks:   chunks,
+		Interval: 10 * time.Millisecond,
+	}
+}
+
+// Ask implements the Provider interface
+func (m *MockProvider) Ask(ctx context.Context, prompt string, opts provider.Options) (<-chan provider.Response, error) {
+	responseChan := make(chan provider.Response)
+
+	go func() {
+		defer close(responseChan)
+
+		var completion int
+		for _, chunk := range m.Chunks {
+			select {
+			case <-time.After(m.Interval):
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case responseChan <- provider.Response{Delta: chunk}:
+				completion += m.EstimateTokens(chunk)
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		promptTokens := m.EstimateTokens(prompt + opts.SystemPrompt)
+		select {
+		case responseChan <- provider.Response{
+			Done: true,
+			TokensUsed: &provider.TokenUsage{
+				PromptTokens:     promptTokens,
+				CompletionTokens: completion,
+				TotalTokens:      promptTokens + completion,
+			},
+		}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return responseChan, nil
+}
+
+// GetName returns the provider name
+func (m *MockProvider) GetName() string {
+	return m.Name
+}
+
+// GetModel returns the model name
+func (m *MockProvider) GetModel() string {
+	return m.Model
+}
+
+// EstimateTokens uses the simple 4-chars-per-token heuristic
+func (m *MockProvider) EstimateTokens(text string) int {
+	return provider.EstimateTokensSimple(text)
+}
+
+// Close cleans up resources
+func (m *MockProvider) Close() error {
+	return nil
+}
+
+// Content returns the full content the mock will stream
+func (m *MockProvider) Content() string {
+	return strings.Join(m.Chunks, "")
+}
@@ -0,0 +1,40 @@
+package providertest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+func TestMockProviderStreamsChunks(t *testing.T) {
+	mock := NewMockProvider("hello ", "world")
+	mock.Interval = time.Millisecond
+
+	responseChan, err := mock.Ask(context.Background(), "prompt", provider.Options{Stream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collector := provider.NewStreamCollector()
+	collector.Collect(context.Background(), responseChan)
+	if collector.Error != nil {
+		t.Fatal(collector.Error)
+	}
+	if collector.Content != mock.Content() {
+		t.Errorf("content = %q, want %q", collector.Content, mock.Content())
+	}
+	if collector.TokensUsed == nil || collector.TokensUsed.TotalTokens == 0 {
+		t.Errorf("token usage = %+v, want it reported", collector.TokensUsed)
+	}
+}
+
+func TestMockProviderCancellationConformance(t *testing.T) {
+	mock := NewMockProvider(strings.Fields(strings.Repeat("tick ", 100))...)
+
+	if err := CheckCancellation(mock, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
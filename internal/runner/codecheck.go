@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+// codeBlockPattern matches fenced code blocks, capturing the info string and body
+var codeBlockPattern = regexp.MustCompile("(?s)```([^\\n`]*)\\n(.*?)```")
+
+// syntaxCheckers validate code by language; blocks in other languages are skipped
+var syntaxCheckers = map[string]func(code string) error{
+	"go":   checkGoSyntax,
+	"json": checkJSONSyntax,
+}
+
+// codeCheckJudge scores a worker by whether the code in its answer parses,
+// without calling a model: 10 when every checkable block parses, 0 when none
+// does. Answers without Go or JSON blocks can't be scored this way and come
+// back as a failed judgement, which leaves the other judges' scores alone.
+func codeCheckJudge(worker WorkerResult, judge config.Judge) JudgeResult {
+	startTime := time.Now()
+	result := JudgeResult{
+		JudgeID:  judge.ID,
+		WorkerID: worker.WorkerID,
+		Attempts: 1,
+	}
+	defer func() { result.Duration = time.Since(startTime) }()
+
+	checked, passed := 0, 0
+	var failures []string
+	for _, match := range codeBlockPattern.FindAllStringSubmatch(worker.Content, -1) {
+		language := blockLanguage(match[1])
+		check, ok := syntaxCheckers[language]
+		if !ok {
+			continue
+		}
+
+		checked++
+		if err := check(match[2]); err != nil {
+			failures = append(failures, fmt.Sprintf("%s block %d: %v", language, checked, err))
+			continue
+		}
+		passed++
+	}
+
+	if checked == 0 {
+		result.Error = fmt.Errorf("no Go or JSON code blocks to check")
+		return result
+	}
+
+	result.Score = int(math.Round(10 * float64(passed) / float64(checked)))
+	result.Reason = fmt.Sprintf("%d of %d code block(s) parse", passed, checked)
+	if len(failures) > 0 {
+		result.Reason += "; " + strings.Join(failures, "; ")
+	}
+	return result
+}
+
+// blockLanguage reads the language from a fence info string such as "go" or
+// "go internal/x.go", falling back to the file extension of an annotated path
+func blockLanguage(info string) string {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	language := strings.ToLower(fields[0])
+	if _, ok := syntaxCheckers[language]; ok {
+		return language
+	}
+	return strings.TrimPrefix(filepath.Ext(fields[len(fields)-1]), ".")
+}
+
+// checkGoSyntax parses Go code as a whole file, or failing that as top-level
+// declarations or statements, since answers often show fragments
+func checkGoSyntax(code string) error {
+	fset := token.NewFileSet()
+	_, err := parser.ParseFile(fset, "", code, parser.SkipObjectResolution)
+	if err == nil || strings.HasPrefix(strings.TrimSpace(code), "package ") {
+		return err
+	}
+
+	_, declErr := parser.ParseFile(fset, "", "package p\n"+code, parser.SkipObjectResolution)
+	if declErr == nil {
+		return nil
+	}
+	if _, stmtErr := parser.ParseFile(fset, "", "package p\nfunc _() {\n"+code+"\n}", parser.SkipObjectResolution); stmtErr == nil {
+		return nil
+	}
+	// Report the error against the declarations form; "expected 'package'" helps nobody
+	return declErr
+}
+
+func checkJSONSyntax(code string) error {
+	var value interface{}
+	return json.Unmarshal([]byte(code), &value)
+}
@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+func TestCodeCheckJudge(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantScore  int
+		wantErr    bool
+		wantReason string
+	}{
+		{
+			name:      "whole Go file",
+			content:   "```go\npackage main\n\nfunc main() {}\n```",
+			wantScore: 10,
+		},
+		{
+			name:      "Go declarations",
+			content:   "```go\nfunc add(a, b int) int { return a + b }\n```",
+			wantScore: 10,
+		},
+		{
+			name:      "Go statements",
+			content:   "```go\nx := 1\nfmt.Println(x)\n```",
+			wantScore: 10,
+		},
+		{
+			name:      "language from an annotated path",
+			content:   "```golang internal/x.go\nfunc f() {}\n```",
+			wantScore: 10,
+		},
+		{
+			name:       "broken Go",
+			content:    "```go\nfunc f( {\n```",
+			wantScore:  0,
+			wantReason: "go block 1",
+		},
+		{
+			name:       "half the blocks parse",
+			content:    "```json\n{\"a\": 1}\n```\n\n```json\n{\"a\": }\n```",
+			wantScore:  5,
+			wantReason: "1 of 2 code block(s) parse",
+		},
+		{
+			name:      "other languages are skipped",
+			content:   "```python\ndef f(:\n```\n\n```json\n[1, 2]\n```",
+			wantScore: 10,
+		},
+		{
+			name:    "nothing to check",
+			content: "No code here, just prose.\n\n```sh\nls\n```",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := codeCheckJudge(WorkerResult{WorkerID: "worker", Content: tt.content}, config.Judge{ID: "code"})
+			if tt.wantErr {
+				if result.Error == nil {
+					t.Fatalf("scored %d, want no judgement without checkable code", result.Score)
+				}
+				return
+			}
+			if result.Error != nil {
+				t.Fatal(result.Error)
+			}
+			if result.Score != tt.wantScore {
+				t.Errorf("score = %d, want %d (%s)", result.Score, tt.wantScore, result.Reason)
+			}
+			if !strings.Contains(result.Reason, tt.wantReason) {
+				t.Errorf("reason = %q, want it to mention %q", result.Reason, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/linediff"
+)
+
+// DiffKind says which of two compared answers a line belongs to
+type DiffKind int
+
+const (
+	DiffBoth  DiffKind = iota // both answers have the line
+	DiffLeft                  // only the first answer has it
+	DiffRight                 // only the second answer has it
+)
+
+// DiffLine is a line of a comparison between two answers
+type DiffLine struct {
+	Kind DiffKind
+	Text string
+}
+
+// Comparison is two workers' answers to the same prompt, diffed line by line
+type Comparison struct {
+	Result    *RunResult // both workers, without judges or consensus
+	Lines     []DiffLine
+	Agreement float64 // share of non-blank lines the answers have in common (0-1)
+}
+
+// Left returns the first of the compared workers
+func (c *Comparison) Left() WorkerResult {
+	return c.Result.Workers[0]
+}
+
+// Right returns the second of the compared workers
+func (c *Comparison) Right() WorkerResult {
+	return c.Result.Workers[1]
+}
+
+// Compare asks two workers the prompt at once and diffs their answers instead
+// of judging them. Each worker answers once, as with RunWorker. The run fails
+// when either worker does; the result still holds both.
+func (r *Runner) Compare(ctx context.Context, prompt, leftID, rightID string) (*Comparison, error) {
+	if leftID == rightID {
+		return nil, fmt.Errorf("compare needs two different workers, got %s twice", leftID)
+	}
+	var workers []config.Worker
+	for _, id := range []string{leftID, rightID} {
+		worker, err := r.config.GetWorkerByID(id)
+		if err != nil {
+			return nil, err
+		}
+		// One answer each is all that's compared
+		single := *worker
+		single.Samples = 0
+		workers = append(workers, single)
+	}
+
+	runID := RunIDFrom(ctx)
+	if runID == "" {
+		runID = newRunID()
+		ctx = WithRunID(ctx, runID)
+	}
+	result := &RunResult{
+		RunID:     runID,
+		Prompt:    prompt,
+		StartTime: time.Now(),
+	}
+	comparison := &Comparison{Result: result}
+
+	runCtx, cancel := context.WithTimeout(ctx, r.config.Consensus.Timeout)
+	defer cancel()
+
+	prepared, err := r.preparePrompt(runCtx, prompt, nil)
+	if err != nil {
+		result.EndTime = time.Now()
+		result.TotalDuration = result.EndTime.Sub(result.StartTime)
+		return comparison, err
+	}
+
+	result.Workers = make([]WorkerResult, len(workers))
+	var g errgroup.Group
+	for i, worker := range workers {
+		g.Go(func() error {
+			result.Workers[i] = r.runSingleWorker(runCtx, worker, prepared.Text, prepared.Context)
+			return nil
+		})
+	}
+	g.Wait()
+
+	r.calculateAggregateStats(result)
+	result.EndTime = time.Now()
+	result.TotalDuration = result.EndTime.Sub(result.StartTime)
+
+	for _, worker := range result.Workers {
+		if worker.Error != nil {
+			return comparison, fmt.Errorf("worker %s failed: %w", worker.WorkerID, worker.Error)
+		}
+	}
+	result.Success = true
+	comparison.Lines, comparison.Agreement = DiffAnswers(result.Workers[0].Content, result.Workers[1].Content)
+	return comparison, nil
+}
+
+// DiffAnswers diffs two answers line by line, ignoring indentation and
+// trailing spaces, and returns the lines with the share of non-blank lines
+// they have in common
+func DiffAnswers(left, right string) ([]DiffLine, float64) {
+	leftLines := strings.Split(strings.TrimSpace(left), "\n")
+	rightLines := strings.Split(strings.TrimSpace(right), "\n")
+	same := func(i, j int) bool {
+		return strings.TrimSpace(leftLines[i]) == strings.TrimSpace(rightLines[j])
+	}
+
+	var lines []DiffLine
+	var shared, nonBlank int
+	i, j := 0, 0
+	for _, op := range linediff.Lines(len(leftLines), len(rightLines), same) {
+		switch op {
+		case linediff.Equal:
+			lines = append(lines, DiffLine{Kind: DiffBoth, Text: leftLines[i]})
+			if strings.TrimSpace(leftLines[i]) != "" {
+				shared += 2
+				nonBlank += 2
+			}
+			i++
+			j++
+		case linediff.Delete:
+			lines = append(lines, DiffLine{Kind: DiffLeft, Text: leftLines[i]})
+			if strings.TrimSpace(leftLines[i]) != "" {
+				nonBlank++
+			}
+			i++
+		case linediff.Insert:
+			lines = append(lines, DiffLine{Kind: DiffRight, Text: rightLines[j]})
+			if strings.TrimSpace(rightLines[j]) != "" {
+				nonBlank++
+			}
+			j++
+		}
+	}
+
+	if nonBlank == 0 {
+		return lines, 1
+	}
+	return lines, float64(shared) / float64(nonBlank)
+}
@@ -0,0 +1,29 @@
+package runner
+
+import "testing"
+
+func TestDiffAnswers(t *testing.T) {
+	lines, agreement := DiffAnswers("intro\n  shared\nleft only\n\nend", "intro\nshared  \nright only\n\nend\n")
+
+	want := []DiffLine{
+		{Kind: DiffBoth, Text: "intro"},
+		{Kind: DiffBoth, Text: "  shared"},
+		{Kind: DiffLeft, Text: "left only"},
+		{Kind: DiffRight, Text: "right only"},
+		{Kind: DiffBoth, Text: ""},
+		{Kind: DiffBoth, Text: "end"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %+v, want %+v", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+
+	// Three shared non-blank lines on each side out of four: blank lines don't count
+	if agreement != 0.75 {
+		t.Errorf("agreement = %v, want 0.75", agreement)
+	}
+}
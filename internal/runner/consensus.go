@@ -3,20 +3,32 @@ package runner
 import (
 	"context"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
 )
 
+// scoreEpsilon is the tolerance within which two average scores count as tied
+const scoreEpsilon = 1e-9
+
 // runConsensus executes the configured consensus algorithm
 func (r *Runner) runConsensus(ctx context.Context, workers []WorkerResult, originalPrompt string) (*Consensus, error) {
 	// Filter out failed workers
 	successfulWorkers := make([]WorkerResult, 0, len(workers))
 	for _, worker := range workers {
-		if worker.Error == nil && worker.Content != "" {
+		if worker.Error == nil && strings.TrimSpace(worker.Content) != "" {
 			successfulWorkers = append(successfulWorkers, worker)
 		}
 	}
 
 	if len(successfulWorkers) == 0 {
-		return nil, fmt.Errorf("no successful workers to build consensus from")
+		return nil, fmt.Errorf("%w to build consensus from", ErrNoSuccessfulWorkers)
+	}
+
+	answered := len(successfulWorkers)
+	if limit := r.config.Consensus.MaxParticipants; limit > 0 && answered > limit {
+		successfulWorkers = selectParticipants(successfulWorkers, limit)
 	}
 
 	consensus := &Consensus{
@@ -24,34 +36,112 @@ func (r *Runner) runConsensus(ctx context.Context, workers []WorkerResult, origi
 		Participants: len(successfulWorkers),
 	}
 
+	var err error
 	switch r.config.Consensus.Algorithm {
 	case "majority":
-		return r.majorityConsensus(successfulWorkers, consensus)
+		consensus, err = r.majorityConsensus(ctx, successfulWorkers, consensus)
 	case "score_top1":
-		return r.scoreTop1Consensus(ctx, successfulWorkers, consensus, originalPrompt)
+		consensus, err = r.scoreTop1Consensus(ctx, successfulWorkers, consensus, originalPrompt)
+	case "json_merge":
+		consensus, err = r.jsonMergeConsensus(successfulWorkers, consensus)
 	case "embedding_cluster":
-		return nil, fmt.Errorf("embedding_cluster consensus not yet implemented")
+		return nil, fmt.Errorf("%w: embedding_cluster is not implemented yet", ErrUnknownAlgorithm)
 	case "referee":
-		return nil, fmt.Errorf("referee consensus not yet implemented")
+		return nil, fmt.Errorf("%w: referee is not implemented yet", ErrUnknownAlgorithm)
 	default:
-		return nil, fmt.Errorf("unknown consensus algorithm: %s", r.config.Consensus.Algorithm)
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, r.config.Consensus.Algorithm)
+	}
+
+	if err == nil && len(successfulWorkers) < answered {
+		consensus.Reasoning += fmt.Sprintf("; considered %d of %d answers (max_participants), spread across providers and models",
+			len(successfulWorkers), answered)
+	}
+	return consensus, err
+}
+
+// selectParticipants picks limit answers to build consensus from, spread
+// across providers and models: the first answer from each provider/model in
+// workers order, then the second from each, and so on, so repeated samples
+// of one model are dropped before any model goes unheard. The selection keeps
+// workers order and is the same for the same answers.
+func selectParticipants(workers []WorkerResult, limit int) []WorkerResult {
+	var sources []string
+	bySource := make(map[string][]int)
+	for i := range workers {
+		source := sampledWorkerID(&workers[i])
+		if stats := workers[i].Stats; stats != nil && stats.Model != "" {
+			source = stats.Provider + "/" + stats.Model
+		}
+		if _, seen := bySource[source]; !seen {
+			sources = append(sources, source)
+		}
+		bySource[source] = append(bySource[source], i)
+	}
+
+	picked := make([]bool, len(workers))
+	for round, count := 0, 0; count < limit; round++ {
+		for _, source := range sources {
+			if round < len(bySource[source]) && count < limit {
+				picked[bySource[source][round]] = true
+				count++
+			}
+		}
+	}
+
+	selected := make([]WorkerResult, 0, limit)
+	for i, worker := range workers {
+		if picked[i] {
+			selected = append(selected, worker)
+		}
 	}
+	return selected
 }
 
-// majorityConsensus implements simple majority voting (for now, just picks the first)
-func (r *Runner) majorityConsensus(workers []WorkerResult, consensus *Consensus) (*Consensus, error) {
+// majorityConsensus picks the answer most workers agree on, where the
+// consensus normalizer decides which answers count as the same. Answers cut
+// off at max_tokens don't vote while a complete one exists; ties go to the
+// group that answered first.
+func (r *Runner) majorityConsensus(ctx context.Context, workers []WorkerResult, consensus *Consensus) (*Consensus, error) {
 	if len(workers) == 0 {
-		return nil, fmt.Errorf("no workers for majority consensus")
+		return nil, fmt.Errorf("%w for majority consensus", ErrNoSuccessfulWorkers)
 	}
 
-	// For now, implement a simple "first successful response" approach
-	// TODO: Implement actual similarity-based majority voting
-	winner := workers[0]
+	voters := make([]WorkerResult, 0, len(workers))
+	for _, worker := range workers {
+		if !worker.Truncated() {
+			voters = append(voters, worker)
+		}
+	}
+	allTruncated := len(voters) == 0
+	if allTruncated {
+		voters = workers
+	}
+
+	groups, normalizer, note, err := r.groupAnswers(ctx, voters)
+	if err != nil {
+		return nil, err
+	}
+	largest := groups[0]
+	for _, group := range groups[1:] {
+		if len(group) > len(largest) {
+			largest = group
+		}
+	}
+	winner := largest[0]
 
 	consensus.Winner = winner.WorkerID
 	consensus.Content = winner.Content
-	consensus.Confidence = 1.0 / float64(len(workers)) // Simple confidence based on participation
-	consensus.Reasoning = fmt.Sprintf("Selected response from %s (simple majority algorithm)", winner.WorkerID)
+	consensus.Confidence = float64(len(largest)) / float64(len(voters))
+	consensus.Reasoning = fmt.Sprintf("Selected response from %s: %d of %d responses agree (%s normalizer)",
+		winner.WorkerID, len(largest), len(voters), normalizer)
+	if note != "" {
+		consensus.Reasoning += "; " + note
+	}
+	if allTruncated {
+		consensus.Reasoning += "; every response was cut off at max_tokens"
+	} else if len(voters) < len(workers) {
+		consensus.Reasoning += "; skipped responses cut off at max_tokens"
+	}
 
 	return consensus, nil
 }
@@ -60,7 +150,7 @@ func (r *Runner) majorityConsensus(workers []WorkerResult, consensus *Consensus)
 func (r *Runner) scoreTop1Consensus(ctx context.Context, workers []WorkerResult, consensus *Consensus, originalPrompt string) (*Consensus, error) {
 	if len(r.config.Judges) == 0 {
 		// No judges configured, fall back to majority
-		return r.majorityConsensus(workers, consensus)
+		return r.majorityConsensus(ctx, workers, consensus)
 	}
 
 	// Evaluate each worker response with all judges
@@ -68,45 +158,87 @@ func (r *Runner) scoreTop1Consensus(ctx context.Context, workers []WorkerResult,
 	copy(evaluatedWorkers, workers)
 
 	for i := range evaluatedWorkers {
-		if evaluatedWorkers[i].Error == nil {
-			judgeResults, err := r.evaluateWithJudges(ctx, evaluatedWorkers[i], originalPrompt)
-			if err != nil {
-				// Log error but don't fail consensus - we can still compare what we have
-				fmt.Printf("Warning: Failed to evaluate worker %s with judges: %v\n", evaluatedWorkers[i].WorkerID, err)
-			} else {
-				evaluatedWorkers[i].JudgeResults = judgeResults
-				evaluatedWorkers[i].AverageScore = r.calculateAverageScore(judgeResults)
-			}
+		// Workers judged while the run was still streaming don't need a second pass
+		if evaluatedWorkers[i].Error == nil && !evaluatedWorkers[i].judged {
+			r.judgeWorker(ctx, &evaluatedWorkers[i], originalPrompt)
 		}
 	}
 
-	// Find the worker with the highest average score
-	var bestWorker *WorkerResult
+	// Find the workers sharing the highest average score; unscored workers
+	// can't be ranked fairly, so they are left out rather than given a default.
+	// Answers cut off at max_tokens rank with truncated_penalty taken off.
+	var topWorkers []*WorkerResult
 	var bestScore float64 = -1
+	var topRaw *WorkerResult
+	unscored := 0
+	penalized := 0
+	scores := make(map[*WorkerResult]float64, len(evaluatedWorkers))
 
 	for i := range evaluatedWorkers {
 		worker := &evaluatedWorkers[i]
 		if worker.Error == nil {
-			// If we have judge scores, use them; otherwise use a default score
+			if worker.Unscored {
+				unscored++
+				continue
+			}
 			score := worker.AverageScore
-			if len(worker.JudgeResults) == 0 {
-				score = 5.0 // Default neutral score for workers not evaluated
+			if worker.Truncated() && r.config.Consensus.TruncatedPenalty > 0 {
+				score = max(score-r.config.Consensus.TruncatedPenalty, 0)
+				penalized++
+			}
+			scores[worker] = score
+			if topRaw == nil || worker.AverageScore > topRaw.AverageScore+scoreEpsilon {
+				topRaw = worker
 			}
 
-			if score > bestScore {
+			switch {
+			case score > bestScore+scoreEpsilon:
 				bestScore = score
-				bestWorker = worker
+				topWorkers = []*WorkerResult{worker}
+			case math.Abs(score-bestScore) <= scoreEpsilon:
+				topWorkers = append(topWorkers, worker)
 			}
 		}
 	}
 
-	if bestWorker == nil {
-		return nil, fmt.Errorf("no valid workers found for scoring")
+	if len(topWorkers) == 0 {
+		if unscored > 0 {
+			return r.unjudgedConsensus(ctx, workers, evaluatedWorkers, consensus)
+		}
+		return nil, fmt.Errorf("%w to score", ErrNoSuccessfulWorkers)
+	}
+
+	// Cost and latency only decide near-ties: the answers within the largest
+	// possible penalty of the best score rank again with their penalties off
+	resourcePenalty := r.resourcePenalties(evaluatedWorkers)
+	if resourcePenalty != nil {
+		margin := r.config.Consensus.CostWeight + r.config.Consensus.LatencyWeight
+		bestRanked := math.Inf(-1)
+		topWorkers = nil
+		for i := range evaluatedWorkers {
+			worker := &evaluatedWorkers[i]
+			score, rankable := scores[worker]
+			if !rankable || score < bestScore-margin-scoreEpsilon {
+				continue
+			}
+			ranked := score - resourcePenalty[i]
+			switch {
+			case ranked > bestRanked+scoreEpsilon:
+				bestRanked = ranked
+				topWorkers = []*WorkerResult{worker}
+			case math.Abs(ranked-bestRanked) <= scoreEpsilon:
+				topWorkers = append(topWorkers, worker)
+			}
+		}
 	}
 
+	bestWorker := r.breakTie(topWorkers)
+	// The threshold and confidence judge the picked answer, not its ranking
+	bestScore = scores[bestWorker]
+
 	// Check if the best score meets the minimum threshold
 	if bestScore < r.config.Consensus.MinScore {
-		return nil, fmt.Errorf("best score %.2f does not meet minimum threshold %.2f", bestScore, r.config.Consensus.MinScore)
+		return nil, fmt.Errorf("%w: best score %.2f, minimum %.2f", ErrBelowMinScore, bestScore, r.config.Consensus.MinScore)
 	}
 
 	consensus.Winner = bestWorker.WorkerID
@@ -114,8 +246,8 @@ func (r *Runner) scoreTop1Consensus(ctx context.Context, workers []WorkerResult,
 	consensus.Confidence = bestScore / 10.0 // Convert 0-10 score to 0-1 confidence
 
 	// Build reasoning
-	reasoning := fmt.Sprintf("Selected %s with average score %.2f from %d judges",
-		bestWorker.WorkerID, bestScore, len(r.config.Judges))
+	reasoning := fmt.Sprintf("Selected %s with %s score %.2f from %d judges",
+		bestWorker.WorkerID, r.scoreLabel(), bestScore, len(r.config.Judges))
 
 	if len(bestWorker.JudgeResults) > 0 {
 		reasoning += " ("
@@ -128,6 +260,32 @@ func (r *Runner) scoreTop1Consensus(ctx context.Context, workers []WorkerResult,
 		reasoning += ")"
 	}
 
+	if len(topWorkers) > 1 {
+		reasoning += fmt.Sprintf("; broke a %d-way tie by %s", len(topWorkers), r.config.Consensus.TieBreaker)
+	}
+
+	if bestWorker.Truncated() {
+		reasoning += "; the answer was cut off at max_tokens"
+	}
+	if penalized > 0 {
+		reasoning += fmt.Sprintf("; %d cut-off answer(s) ranked %.1f points lower", penalized, r.config.Consensus.TruncatedPenalty)
+	}
+
+	if resourcePenalty != nil {
+		for i := range evaluatedWorkers {
+			if &evaluatedWorkers[i] == bestWorker {
+				reasoning += fmt.Sprintf("; cost/latency penalty %.2f points", resourcePenalty[i])
+			}
+		}
+		if topRaw != nil && topRaw != bestWorker && topRaw.AverageScore > bestWorker.AverageScore+scoreEpsilon {
+			reasoning += fmt.Sprintf("; preferred over %s (score %.2f) for its cost and latency", topRaw.WorkerID, topRaw.AverageScore)
+		}
+	}
+
+	if unscored > 0 {
+		reasoning += fmt.Sprintf("; %d unscored worker(s) excluded after judge failures", unscored)
+	}
+
 	consensus.Reasoning = reasoning
 
 	// Update the workers slice with evaluation results
@@ -136,16 +294,167 @@ func (r *Runner) scoreTop1Consensus(ctx context.Context, workers []WorkerResult,
 	return consensus, nil
 }
 
-// calculateAverageScore calculates the average score from judge results
-func (r *Runner) calculateAverageScore(judgeResults []JudgeResult) float64 {
+// resourcePenalties returns the points each worker ranks lower for its cost
+// and latency when score_top1 breaks a near-tie: none for the cheapest answer
+// among the rankable workers, cost_weight for the costliest and linearly in
+// between, and likewise for latency. Workers without stats, a lone worker and
+// workers that cost the same get none. Returns nil when neither weight is set.
+func (r *Runner) resourcePenalties(workers []WorkerResult) []float64 {
+	costWeight, latencyWeight := r.config.Consensus.CostWeight, r.config.Consensus.LatencyWeight
+	if costWeight <= 0 && latencyWeight <= 0 {
+		return nil
+	}
+
+	minCost, maxCost := math.Inf(1), math.Inf(-1)
+	var minLatency, maxLatency time.Duration = math.MaxInt64, math.MinInt64
+	for i := range workers {
+		worker := &workers[i]
+		if worker.Error != nil || worker.Unscored || worker.Stats == nil {
+			continue
+		}
+		minCost = min(minCost, worker.Stats.EstimatedCost)
+		maxCost = max(maxCost, worker.Stats.EstimatedCost)
+		minLatency = min(minLatency, worker.Stats.Duration)
+		maxLatency = max(maxLatency, worker.Stats.Duration)
+	}
+
+	penalties := make([]float64, len(workers))
+	for i := range workers {
+		worker := &workers[i]
+		if worker.Error != nil || worker.Unscored || worker.Stats == nil {
+			continue
+		}
+		if maxCost > minCost {
+			penalties[i] += costWeight * (worker.Stats.EstimatedCost - minCost) / (maxCost - minCost)
+		}
+		if maxLatency > minLatency {
+			penalties[i] += latencyWeight * float64(worker.Stats.Duration-minLatency) / float64(maxLatency-minLatency)
+		}
+	}
+	return penalties
+}
+
+// unjudgedConsensus falls back to majority when every judge call failed, so
+// the run still answers but the pick isn't presented as a scored decision
+func (r *Runner) unjudgedConsensus(ctx context.Context, workers, evaluatedWorkers []WorkerResult, consensus *Consensus) (*Consensus, error) {
+	consensus, err := r.majorityConsensus(ctx, evaluatedWorkers, consensus)
+	if err != nil {
+		return nil, err
+	}
+
+	// Half the majority confidence: no judge backs this pick
+	consensus.Confidence /= 2
+	consensus.Reasoning = fmt.Sprintf("Judging unavailable: judges failed to score all %d workers, so %s was selected by the majority fallback without scores",
+		len(evaluatedWorkers), consensus.Winner)
+
+	copy(workers, evaluatedWorkers)
+	return consensus, nil
+}
+
+// aggregateScores combines the judges' scores of a worker into one, per
+// consensus.judge_aggregation. trimmed_mean leaves out the highest and lowest
+// score when at least three judges scored, and is a plain mean otherwise.
+func (r *Runner) aggregateScores(judgeResults []JudgeResult) float64 {
 	if len(judgeResults) == 0 {
 		return 0
 	}
 
+	scores := make([]int, len(judgeResults))
+	for i, result := range judgeResults {
+		scores[i] = result.Score
+	}
+	slices.Sort(scores)
+
+	switch r.config.Consensus.JudgeAggregation {
+	case "median":
+		middle := len(scores) / 2
+		if len(scores)%2 == 1 {
+			return float64(scores[middle])
+		}
+		return float64(scores[middle-1]+scores[middle]) / 2
+	case "min":
+		return float64(scores[0])
+	case "max":
+		return float64(scores[len(scores)-1])
+	case "trimmed_mean":
+		if len(scores) >= 3 {
+			scores = scores[1 : len(scores)-1]
+		}
+	}
+
 	var total int
-	for _, result := range judgeResults {
-		total += result.Score
+	for _, score := range scores {
+		total += score
+	}
+	return float64(total) / float64(len(scores))
+}
+
+// scoreLabel names the aggregated score in consensus reasoning
+func (r *Runner) scoreLabel() string {
+	switch r.config.Consensus.JudgeAggregation {
+	case "median":
+		return "median"
+	case "min":
+		return "lowest"
+	case "max":
+		return "highest"
+	case "trimmed_mean":
+		return "trimmed mean"
+	default:
+		return "average"
 	}
+}
+
+// breakTie picks one worker from candidates that share the top score using the
+// configured tie-breaker; remaining ties keep the workers list order
+func (r *Runner) breakTie(candidates []*WorkerResult) *WorkerResult {
+	best := candidates[0]
+	for _, candidate := range candidates[1:] {
+		if r.preferOnTie(candidate, best) {
+			best = candidate
+		}
+	}
+	return best
+}
 
-	return float64(total) / float64(len(judgeResults))
+// preferOnTie reports whether a should win a tie against b
+func (r *Runner) preferOnTie(a, b *WorkerResult) bool {
+	switch r.config.Consensus.TieBreaker {
+	case "lowest_cost":
+		return workerCost(a) < workerCost(b)
+	case "lowest_latency":
+		return workerLatency(a) < workerLatency(b)
+	case "priority":
+		return r.priorityRank(sampledWorkerID(a)) < r.priorityRank(sampledWorkerID(b))
+	case "shortest":
+		return len(a.Content) < len(b.Content)
+	case "longest":
+		return len(a.Content) > len(b.Content)
+	default:
+		return false
+	}
+}
+
+// priorityRank returns the worker's position in the priority list, unlisted workers rank last
+func (r *Runner) priorityRank(workerID string) int {
+	for i, id := range r.config.Consensus.Priority {
+		if id == workerID {
+			return i
+		}
+	}
+	return len(r.config.Consensus.Priority)
+}
+
+func workerCost(worker *WorkerResult) float64 {
+	if worker.Stats == nil {
+		return math.MaxFloat64
+	}
+	return worker.Stats.EstimatedCost
+}
+
+func workerLatency(worker *WorkerResult) time.Duration {
+	if worker.Stats == nil {
+		return time.Duration(math.MaxInt64)
+	}
+	return worker.Stats.Duration
 }
@@ -0,0 +1,240 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// judgedWorker is a worker already scored by the judges
+func judgedWorker(id string, score, cost float64, duration time.Duration) WorkerResult {
+	return WorkerResult{
+		WorkerID:     id,
+		Content:      id + " answer",
+		AverageScore: score,
+		Stats:        &provider.Stats{EstimatedCost: cost, Duration: duration},
+		JudgeResults: []JudgeResult{{JudgeID: "judge", Score: int(score)}},
+		judged:       true,
+	}
+}
+
+// scoringRunner is a runner ranking with score_top1 under consensus
+func scoringRunner(consensus config.Consensus) *Runner {
+	consensus.Algorithm = "score_top1"
+	return &Runner{config: &config.Config{
+		Judges:    []config.Judge{{ID: "judge"}},
+		Consensus: consensus,
+	}}
+}
+
+func TestResourcePenalties(t *testing.T) {
+	noStats := judgedWorker("unmeasured", 8, 0, 0)
+	noStats.Stats = nil
+
+	tests := []struct {
+		name    string
+		workers []WorkerResult
+		want    []float64
+	}{
+		{
+			name:    "cheapest and fastest go free",
+			workers: []WorkerResult{judgedWorker("cheap", 8, 0.01, time.Second), judgedWorker("mid", 8, 0.02, 2*time.Second), judgedWorker("dear", 8, 0.03, 3*time.Second)},
+			want:    []float64{0, 0.75, 1.5},
+		},
+		{
+			name:    "a lone worker",
+			workers: []WorkerResult{judgedWorker("only", 8, 0.03, 3*time.Second)},
+			want:    []float64{0},
+		},
+		{
+			name:    "equal cost and latency",
+			workers: []WorkerResult{judgedWorker("a", 8, 0.02, time.Second), judgedWorker("b", 8, 0.02, time.Second)},
+			want:    []float64{0, 0},
+		},
+		{
+			name:    "missing stats",
+			workers: []WorkerResult{noStats, judgedWorker("cheap", 8, 0.01, time.Second), judgedWorker("dear", 8, 0.03, time.Second)},
+			want:    []float64{0, 0, 1},
+		},
+	}
+
+	r := scoringRunner(config.Consensus{CostWeight: 1, LatencyWeight: 0.5})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.resourcePenalties(tt.workers)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("penalties = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestScoreTop1CostWeightOnlyDecidesNearTies(t *testing.T) {
+	tests := []struct {
+		name       string
+		workers    []WorkerResult
+		wantWinner string
+		wantConf   float64
+	}{
+		{
+			name:       "cheaper near-tie wins",
+			workers:    []WorkerResult{judgedWorker("dear", 9, 0.10, time.Second), judgedWorker("cheap", 8.8, 0.01, time.Second)},
+			wantWinner: "cheap",
+			wantConf:   0.88,
+		},
+		{
+			name:       "clear winner stays",
+			workers:    []WorkerResult{judgedWorker("dear", 9, 0.10, time.Second), judgedWorker("cheap", 7, 0.01, time.Second)},
+			wantWinner: "dear",
+			wantConf:   0.9,
+		},
+		{
+			name:       "a lone worker keeps its score",
+			workers:    []WorkerResult{judgedWorker("dear", 9, 0.10, time.Second)},
+			wantWinner: "dear",
+			wantConf:   0.9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := scoringRunner(config.Consensus{CostWeight: 0.5, MinScore: 8.5})
+			consensus, err := r.scoreTop1Consensus(context.Background(), tt.workers, &Consensus{}, "prompt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if consensus.Winner != tt.wantWinner {
+				t.Errorf("winner = %s, want %s", consensus.Winner, tt.wantWinner)
+			}
+			if math.Abs(consensus.Confidence-tt.wantConf) > 1e-9 {
+				t.Errorf("confidence = %v, want %v from the unpenalized score", consensus.Confidence, tt.wantConf)
+			}
+		})
+	}
+}
+
+func TestScoreTop1MinScoreIgnoresCostPenalty(t *testing.T) {
+	// 9 meets min_score 9; the cost penalty must not push it under
+	r := scoringRunner(config.Consensus{CostWeight: 0.5, MinScore: 9})
+	workers := []WorkerResult{judgedWorker("dear", 9, 0.10, time.Second), judgedWorker("cheap", 6, 0.01, time.Second)}
+	consensus, err := r.scoreTop1Consensus(context.Background(), workers, &Consensus{}, "prompt")
+	if errors.Is(err, ErrBelowMinScore) {
+		t.Fatalf("best answer rejected as below min_score: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consensus.Winner != "dear" {
+		t.Errorf("winner = %s, want dear", consensus.Winner)
+	}
+}
+
+// modelAnswer is an answer from worker id on provider/model, the
+// sample of configured worker when that is set
+func modelAnswer(id, worker, prov, model string) WorkerResult {
+	result := WorkerResult{WorkerID: id, Content: id + " answer", Stats: &provider.Stats{Provider: prov, Model: model}}
+	if worker != "" {
+		result.Metadata = map[string]interface{}{"worker": worker}
+	}
+	return result
+}
+
+func TestSelectParticipants(t *testing.T) {
+	samples := []WorkerResult{
+		modelAnswer("a#1", "a", "openai", "gpt-4o"),
+		modelAnswer("a#2", "a", "openai", "gpt-4o"),
+		modelAnswer("a#3", "a", "openai", "gpt-4o"),
+		modelAnswer("b", "", "anthropic", "claude"),
+		{WorkerID: "c", Content: "c answer"}, // no stats: its own source
+	}
+	sameModel := []WorkerResult{
+		modelAnswer("x", "", "openai", "gpt-4o"),
+		modelAnswer("y", "", "openai", "gpt-4o"),
+		modelAnswer("z", "", "openai", "gpt-4o-mini"),
+	}
+
+	tests := []struct {
+		name    string
+		workers []WorkerResult
+		limit   int
+		want    []string
+	}{
+		{name: "one answer from each model first", workers: samples, limit: 3, want: []string{"a#1", "b", "c"}},
+		{name: "then second samples", workers: samples, limit: 4, want: []string{"a#1", "a#2", "b", "c"}},
+		{name: "limit below the models", workers: samples, limit: 2, want: []string{"a#1", "b"}},
+		{name: "workers on one model share a source", workers: sameModel, limit: 2, want: []string{"x", "z"}},
+		{name: "everyone fits", workers: sameModel, limit: 3, want: []string{"x", "y", "z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, worker := range selectParticipants(tt.workers, tt.limit) {
+				got = append(got, worker.WorkerID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunConsensusCapsParticipants(t *testing.T) {
+	r := scoringRunner(config.Consensus{MaxParticipants: 2})
+	workers := []WorkerResult{
+		judgedWorker("first", 6, 0, time.Second),
+		judgedWorker("second", 7, 0, time.Second),
+		judgedWorker("third", 9, 0, time.Second),
+	}
+
+	consensus, err := r.runConsensus(context.Background(), workers, "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consensus.Participants != 2 || consensus.Winner != "second" {
+		t.Errorf("participants %d, winner %s; want 2 and the best of the first two", consensus.Participants, consensus.Winner)
+	}
+	if !strings.Contains(consensus.Reasoning, "considered 2 of 3 answers") {
+		t.Errorf("reasoning = %q, want the cap noted", consensus.Reasoning)
+	}
+}
+
+func TestAggregateScores(t *testing.T) {
+	judged := func(scores ...int) []JudgeResult {
+		results := make([]JudgeResult, len(scores))
+		for i, score := range scores {
+			results[i] = JudgeResult{JudgeID: fmt.Sprintf("judge%d", i), Score: score}
+		}
+		return results
+	}
+
+	tests := []struct {
+		aggregation string
+		results     []JudgeResult
+		want        float64
+	}{
+		{aggregation: "mean", results: judged(9, 4, 8), want: 7},
+		{aggregation: "mean", results: nil, want: 0},
+		{aggregation: "median", results: judged(9, 1, 8), want: 8},
+		{aggregation: "median", results: judged(9, 2, 8, 3), want: 5.5},
+		{aggregation: "min", results: judged(7, 3, 9), want: 3},
+		{aggregation: "max", results: judged(7, 3, 9), want: 9},
+		{aggregation: "trimmed_mean", results: judged(10, 0, 6, 8), want: 7},
+		{aggregation: "trimmed_mean", results: judged(10, 5), want: 7.5}, // too few judges to trim
+	}
+	for _, tt := range tests {
+		r := &Runner{config: &config.Config{Consensus: config.Consensus{JudgeAggregation: tt.aggregation}}}
+		if got := r.aggregateScores(tt.results); got != tt.want {
+			t.Errorf("%s of %v = %v, want %v", tt.aggregation, tt.results, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/evisdrenova/devgru/internal/ide"
+)
+
+// fileBlockPattern matches fenced code blocks annotated with a file path, e.g. ```go main.go
+var fileBlockPattern = regexp.MustCompile("(?s)```[\\w+-]*[ \\t]+([^\\s`]+)[ \\t]*\\n(.*?)```")
+
+// extractDiffs turns file-annotated code blocks in the content into proposed diffs
+func (r *Runner) extractDiffs(content string, ideContext interface{}) []ide.DiffResult {
+	root := "."
+	if ctx, ok := ideContext.(*ide.IDEContext); ok && ctx.WorkspaceRoot != "" {
+		root = ctx.WorkspaceRoot
+	}
+
+	var diffs []ide.DiffResult
+	for _, match := range fileBlockPattern.FindAllStringSubmatch(content, -1) {
+		file := filepath.Clean(match[1])
+		if filepath.IsAbs(file) || file == ".." || strings.HasPrefix(file, ".."+string(filepath.Separator)) {
+			// Never propose edits outside the workspace
+			continue
+		}
+
+		newContent := match[2]
+		var origContent string
+		if data, err := os.ReadFile(filepath.Join(root, file)); err == nil {
+			origContent = string(data)
+		}
+
+		if origContent == newContent {
+			continue
+		}
+
+		diff := ide.NewDiffResult(file, origContent, newContent)
+		diff.File = filepath.Join(root, file)
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
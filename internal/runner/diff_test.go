@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/ide"
+)
+
+func TestExtractDiffs(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "same.go"), []byte("package same\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "edit.go"), []byte("package edit\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "Here are the changes.\n\n" +
+		"```go edit.go\npackage edit\n\nfunc New() {}\n```\n\n" +
+		"```go same.go\npackage same\n```\n\n" +
+		"```go pkg/new.go\npackage pkg\n```\n\n" +
+		"```go\nfmt.Println(\"no file\")\n```\n\n" +
+		"```go ../escape.go\npackage escape\n```\n\n" +
+		"```go /etc/passwd\nroot\n```\n"
+
+	r := &Runner{}
+	diffs := r.extractDiffs(content, &ide.IDEContext{WorkspaceRoot: root})
+
+	want := map[string]string{
+		filepath.Join(root, "edit.go"):    "package edit\n\nfunc New() {}\n",
+		filepath.Join(root, "pkg/new.go"): "package pkg\n",
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d diffs, want %d (unchanged, unannotated and outside files skipped): %+v", len(diffs), len(want), diffs)
+	}
+	for _, diff := range diffs {
+		newContent, ok := want[diff.File]
+		if !ok {
+			t.Errorf("unexpected diff for %s", diff.File)
+			continue
+		}
+		if diff.NewContent != newContent {
+			t.Errorf("%s new content = %q, want %q", diff.File, diff.NewContent, newContent)
+		}
+		if diff.Patch == "" {
+			t.Errorf("%s has no patch", diff.File)
+		}
+	}
+}
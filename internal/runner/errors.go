@@ -0,0 +1,17 @@
+package runner
+
+import "errors"
+
+// Consensus failures, wrapped with their details; callers tell them apart
+// with errors.Is, e.g. to retry, ask a human or point at the config
+var (
+	// ErrNoSuccessfulWorkers means no worker answered, or none could be scored
+	ErrNoSuccessfulWorkers = errors.New("no successful workers")
+
+	// ErrBelowMinScore means the best judged answer scored under consensus.min_score
+	ErrBelowMinScore = errors.New("best score does not meet consensus.min_score")
+
+	// ErrUnknownAlgorithm means consensus.algorithm names no algorithm this
+	// build can run
+	ErrUnknownAlgorithm = errors.New("unknown consensus algorithm")
+)
@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+// escalateLowConfidence gives a consensus below consensus.min_confidence a
+// second round under consensus.on_low_confidence: more answers are asked for
+// (from every worker again, or from the escalation provider), judged like the
+// others, and consensus is reached again over all of them. It returns the
+// answers and consensus to keep, which are the ones given when there was
+// nothing to do; a failed second round keeps the first consensus.
+//
+// The first round may have used most of the run's timeout, so ctx should be
+// the run's own context: the second round gets consensus.escalation_timeout
+// from when it starts.
+func (r *Runner) escalateLowConfidence(ctx context.Context, workers []WorkerResult, consensus *Consensus, prepared PreparedPrompt) ([]WorkerResult, *Consensus) {
+	policy := r.config.Consensus.OnLowConfidence
+	threshold := r.config.Consensus.MinConfidence
+	if policy == config.LowConfidenceFlag || threshold <= 0 || consensus.Confidence >= threshold || ctx.Err() != nil {
+		return workers, consensus
+	}
+
+	ctx, cancelEscalation := context.WithTimeout(ctx, r.config.Consensus.EscalationTimeout)
+	defer cancelEscalation()
+
+	extra := r.escalationWorkers(workers, consensus)
+	answers := make([]WorkerResult, len(extra))
+	var wg sync.WaitGroup
+	for i, worker := range extra {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			workerCtx, cancelWorker := context.WithTimeout(ctx, r.config.Consensus.WorkerTimeout)
+			answer := r.runSingleWorker(workerCtx, worker.Worker, prepared.Text, prepared.Context)
+			cancelWorker()
+
+			answer.Metadata["escalation"] = policy
+			if worker.from != "" {
+				answer.Metadata["worker"] = worker.from
+			}
+			if r.pipelinesJudges() && answer.Error == nil && answer.Content != "" {
+				judgeCtx, cancelJudge := r.judgingContext(ctx)
+				r.judgeWorker(judgeCtx, &answer, prepared.Text)
+				cancelJudge()
+			}
+			answers[i] = answer
+		}()
+	}
+	wg.Wait()
+
+	all := slices.Concat(workers, answers)
+	consensusCtx, cancel := r.judgingContext(ctx)
+	defer cancel()
+	second, err := r.runConsensus(consensusCtx, all, prepared.Text)
+	if err != nil {
+		consensus.Reasoning += fmt.Sprintf("; %s after low confidence failed: %v", policy, err)
+		return all, consensus
+	}
+
+	second.Reasoning += fmt.Sprintf("; second round after low confidence %.2f: %s asked %d more worker(s)", consensus.Confidence, policy, len(extra))
+	return all, second
+}
+
+// escalationWorker is a worker asked in a low-confidence second round; from
+// is the configured worker it repeats, if any
+type escalationWorker struct {
+	config.Worker
+	from string
+}
+
+// escalationWorkers returns the workers a low-confidence second round asks:
+// one more answer from every configured worker for resample, or for escalate
+// the worker behind the current winner on the escalation provider
+func (r *Runner) escalationWorkers(workers []WorkerResult, consensus *Consensus) []escalationWorker {
+	if r.config.Consensus.OnLowConfidence == config.LowConfidenceResample {
+		var extra []escalationWorker
+		for _, worker := range r.config.Workers {
+			retry := worker
+			retry.ID = worker.ID + "/retry"
+			retry.Samples = 0
+			extra = append(extra, escalationWorker{Worker: retry, from: worker.ID})
+		}
+		return extra
+	}
+
+	base := r.config.Workers[0]
+	for i := range workers {
+		if workers[i].WorkerID != consensus.Winner {
+			continue
+		}
+		if winner, err := r.config.GetWorkerByID(sampledWorkerID(&workers[i])); err == nil {
+			base = *winner
+		}
+		break
+	}
+	escalated := base
+	escalated.ID = base.ID + "@" + r.config.Consensus.EscalationProvider
+	escalated.Provider = r.config.Consensus.EscalationProvider
+	escalated.FallbackProvider = ""
+	escalated.Samples = 0
+	return []escalationWorker{{Worker: escalated}}
+}
@@ -0,0 +1,177 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// changingOpenAI is fakeOpenAI for workers whose first answer is first and
+// every later one then
+func changingOpenAI(t *testing.T, first, then string) *httptest.Server {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		answer := then
+		if requests.Add(1) == 1 {
+			answer = first
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk, _ := json.Marshal(map[string]interface{}{
+			"choices": []map[string]interface{}{{"delta": map[string]string{"content": answer}}},
+		})
+		fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", chunk)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func workerIDs(workers []WorkerResult) []string {
+	ids := make([]string, len(workers))
+	for i, worker := range workers {
+		ids[i] = worker.WorkerID
+	}
+	return ids
+}
+
+func TestResampleAfterLowConfidence(t *testing.T) {
+	steady := fakeOpenAI(t, "yes", 0)
+	changing := changingOpenAI(t, "no", "yes")
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  steady:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+  changing:
+    kind: openai
+    model: gpt-4o
+    base_url: %s
+workers:
+  - id: a
+    provider: steady
+  - id: b
+    provider: changing
+consensus:
+  algorithm: majority
+  min_confidence: 0.6
+  on_low_confidence: resample
+`, steady.URL, changing.URL))
+
+	result, err := r.Run(context.Background(), "Agree?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 of 2 agree, then 3 of 4 with the retries
+	if got := fmt.Sprint(workerIDs(result.Workers)); got != "[a b a/retry b/retry]" {
+		t.Errorf("workers = %s, want both asked again", got)
+	}
+	for _, worker := range result.Workers[2:] {
+		if worker.Metadata["escalation"] != "resample" || sampledWorkerID(&worker) != strings.TrimSuffix(worker.WorkerID, "/retry") {
+			t.Errorf("%s metadata = %v, want the resample and its worker noted", worker.WorkerID, worker.Metadata)
+		}
+	}
+	if !result.Success || result.Consensus.LowConfidence || result.Consensus.Confidence != 0.75 {
+		t.Errorf("consensus = %+v, want the second round's 0.75", result.Consensus)
+	}
+	if !strings.Contains(result.Consensus.Reasoning, "second round after low confidence 0.50: resample asked 2 more worker(s)") {
+		t.Errorf("reasoning = %q, want the second round noted", result.Consensus.Reasoning)
+	}
+}
+
+func TestEscalateAfterLowConfidence(t *testing.T) {
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  yes:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+  no:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+  strong:
+    kind: openai
+    model: gpt-4o
+    base_url: %s
+workers:
+  - id: a
+    provider: yes
+  - id: b
+    provider: no
+consensus:
+  algorithm: majority
+  min_confidence: 0.6
+  on_low_confidence: escalate
+  escalation_provider: strong
+`, fakeOpenAI(t, "yes", 0).URL, fakeOpenAI(t, "no", 0).URL, fakeOpenAI(t, "yes", 0).URL))
+
+	result, err := r.Run(context.Background(), "Agree?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The tie goes to a, whose settings the escalation provider answers with
+	if got := fmt.Sprint(workerIDs(result.Workers)); got != "[a b a@strong]" {
+		t.Errorf("workers = %s, want the winner's worker on the escalation provider", got)
+	}
+	if escalated := result.Workers[2]; escalated.Metadata["escalation"] != "escalate" || escalated.Stats == nil || escalated.Stats.Model != "gpt-4o" {
+		t.Errorf("escalated worker = %+v, want it from the escalation provider", escalated)
+	}
+	if !result.Success || result.Consensus.Winner != "a" || result.Consensus.LowConfidence {
+		t.Errorf("consensus = %+v, want a confirmed by the escalation", result.Consensus)
+	}
+}
+
+func TestEscalationHasItsOwnTimeBudget(t *testing.T) {
+	// The first round takes most of timeout; the escalation provider answers
+	// within escalation_timeout but not within what is left of timeout
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  yes:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+  no:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+  strong:
+    kind: openai
+    model: gpt-4o
+    base_url: %s
+workers:
+  - id: a
+    provider: yes
+  - id: b
+    provider: no
+consensus:
+  algorithm: majority
+  timeout: 1s
+  min_confidence: 0.6
+  on_low_confidence: escalate
+  escalation_provider: strong
+`, fakeOpenAI(t, "yes", 700*time.Millisecond).URL, fakeOpenAI(t, "no", 700*time.Millisecond).URL, fakeOpenAI(t, "yes", 500*time.Millisecond).URL))
+	if r.config.Consensus.EscalationTimeout != time.Second {
+		t.Fatalf("escalation_timeout = %v, want worker_timeout plus judging_timeout", r.config.Consensus.EscalationTimeout)
+	}
+
+	result, err := r.Run(context.Background(), "Agree?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Workers) != 3 {
+		t.Fatalf("workers = %v, want the escalation asked", workerIDs(result.Workers))
+	}
+	if err := result.Workers[2].Error; err != nil {
+		t.Fatalf("escalation failed: %v", err)
+	}
+	if !result.Success || result.Consensus.LowConfidence {
+		t.Errorf("consensus = %+v, want the escalation to settle it", result.Consensus)
+	}
+}
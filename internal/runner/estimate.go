@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// CostEstimate is a worst-case forecast for a run, assuming every worker and
+// judge call uses its full max_tokens and every judge retry is needed
+type CostEstimate struct {
+	Calls   []CallEstimate `json:"calls"`
+	MaxCost float64        `json:"max_cost"`
+}
+
+// CallEstimate is the worst-case cost of the requests made by one worker or judge
+type CallEstimate struct {
+	ID                  string  `json:"id"` // worker ID, or judge → worker for judge calls
+	Model               string  `json:"model"`
+	Requests            int     `json:"requests"`
+	PromptTokens        int     `json:"prompt_tokens"`
+	MaxCompletionTokens int     `json:"max_completion_tokens"`
+	MaxCost             float64 `json:"max_cost"`
+}
+
+// EstimateRun forecasts the worst-case cost of Run for the prompt
+func (r *Runner) EstimateRun(prompt string) *CostEstimate {
+	estimate := &CostEstimate{}
+	r.estimateFanOut(estimate, prompt, 0)
+	return estimate
+}
+
+// EstimatePlanRun forecasts the worst-case cost of GeneratePlan followed by
+// ExecutePlan: the planning call plus a fan-out whose prompt carries the plan
+func (r *Runner) EstimatePlanRun(prompt string, ideContext interface{}) *CostEstimate {
+	estimate := &CostEstimate{}
+	if len(r.config.Workers) == 0 {
+		return estimate
+	}
+
+	planner := r.config.Planner()
+	estimate.add(r.estimateCall(planner.ID+" (plan)", planner.Provider, r.estimatedPrompt(prompt, ideContext), 0, planner.MaxTokens, 1))
+
+	// The execution prompt embeds the generated plan, at most the planner's max_tokens
+	r.estimateFanOut(estimate, prompt, planner.MaxTokens)
+	return estimate
+}
+
+// estimateFanOut adds every worker call, and the judge calls scoring them, to the estimate
+func (r *Runner) estimateFanOut(estimate *CostEstimate, prompt string, extraPromptTokens int) {
+	judged := r.config.Consensus.Algorithm == "score_top1"
+	// Retries, plus the corrective re-ask of an unparseable answer
+	judgeAttempts := 2 + max(r.config.Consensus.JudgeRetries, 0)
+	prepared := r.estimatedPrompt(prompt, nil)
+
+	workers := slices.Clone(r.config.Workers)
+	switch r.config.Consensus.OnLowConfidence {
+	case config.LowConfidenceResample:
+		// A low-confidence second round asks every worker once more
+		for _, worker := range r.config.Workers {
+			worker.ID += "/retry"
+			worker.Samples = 0
+			workers = append(workers, worker)
+		}
+	case config.LowConfidenceEscalate:
+		// ...or the escalation provider, as whichever worker writes the longest answers
+		escalated := r.config.Workers[0]
+		for _, worker := range r.config.Workers {
+			if worker.MaxTokens > escalated.MaxTokens {
+				escalated = worker
+			}
+		}
+		escalated.ID += "@" + r.config.Consensus.EscalationProvider
+		escalated.Provider = r.config.Consensus.EscalationProvider
+		escalated.Samples = 0
+		workers = append(workers, escalated)
+	}
+
+	for _, worker := range workers {
+		// Priced as one request per sample, an upper bound when the provider samples natively
+		samples := max(worker.Samples, 1)
+		estimate.add(r.estimateCall(worker.ID, worker.Provider, prepared+r.workerSystemPrompt(worker.SystemPrompt), extraPromptTokens, worker.MaxTokens, samples))
+
+		if !judged {
+			continue
+		}
+		// Judges see the prompt plus the worker's full answer
+		for _, judge := range r.config.Judges {
+			if judge.Kind == config.JudgeKindCodeCheck {
+				continue // checked locally, free
+			}
+			id := fmt.Sprintf("%s → %s", judge.ID, worker.ID)
+			estimate.add(r.estimateCall(id, judge.Provider, prompt+judge.SystemPrompt, extraPromptTokens+worker.MaxTokens, judgeMaxTokens, judgeAttempts*samples))
+		}
+	}
+}
+
+// estimatedPrompt is the prompt as the preprocessors would send it, context
+// included; one they reject is priced as written, since the run fails anyway
+func (r *Runner) estimatedPrompt(prompt string, ideContext interface{}) string {
+	prepared, err := r.preparePrompt(context.Background(), prompt, ideContext)
+	if err != nil {
+		return prompt
+	}
+	return prepared.Text + prepared.Context
+}
+
+// estimateCall prices requests to one provider at their completion ceiling
+func (r *Runner) estimateCall(id, providerName, text string, extraPromptTokens, maxTokens, requests int) CallEstimate {
+	call := CallEstimate{
+		ID:                  id,
+		Requests:            requests,
+		PromptTokens:        extraPromptTokens,
+		MaxCompletionTokens: maxTokens,
+	}
+
+	prov, err := r.providerManager.GetProvider(providerName)
+	if err != nil {
+		call.PromptTokens += provider.EstimateTokensSimple(text)
+	} else {
+		call.Model = prov.GetModel()
+		call.PromptTokens += prov.EstimateTokens(text)
+	}
+
+	perRequest := provider.EstimateCost(call.Model, &provider.TokenUsage{
+		PromptTokens:     call.PromptTokens,
+		CompletionTokens: maxTokens,
+		TotalTokens:      call.PromptTokens + maxTokens,
+	})
+	call.MaxCost = perRequest * float64(requests)
+	return call
+}
+
+func (e *CostEstimate) add(call CallEstimate) {
+	e.Calls = append(e.Calls, call)
+	e.MaxCost += call.MaxCost
+}
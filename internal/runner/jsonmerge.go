@@ -0,0 +1,181 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fieldVote is the outcome of the vote on one field of the merged answer
+type fieldVote struct {
+	path   string // dotted path of the field, e.g. address.city
+	agreed int    // workers whose answer has the winning value
+	voters int    // workers that voted on the field
+}
+
+// jsonMergeConsensus merges the workers' structured answers field by field:
+// each key of the JSON object takes the value most workers gave it (nested
+// objects are merged the same way), and a key most workers left out is left
+// out. Ties go to the value from the worker listed first. Confidence is the
+// mean agreement across fields.
+func (r *Runner) jsonMergeConsensus(workers []WorkerResult, consensus *Consensus) (*Consensus, error) {
+	var voters []WorkerResult
+	for _, worker := range workers {
+		if worker.StructuredContent != nil {
+			voters = append(voters, worker)
+		}
+	}
+	if len(voters) == 0 {
+		return nil, fmt.Errorf("json_merge consensus found no valid JSON answers; every worker needs a response_schema")
+	}
+
+	values := make([]interface{}, len(voters))
+	for i, voter := range voters {
+		values[i] = voter.StructuredContent
+	}
+	merged, votes := mergeValues(values, "")
+
+	content, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("json_merge consensus: %w", err)
+	}
+
+	var agreement float64
+	parts := make([]string, len(votes))
+	for i, vote := range votes {
+		agreement += float64(vote.agreed) / float64(vote.voters)
+		parts[i] = fmt.Sprintf("%s %d/%d", vote.path, vote.agreed, vote.voters)
+	}
+
+	// Credit the answer that matches the merged object on the most fields
+	mergedKey := canonicalJSON(merged)
+	winner, best := voters[0], -1
+	for _, voter := range voters {
+		matching := matchingFields(voter.StructuredContent, merged)
+		if canonicalJSON(voter.StructuredContent) == mergedKey {
+			matching = len(votes) + 1
+		}
+		if matching > best {
+			winner, best = voter, matching
+		}
+	}
+
+	consensus.Winner = winner.WorkerID
+	consensus.Content = string(content)
+	consensus.Confidence = agreement / float64(len(votes))
+	consensus.Reasoning = fmt.Sprintf("Merged %d JSON answers field by field (agreement per field: %s)",
+		len(voters), strings.Join(parts, ", "))
+	if len(voters) < len(workers) {
+		consensus.Reasoning += fmt.Sprintf("; %d answer(s) without valid JSON were left out", len(workers)-len(voters))
+	}
+
+	return consensus, nil
+}
+
+// mergeValues votes on values given for the same field. When every value is
+// an object, each key is voted on separately; anything else is voted on as a
+// whole. It returns the winning value and the votes behind it.
+func mergeValues(values []interface{}, path string) (interface{}, []fieldVote) {
+	objects := make([]map[string]interface{}, 0, len(values))
+	for _, value := range values {
+		if object, ok := value.(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+	if len(objects) < len(values) {
+		winner, agreed := majorityValue(values)
+		return winner, []fieldVote{{path: fieldPath(path), agreed: agreed, voters: len(values)}}
+	}
+
+	keys := make(map[string]bool)
+	for _, object := range objects {
+		for key := range object {
+			keys[key] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	merged := make(map[string]interface{})
+	var votes []fieldVote
+	for _, key := range sorted {
+		var present []interface{}
+		for _, object := range objects {
+			if value, ok := object[key]; ok {
+				present = append(present, value)
+			}
+		}
+
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		// Most workers left the key out
+		if 2*len(present) < len(objects) {
+			votes = append(votes, fieldVote{path: keyPath + " (left out)", agreed: len(objects) - len(present), voters: len(objects)})
+			continue
+		}
+
+		value, keyVotes := mergeValues(present, keyPath)
+		merged[key] = value
+		votes = append(votes, keyVotes...)
+	}
+	if len(votes) == 0 {
+		votes = []fieldVote{{path: fieldPath(path), agreed: len(objects), voters: len(objects)}}
+	}
+	return merged, votes
+}
+
+// majorityValue returns the value given most often, ties going to the first
+// given, and how many gave it
+func majorityValue(values []interface{}) (interface{}, int) {
+	counts := make(map[string]int)
+	for _, value := range values {
+		counts[canonicalJSON(value)]++
+	}
+
+	winner, best := values[0], 0
+	for _, value := range values {
+		if count := counts[canonicalJSON(value)]; count > best {
+			winner, best = value, count
+		}
+	}
+	return winner, best
+}
+
+// matchingFields counts the top-level fields of answer equal to merged's
+func matchingFields(answer, merged interface{}) int {
+	answerObject, ok := answer.(map[string]interface{})
+	mergedObject, ok2 := merged.(map[string]interface{})
+	if !ok || !ok2 {
+		return 0
+	}
+
+	matching := 0
+	for key, value := range mergedObject {
+		if other, exists := answerObject[key]; exists && canonicalJSON(other) == canonicalJSON(value) {
+			matching++
+		}
+	}
+	return matching
+}
+
+// canonicalJSON encodes a value so equal values encode identically;
+// encoding/json sorts map keys
+func canonicalJSON(value interface{}) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// fieldPath names the whole answer when a vote isn't on a field
+func fieldPath(path string) string {
+	if path == "" {
+		return "(answer)"
+	}
+	return path
+}
@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// structuredAnswers returns workers w0, w1... whose structured answers are
+// the given JSON documents; an empty document is an answer without valid JSON
+func structuredAnswers(t *testing.T, documents ...string) []WorkerResult {
+	t.Helper()
+	workers := answers(documents...)
+	for i, document := range documents {
+		if document == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(document), &workers[i].StructuredContent); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return workers
+}
+
+func TestJSONMergeConsensus(t *testing.T) {
+	tests := []struct {
+		name           string
+		answers        []string
+		wantContent    string
+		wantWinner     string
+		wantConfidence float64
+		wantReasoning  string
+	}{
+		{
+			name: "field by field majority",
+			answers: []string{
+				`{"city": "Paris", "country": "France", "population": 2}`,
+				`{"city": "Paris", "country": "FR", "population": 2}`,
+				`{"city": "Lyon", "country": "France", "population": 2}`,
+			},
+			wantContent:    `{"city":"Paris","country":"France","population":2}`,
+			wantWinner:     "w0",
+			wantConfidence: (2.0/3 + 2.0/3 + 1) / 3,
+			wantReasoning:  "city 2/3",
+		},
+		{
+			name: "nested objects merge per key",
+			answers: []string{
+				`{"address": {"city": "Paris", "zip": "75001"}}`,
+				`{"address": {"city": "Paris", "zip": "75002"}}`,
+				`{"address": {"city": "Lyon", "zip": "75001"}}`,
+			},
+			wantContent:    `{"address":{"city":"Paris","zip":"75001"}}`,
+			wantWinner:     "w0",
+			wantConfidence: 2.0 / 3,
+			wantReasoning:  "address.city 2/3",
+		},
+		{
+			name: "keys most answers leave out are left out",
+			answers: []string{
+				`{"name": "x", "extra": true}`,
+				`{"name": "x"}`,
+				`{"name": "x"}`,
+			},
+			wantContent:    `{"name":"x"}`,
+			wantWinner:     "w1",
+			wantConfidence: (2.0/3 + 1) / 2,
+			wantReasoning:  "extra (left out) 2/3",
+		},
+		{
+			name:           "ties go to the first answer",
+			answers:        []string{`{"n": 1}`, `{"n": 2}`},
+			wantContent:    `{"n":1}`,
+			wantWinner:     "w0",
+			wantConfidence: 0.5,
+		},
+		{
+			name:           "answers without valid JSON are left out",
+			answers:        []string{"", `{"n": 1}`, `{"n": 1}`},
+			wantContent:    `{"n":1}`,
+			wantWinner:     "w1",
+			wantConfidence: 1,
+			wantReasoning:  "1 answer(s) without valid JSON were left out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Runner{}
+			consensus, err := r.jsonMergeConsensus(structuredAnswers(t, tt.answers...), &Consensus{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var merged interface{}
+			if err := json.Unmarshal([]byte(consensus.Content), &merged); err != nil {
+				t.Fatalf("merged answer isn't JSON: %v", err)
+			}
+			if got := canonicalJSON(merged); got != tt.wantContent {
+				t.Errorf("merged = %s, want %s", got, tt.wantContent)
+			}
+			if consensus.Winner != tt.wantWinner {
+				t.Errorf("winner = %s, want %s", consensus.Winner, tt.wantWinner)
+			}
+			if math.Abs(consensus.Confidence-tt.wantConfidence) > 1e-9 {
+				t.Errorf("confidence = %v, want %v", consensus.Confidence, tt.wantConfidence)
+			}
+			if !strings.Contains(consensus.Reasoning, tt.wantReasoning) {
+				t.Errorf("reasoning = %q, want it to mention %q", consensus.Reasoning, tt.wantReasoning)
+			}
+		})
+	}
+}
+
+func TestJSONMergeConsensusNeedsJSONAnswers(t *testing.T) {
+	r := &Runner{}
+	if _, err := r.jsonMergeConsensus(answers("not json", "neither"), &Consensus{}); err == nil {
+		t.Fatal("merged answers without any JSON, want an error")
+	}
+}
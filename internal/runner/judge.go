@@ -3,7 +3,9 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +16,74 @@ import (
 	"github.com/evisdrenova/devgru/internal/provider"
 )
 
+// judgeMaxTokens caps judge answers, which only need a score and a short reason
+const judgeMaxTokens = 500
+
+// errJudgeParse marks judge answers that arrived but weren't the expected JSON
+var errJudgeParse = errors.New("failed to parse judge response")
+
+// judgeCorrectionPrompt follows the evaluation prompt when re-asking a judge
+// whose previous answer could not be parsed
+const judgeCorrectionPrompt = `
+
+Your previous answer could not be used: %v
+
+Respond ONLY with valid JSON in exactly this format, with no other text:
+{"score": <integer 0-10>, "reason": "<brief explanation>"}`
+
+// judgeResponseSchema is the judge answer format, enforced on the corrective
+// re-ask by providers that support structured output
+var judgeResponseSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"score":  map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 10},
+		"reason": map[string]interface{}{"type": "string"},
+	},
+	"required":             []interface{}{"score", "reason"},
+	"additionalProperties": false,
+}
+
+// judgeWorker scores a worker with all judges and records the results on it;
+// a worker no judge could score is marked unscored rather than given a default
+func (r *Runner) judgeWorker(ctx context.Context, worker *WorkerResult, originalPrompt string) {
+	judgeResults, err := r.evaluateWithJudges(ctx, *worker, originalPrompt)
+	worker.judged = true
+	if err != nil {
+		// Log error but don't fail consensus - we can still compare what we have
+		fmt.Fprintf(os.Stderr, "Warning: run %s: failed to evaluate worker %s with judges: %v\n", RunIDFrom(ctx), worker.WorkerID, err)
+		worker.Unscored = true
+		return
+	}
+
+	worker.JudgeResults = judgeResults
+	worker.AverageScore = r.aggregateScores(judgeResults)
+	worker.Unscored = len(judgeResults) == 0
+}
+
+// SetJudgeObserver registers a callback told when each judge starts and
+// finishes scoring a worker. Judges run concurrently, so the callback must be
+// safe for concurrent use and should return quickly.
+func (r *Runner) SetJudgeObserver(fn func(JudgeEvent)) {
+	r.judgeObserver = fn
+}
+
+type judgeObserverKey struct{}
+
+// WithJudgeObserver returns a context whose runs also report judge progress to
+// fn, so concurrent runs on one runner can each follow their own judges
+func WithJudgeObserver(ctx context.Context, fn func(JudgeEvent)) context.Context {
+	return context.WithValue(ctx, judgeObserverKey{}, fn)
+}
+
+func (r *Runner) emitJudgeEvent(ctx context.Context, event JudgeEvent) {
+	if r.judgeObserver != nil {
+		r.judgeObserver(event)
+	}
+	if fn, ok := ctx.Value(judgeObserverKey{}).(func(JudgeEvent)); ok {
+		fn(event)
+	}
+}
+
 // evaluateWithJudges evaluates a worker response with all configured judges
 func (r *Runner) evaluateWithJudges(ctx context.Context, worker WorkerResult, originalPrompt string) ([]JudgeResult, error) {
 	g, ctx := errgroup.WithContext(ctx)
@@ -49,18 +119,101 @@ func (r *Runner) evaluateWithJudges(ctx context.Context, worker WorkerResult, or
 	return validResults, nil
 }
 
-// evaluateWithSingleJudge evaluates a worker response with a single judge
-func (r *Runner) evaluateWithSingleJudge(ctx context.Context, worker WorkerResult, originalPrompt string, judge config.Judge) JudgeResult {
+// evaluateWithSingleJudge evaluates a worker response with a single judge,
+// retrying failed answers up to the configured judge retries. An unparseable
+// answer is re-asked once with the parse error and the expected format, even
+// with retries disabled or used up; if that answer can't be parsed either,
+// the judge gives up.
+func (r *Runner) evaluateWithSingleJudge(ctx context.Context, worker WorkerResult, originalPrompt string, judge config.Judge) (result JudgeResult) {
 	startTime := time.Now()
-	result := JudgeResult{
+	result = JudgeResult{
 		JudgeID:  judge.ID,
 		WorkerID: worker.WorkerID,
 	}
 
+	r.emitJudgeEvent(ctx, JudgeEvent{JudgeID: judge.ID, WorkerID: worker.WorkerID})
+	defer func() {
+		r.emitJudgeEvent(ctx, JudgeEvent{JudgeID: judge.ID, WorkerID: worker.WorkerID, Done: true, Score: result.Score, Err: result.Error})
+	}()
+
+	if judge.Kind == config.JudgeKindCodeCheck {
+		return codeCheckJudge(worker, judge)
+	}
+
 	// Get the provider for this judge
 	prov, err := r.providerManager.GetProvider(judge.Provider)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to get judge provider %s: %w", judge.Provider, err)
+		result.Error = err
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
+	attempts := 1 + max(r.config.Consensus.JudgeRetries, 0)
+	var correction error
+	corrected := false
+	for attempt := 1; attempt <= attempts; attempt++ {
+		result = r.judgeAttempt(ctx, prov, worker, originalPrompt, judge, correction)
+		result.Attempts = attempt
+		if result.Error == nil || ctx.Err() != nil || !retryableJudgeError(result.Error) {
+			break
+		}
+
+		correction = nil
+		if errors.Is(result.Error, errJudgeParse) {
+			if corrected {
+				break
+			}
+			correction, corrected = result.Error, true
+			// The corrective re-ask is one extra call, not a retry
+			if attempt == attempts {
+				attempts++
+			}
+		}
+	}
+
+	result.Duration = time.Since(startTime)
+	return result
+}
+
+// acquireJudgeSlot waits for room under consensus.judge_concurrency, which
+// caps judge calls across all workers (and runs) so judging many workers at
+// once doesn't multiply into rate limit errors
+func (r *Runner) acquireJudgeSlot(ctx context.Context) (release func(), err error) {
+	if r.judgeSlots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case r.judgeSlots <- struct{}{}:
+		return func() { <-r.judgeSlots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("gave up waiting for a judge slot: %w", ctx.Err())
+	}
+}
+
+// judgeAttempt makes one judge call, bounded by the judge timeout. A non-nil
+// correction is the parse error of the previous answer, quoted back to the judge.
+func (r *Runner) judgeAttempt(ctx context.Context, prov provider.Provider, worker WorkerResult, originalPrompt string, judge config.Judge, correction error) JudgeResult {
+	startTime := time.Now()
+	result := JudgeResult{
+		JudgeID:   judge.ID,
+		WorkerID:  worker.WorkerID,
+		Corrected: correction != nil,
+	}
+
+	// Queue behind other judge calls; the wait doesn't count against the judge timeout
+	release, err := r.acquireJudgeSlot(ctx)
+	if err != nil {
+		result.Error = err
+		result.Duration = time.Since(startTime)
+		return result
+	}
+	defer release()
+
+	// Skip judges whose provider keeps failing. Checked once a slot is held, so
+	// a half-open trial isn't taken by a call that then gives up queueing.
+	if err := r.providerManager.Allow(judge.Provider); err != nil {
+		result.Error = err
 		result.Duration = time.Since(startTime)
 		return result
 	}
@@ -71,18 +224,43 @@ func (r *Runner) evaluateWithSingleJudge(ctx context.Context, worker WorkerResul
 Response to Evaluate: %s
 
 Please evaluate this response according to the criteria in your system prompt.`, originalPrompt, worker.Content)
+	if correction != nil {
+		evaluationPrompt += fmt.Sprintf(judgeCorrectionPrompt, correction)
+	}
 
 	// Set up options for the judge
 	opts := provider.Options{
 		Temperature:  0.1, // Low temperature for consistent evaluation
-		MaxTokens:    500, // Judges should be concise
+		MaxTokens:    judgeMaxTokens,
 		SystemPrompt: judge.SystemPrompt,
 		Stream:       false, // Non-streaming for easier parsing
+		RawOptions:   r.rawOptions(judge.Provider, nil),
+		Seed:         r.seed,
+
+		MaxResponseBytes: max(r.config.Consensus.MaxResponseBytes, 0),
+	}
+	if enforcer, ok := prov.(provider.SchemaEnforcer); ok && correction != nil && enforcer.EnforcesResponseSchema() {
+		opts.ResponseSchema = judgeResponseSchema
+	}
+	defer func() { r.traceJudge(RunIDFrom(ctx), judge, evaluationPrompt, opts, result) }()
+	if r.recordPrompts {
+		result.Prompt = assemblePrompt(opts, evaluationPrompt)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, r.config.Consensus.JudgeTimeout)
+	defer cancel()
+
+	if err := r.providerManager.Wait(attemptCtx, judge.Provider); err != nil {
+		r.providerManager.Release(judge.Provider)
+		result.Error = fmt.Errorf("gave up waiting for rate limit: %w", err)
+		result.Duration = time.Since(startTime)
+		return result
 	}
 
 	// Execute the evaluation
-	responseChan, err := prov.Ask(ctx, evaluationPrompt, opts)
+	responseChan, err := prov.Ask(attemptCtx, evaluationPrompt, opts)
 	if err != nil {
+		r.providerManager.RecordResult(judge.Provider, err)
 		result.Error = fmt.Errorf("failed to ask judge: %w", err)
 		result.Duration = time.Since(startTime)
 		return result
@@ -90,19 +268,30 @@ Please evaluate this response according to the criteria in your system prompt.`,
 
 	// Collect the response
 	collector := provider.NewStreamCollector()
-	collector.Collect(ctx, responseChan)
+	collector.MaxBytes = max(r.config.Consensus.MaxResponseBytes, 0)
+	collector.Collect(attemptCtx, responseChan)
+	r.providerManager.RecordResult(judge.Provider, collector.Error)
 
 	result.Duration = time.Since(startTime)
+	result.RawResponse = collector.Content
 
 	if collector.Error != nil {
 		result.Error = collector.Error
+		if attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			result.Error = &provider.ProviderError{
+				Provider: prov.GetName(),
+				Type:     provider.ErrorTypeTimeout,
+				Message:  fmt.Sprintf("judge %s did not answer within %v", judge.ID, r.config.Consensus.JudgeTimeout),
+				Cause:    collector.Error,
+			}
+		}
 		return result
 	}
 
 	// Parse the JSON response
 	score, reason, err := parseJudgeResponse(collector.Content)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to parse judge response: %w", err)
+		result.Error = fmt.Errorf("%w: %w", errJudgeParse, err)
 		return result
 	}
 
@@ -112,6 +301,22 @@ Please evaluate this response according to the criteria in your system prompt.`,
 	return result
 }
 
+// retryableJudgeError reports whether another judge attempt could succeed;
+// auth, quota and invalid request errors will fail the same way again
+func retryableJudgeError(err error) bool {
+	var provErr *provider.ProviderError
+	if !errors.As(err, &provErr) {
+		return true
+	}
+
+	switch provErr.Type {
+	case provider.ErrorTypeAuth, provider.ErrorTypeQuota,
+		provider.ErrorTypeValidation, provider.ErrorTypeUnavailable:
+		return false
+	}
+	return true
+}
+
 // parseJudgeResponse parses the JSON response from a judge
 func parseJudgeResponse(response string) (int, string, error) {
 	// Try to extract JSON from the response
@@ -0,0 +1,188 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// structuredNormalizer compares the parsed values of response_schema answers;
+// it is picked automatically rather than configured
+const structuredNormalizer = "structured"
+
+// lexicalNormalizer groups answers by word overlap, standing in for semantic
+// grouping when embeddings are unavailable
+const lexicalNormalizer = "lexical"
+
+// groupAnswers splits workers into groups of answers the configured
+// normalizer counts as the same, in order of each group's first answer. The
+// note explains a fallback to another normalizer, if one was needed; the
+// error is set only when embeddings failed and embedding_fallback is fail.
+func (r *Runner) groupAnswers(ctx context.Context, workers []WorkerResult) (groups [][]WorkerResult, normalizer, note string, err error) {
+	normalizer = r.config.Consensus.Normalizer
+
+	// Structured answers are compared as values, whatever their formatting
+	structured := true
+	for _, worker := range workers {
+		if worker.StructuredContent == nil {
+			structured = false
+			break
+		}
+	}
+	if structured {
+		normalizer = structuredNormalizer
+	}
+
+	if normalizer == config.NormalizerSemantic {
+		groups, err := r.groupBySimilarity(ctx, workers)
+		if err == nil {
+			return groups, normalizer, "", nil
+		}
+		if r.config.Consensus.EmbeddingFallback == config.EmbeddingFallbackFail {
+			return nil, normalizer, "", fmt.Errorf("embeddings unavailable for semantic grouping: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: run %s: embeddings unavailable (%v); grouping answers by word overlap instead\n", RunIDFrom(ctx), err)
+		note = fmt.Sprintf("embeddings unavailable (%v), used lexical clustering", err)
+		return groupByVectors(workers, wordVectors(workers), r.config.Consensus.SimilarityThreshold), lexicalNormalizer, note, nil
+	}
+
+	index := make(map[string]int)
+	for _, worker := range workers {
+		key := normalizeAnswer(worker.Content, normalizer)
+		if normalizer == structuredNormalizer {
+			// encoding/json sorts map keys, so equal values encode identically
+			encoded, _ := json.Marshal(worker.StructuredContent)
+			key = string(encoded)
+		}
+		i, exists := index[key]
+		if !exists {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], worker)
+	}
+	return groups, normalizer, note, nil
+}
+
+// normalizeAnswer reduces an answer to the form the normalizer compares
+func normalizeAnswer(content, normalizer string) string {
+	switch normalizer {
+	case structuredNormalizer:
+		return content
+	case config.NormalizerTrimmed:
+		return strings.ToLower(strings.TrimSpace(content))
+	case config.NormalizerCode:
+		code := content
+		if blocks := codeBlockPattern.FindAllStringSubmatch(content, -1); len(blocks) > 0 {
+			bodies := make([]string, len(blocks))
+			for i, block := range blocks {
+				bodies[i] = block[2]
+			}
+			code = strings.Join(bodies, "\n")
+		}
+		return strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, code)
+	default:
+		return content
+	}
+}
+
+// groupBySimilarity embeds every answer and adds each one to the first group
+// whose first answer is within the similarity threshold, or starts a new group
+func (r *Runner) groupBySimilarity(ctx context.Context, workers []WorkerResult) ([][]WorkerResult, error) {
+	prov, err := r.providerManager.GetProvider(r.config.Consensus.EmbeddingProvider)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := prov.(provider.Embedder)
+	if !ok {
+		return nil, fmt.Errorf("provider %s can't create embeddings", r.config.Consensus.EmbeddingProvider)
+	}
+
+	texts := make([]string, len(workers))
+	for i, worker := range workers {
+		texts[i] = worker.Content
+	}
+	embeddings, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(workers) {
+		return nil, fmt.Errorf("provider %s returned %d embeddings for %d answers", r.config.Consensus.EmbeddingProvider, len(embeddings), len(workers))
+	}
+	return groupByVectors(workers, embeddings, r.config.Consensus.SimilarityThreshold), nil
+}
+
+// groupByVectors adds each worker to the first group whose first answer's
+// vector is within threshold cosine similarity of its own, or starts a new group
+func groupByVectors(workers []WorkerResult, vectors [][]float64, threshold float64) [][]WorkerResult {
+	var groups [][]WorkerResult
+	var leaders [][]float64
+	for i, worker := range workers {
+		placed := false
+		for g, leader := range leaders {
+			if cosineSimilarity(leader, vectors[i]) >= threshold {
+				groups[g] = append(groups[g], worker)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, []WorkerResult{worker})
+			leaders = append(leaders, vectors[i])
+		}
+	}
+	return groups
+}
+
+// wordVectors counts the words of each answer, lowercased, over the
+// vocabulary of all of them, so answers can be compared by word overlap
+func wordVectors(workers []WorkerResult) [][]float64 {
+	vocabulary := make(map[string]int)
+	words := make([][]string, len(workers))
+	for i, worker := range workers {
+		words[i] = strings.FieldsFunc(strings.ToLower(worker.Content), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words[i] {
+			if _, seen := vocabulary[word]; !seen {
+				vocabulary[word] = len(vocabulary)
+			}
+		}
+	}
+
+	vectors := make([][]float64, len(workers))
+	for i := range workers {
+		vectors[i] = make([]float64, len(vocabulary))
+		for _, word := range words[i] {
+			vectors[i][vocabulary[word]]++
+		}
+	}
+	return vectors
+}
+
+// cosineSimilarity of two vectors, 0 when either is empty or zero
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+// answers returns workers answering with the given contents, named w0, w1...
+func answers(contents ...string) []WorkerResult {
+	workers := make([]WorkerResult, len(contents))
+	for i, content := range contents {
+		workers[i] = WorkerResult{WorkerID: fmt.Sprintf("w%d", i), Content: content}
+	}
+	return workers
+}
+
+// groupIDs renders groups of workers as "w0 w1 | w2"
+func groupIDs(groups [][]WorkerResult) string {
+	var parts []string
+	for _, group := range groups {
+		var ids []string
+		for _, worker := range group {
+			ids = append(ids, worker.WorkerID)
+		}
+		parts = append(parts, strings.Join(ids, " "))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func TestNormalizeAnswer(t *testing.T) {
+	tests := []struct {
+		normalizer string
+		a, b       string
+		same       bool
+	}{
+		{normalizer: config.NormalizerExact, a: "Yes", b: "Yes", same: true},
+		{normalizer: config.NormalizerExact, a: "Yes", b: " yes\n"},
+		{normalizer: config.NormalizerTrimmed, a: "Yes", b: " yes\n", same: true},
+		{normalizer: config.NormalizerTrimmed, a: "Yes.", b: "yes"},
+		{normalizer: config.NormalizerCode, a: "Use:\n```go\nx := 1\n```", b: "Try this:\n```go\nx:=1\n```", same: true},
+		{normalizer: config.NormalizerCode, a: "```go\nx := 1\n```", b: "```go\nx := 2\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.normalizer+" "+tt.a, func(t *testing.T) {
+			same := normalizeAnswer(tt.a, tt.normalizer) == normalizeAnswer(tt.b, tt.normalizer)
+			if same != tt.same {
+				t.Errorf("%q and %q counted the same: %v, want %v", tt.a, tt.b, same, tt.same)
+			}
+		})
+	}
+}
+
+func TestWordVectorsGroupByOverlap(t *testing.T) {
+	workers := answers("The answer is 42.", "the ANSWER is: 42", "Nobody knows for sure")
+	groups := groupByVectors(workers, wordVectors(workers), 0.8)
+	if got := groupIDs(groups); got != "w0 w1 | w2" {
+		t.Errorf("groups = %s, want w0 w1 | w2", got)
+	}
+}
+
+func TestGroupByVectorsComparesWithEachGroupsFirstAnswer(t *testing.T) {
+	// w1 is close to w0 and w2 to w1, but w2 isn't close to w0
+	vectors := [][]float64{{1, 0}, {1, 0.6}, {0.3, 1}}
+	groups := groupByVectors(answers("a", "b", "c"), vectors, 0.8)
+	if got := groupIDs(groups); got != "w0 w1 | w2" {
+		t.Errorf("groups = %s, want w0 w1 | w2", got)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float64
+		want float64
+	}{
+		{a: []float64{1, 0}, b: []float64{2, 0}, want: 1},
+		{a: []float64{1, 0}, b: []float64{0, 1}, want: 0},
+		{a: []float64{1, 0}, b: []float64{-1, 0}, want: -1},
+		{a: []float64{0, 0}, b: []float64{1, 0}, want: 0},
+		{a: nil, b: nil, want: 0},
+	}
+	for _, tt := range tests {
+		if got := cosineSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("cosineSimilarity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// fakeEmbeddings serves Ollama's /api/embeddings, embedding answers that
+// mention "yes" and the rest on orthogonal axes, or failing with status
+func fakeEmbeddings(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, `{"error":"model not found"}`, status)
+			return
+		}
+		var body struct {
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		embedding := []float64{0, 1}
+		if strings.Contains(strings.ToLower(body.Prompt), "yes") {
+			embedding = []float64{1, 0}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embedding": embedding})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGroupAnswersSemantic(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		fallback       string
+		wantGroups     string
+		wantNormalizer string
+		wantErr        bool
+	}{
+		{name: "embeddings", status: http.StatusOK, fallback: "lexical", wantGroups: "w0 w1 | w2", wantNormalizer: config.NormalizerSemantic},
+		// Without embeddings, word overlap keeps the differently worded answers apart
+		{name: "lexical fallback", status: http.StatusNotFound, fallback: "lexical", wantGroups: "w0 | w1 | w2", wantNormalizer: lexicalNormalizer},
+		{name: "fail", status: http.StatusNotFound, fallback: "fail", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embeddings := fakeEmbeddings(t, tt.status)
+			r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: http://localhost:1
+  local:
+    kind: ollama
+    model: llama3.1
+    host: %s
+workers:
+  - id: worker
+    provider: openai
+consensus:
+  algorithm: majority
+  normalizer: semantic
+  embedding_provider: local
+  embedding_fallback: %s
+`, embeddings.URL, tt.fallback))
+
+			groups, normalizer, note, err := r.groupAnswers(context.Background(), answers("Yes.", "YES, it does", "No"))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("grouping succeeded, want embedding_fallback: fail to fail it")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := groupIDs(groups); got != tt.wantGroups {
+				t.Errorf("groups = %s, want %s", got, tt.wantGroups)
+			}
+			if normalizer != tt.wantNormalizer {
+				t.Errorf("normalizer = %s, want %s", normalizer, tt.wantNormalizer)
+			}
+			if (normalizer == lexicalNormalizer) != (note != "") {
+				t.Errorf("note = %q; a fallback must be explained, and only a fallback", note)
+			}
+		})
+	}
+}
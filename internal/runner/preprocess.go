@@ -0,0 +1,168 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/ide"
+)
+
+// PreparedPrompt is a prompt on its way to the providers. Text is sent as
+// the prompt itself; Context is stable material sent ahead of it
+// (provider.Options.Context), which providers may cache.
+type PreparedPrompt struct {
+	Text    string
+	Context string
+}
+
+// PromptPreprocessor transforms a prompt before it reaches the providers.
+// Preprocessors run in order, each seeing the previous one's output;
+// ideContext is nil when no editor context applies. An error fails the
+// request before any provider is called.
+type PromptPreprocessor interface {
+	Name() string
+	Process(ctx context.Context, prompt PreparedPrompt, ideContext *ide.IDEContext) (PreparedPrompt, error)
+}
+
+// builtinPreprocessor returns the built-in preprocessor registered under a
+// prompt.preprocessors name
+func (r *Runner) builtinPreprocessor(name string) (PromptPreprocessor, error) {
+	switch name {
+	case config.PreprocessorTemplate:
+		return templatePreprocessor{}, nil
+	case config.PreprocessorIDEContext:
+		return ideContextPreprocessor{runner: r}, nil
+	case config.PreprocessorFiles:
+		return filesPreprocessor{runner: r}, nil
+	default:
+		return nil, fmt.Errorf("unknown prompt preprocessor: %s", name)
+	}
+}
+
+// AddPreprocessor appends a preprocessor to the pipeline, after the ones
+// configured in prompt.preprocessors
+func (r *Runner) AddPreprocessor(p PromptPreprocessor) {
+	r.preprocessors = append(r.preprocessors, p)
+}
+
+// ideContextKey is the context key for editor context given to a run
+type ideContextKey struct{}
+
+// WithIDEContext returns a context carrying editor context, for runs started
+// outside interactive mode (e.g. devgru run --use-ide-context): Run and
+// RunWorker prepare their prompt with it as planning does
+func WithIDEContext(ctx context.Context, ideContext *ide.IDEContext) context.Context {
+	return context.WithValue(ctx, ideContextKey{}, ideContext)
+}
+
+// preparePrompt runs the prompt through the preprocessor pipeline.
+// ideContext is an *ide.IDEContext or nil, as callers receive it; when nil,
+// any editor context carried by ctx applies.
+func (r *Runner) preparePrompt(ctx context.Context, prompt string, ideContext interface{}) (PreparedPrompt, error) {
+	editor, _ := ideContext.(*ide.IDEContext)
+	if editor == nil {
+		editor, _ = ctx.Value(ideContextKey{}).(*ide.IDEContext)
+	}
+
+	prepared := PreparedPrompt{Text: prompt}
+	for _, p := range r.preprocessors {
+		next, err := p.Process(ctx, prepared, editor)
+		if err != nil {
+			return prepared, fmt.Errorf("prompt preprocessor %s: %w", p.Name(), err)
+		}
+		prepared = next
+	}
+
+	// The steering instruction goes last, whatever the preprocessors did
+	if instruction := strings.TrimSpace(r.config.WorkersInstruction); instruction != "" {
+		prepared.Text += "\n\n" + instruction
+	}
+	return prepared, nil
+}
+
+// withContext appends a section to the prompt's context
+func (p PreparedPrompt) withContext(section string) PreparedPrompt {
+	if section == "" {
+		return p
+	}
+	if p.Context != "" {
+		p.Context += "\n\n"
+	}
+	p.Context += section
+	return p
+}
+
+// placeholderPattern matches {{name}} template placeholders
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// templatePreprocessor fills editor placeholders in the prompt text:
+// {{selection}}, {{active_file}} and {{workspace}}. Without editor context
+// (e.g. devgru run) the text is left alone, as is any other {{...}}.
+type templatePreprocessor struct{}
+
+func (templatePreprocessor) Name() string { return config.PreprocessorTemplate }
+
+func (templatePreprocessor) Process(ctx context.Context, prompt PreparedPrompt, ideContext *ide.IDEContext) (PreparedPrompt, error) {
+	if ideContext == nil {
+		return prompt, nil
+	}
+
+	var err error
+	prompt.Text = placeholderPattern.ReplaceAllStringFunc(prompt.Text, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		var value string
+		switch name {
+		case "selection":
+			if ideContext.Selection != nil {
+				value = ideContext.Selection.Text
+			}
+		case "active_file":
+			if ideContext.ActiveFile != "" {
+				value = ideContext.DisplayPath(ideContext.ActiveFile)
+			}
+		case "workspace":
+			value = ideContext.WorkspaceRoot
+		default:
+			return match
+		}
+		if value == "" && err == nil {
+			err = fmt.Errorf("the prompt uses {{%s}}, but the editor hasn't reported one", name)
+		}
+		return value
+	})
+	return prompt, err
+}
+
+// ideContextPreprocessor adds the editor's project context (active file,
+// selection, open files, diagnostics) to the prompt's context
+type ideContextPreprocessor struct {
+	runner *Runner
+}
+
+func (ideContextPreprocessor) Name() string { return config.PreprocessorIDEContext }
+
+func (p ideContextPreprocessor) Process(ctx context.Context, prompt PreparedPrompt, ideContext *ide.IDEContext) (PreparedPrompt, error) {
+	if ideContext == nil {
+		return prompt, nil
+	}
+	return prompt.withContext("## Project Context\n" + p.runner.buildProjectContext(ideContext)), nil
+}
+
+// filesPreprocessor attaches the workspace files the prompt text mentions
+// to the prompt's context, within the file_references token budget
+type filesPreprocessor struct {
+	runner *Runner
+}
+
+func (filesPreprocessor) Name() string { return config.PreprocessorFiles }
+
+func (p filesPreprocessor) Process(ctx context.Context, prompt PreparedPrompt, ideContext *ide.IDEContext) (PreparedPrompt, error) {
+	root := ""
+	if ideContext != nil {
+		root = ideContext.WorkspaceRoot
+	}
+	return prompt.withContext(p.runner.referencedFiles(prompt.Text, root)), nil
+}
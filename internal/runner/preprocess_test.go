@@ -0,0 +1,171 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/ide"
+)
+
+// preprocessTestConfig is a config whose prompt section is appended by tests
+const preprocessTestConfig = `
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: http://localhost:1
+workers:
+  - id: worker
+    provider: openai
+consensus:
+  algorithm: majority
+`
+
+// failingPreprocessor fails every prompt
+type failingPreprocessor struct{}
+
+func (failingPreprocessor) Name() string { return "failing" }
+
+func (failingPreprocessor) Process(ctx context.Context, prompt PreparedPrompt, ideContext *ide.IDEContext) (PreparedPrompt, error) {
+	return prompt, errors.New("no thanks")
+}
+
+// recordingPreprocessor records the prompt it was given
+type recordingPreprocessor struct {
+	seen *PreparedPrompt
+}
+
+func (recordingPreprocessor) Name() string { return "recording" }
+
+func (p recordingPreprocessor) Process(ctx context.Context, prompt PreparedPrompt, ideContext *ide.IDEContext) (PreparedPrompt, error) {
+	*p.seen = prompt
+	return prompt, nil
+}
+
+func TestTemplatePreprocessor(t *testing.T) {
+	editor := &ide.IDEContext{
+		WorkspaceRoot: "/work",
+		ActiveFile:    "/work/cmd/main.go",
+		Selection:     &ide.SelectionMessage{Text: "x := 1"},
+	}
+
+	tests := []struct {
+		name    string
+		prompt  string
+		editor  *ide.IDEContext
+		want    string
+		wantErr string
+	}{
+		{name: "fills placeholders", prompt: "explain {{selection}} in {{ active_file }} of {{workspace}}", editor: editor, want: "explain x := 1 in cmd/main.go of /work"},
+		{name: "leaves unknown placeholders", prompt: "{{selection}} and {{other}}", editor: editor, want: "x := 1 and {{other}}"},
+		{name: "without an editor", prompt: "explain {{selection}}", want: "explain {{selection}}"},
+		{name: "missing selection", prompt: "explain {{selection}}", editor: &ide.IDEContext{WorkspaceRoot: "/work"}, wantErr: "{{selection}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := templatePreprocessor{}.Process(context.Background(), PreparedPrompt{Text: tt.prompt}, tt.editor)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one about %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Text != tt.want {
+				t.Errorf("text = %q, want %q", got.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreparePromptPipeline(t *testing.T) {
+	editor := &ide.IDEContext{
+		WorkspaceRoot: "/work",
+		ActiveFile:    "/work/main.go",
+		Selection:     &ide.SelectionMessage{File: "/work/main.go", Text: "x := 1"},
+	}
+
+	tests := []struct {
+		name        string
+		prompt      string // prompt section of the config
+		wantText    string
+		wantContext string // empty: no context at all
+	}{
+		{
+			name:        "default pipeline",
+			wantText:    "explain x := 1",
+			wantContext: "## Project Context",
+		},
+		{
+			name:     "no preprocessors",
+			prompt:   "prompt:\n  preprocessors: []\n",
+			wantText: "explain {{selection}}",
+		},
+		{
+			name:        "configured order",
+			prompt:      "prompt:\n  preprocessors: [ide_context]\n",
+			wantText:    "explain {{selection}}",
+			wantContext: "## Project Context",
+		},
+		{
+			name:     "workers instruction goes last",
+			prompt:   "workers_instruction: Answer briefly.\nprompt:\n  preprocessors: [template]\n",
+			wantText: "explain x := 1\n\nAnswer briefly.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRunner(t, preprocessTestConfig+tt.prompt)
+			prepared, err := r.preparePrompt(context.Background(), "explain {{selection}}", editor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prepared.Text != tt.wantText {
+				t.Errorf("text = %q, want %q", prepared.Text, tt.wantText)
+			}
+			if tt.wantContext == "" && prepared.Context != "" {
+				t.Errorf("context = %q, want none", prepared.Context)
+			}
+			if !strings.Contains(prepared.Context, tt.wantContext) {
+				t.Errorf("context = %q, want it to contain %q", prepared.Context, tt.wantContext)
+			}
+		})
+	}
+}
+
+func TestPreparePromptAddedPreprocessorsRunLast(t *testing.T) {
+	r := newTestRunner(t, preprocessTestConfig+"prompt:\n  preprocessors: [template]\n")
+	var seen PreparedPrompt
+	r.AddPreprocessor(recordingPreprocessor{seen: &seen})
+
+	// Editor context carried by ctx applies when none is given
+	ctx := WithIDEContext(context.Background(), &ide.IDEContext{Selection: &ide.SelectionMessage{Text: "x := 1"}})
+	if _, err := r.preparePrompt(ctx, "explain {{selection}}", nil); err != nil {
+		t.Fatal(err)
+	}
+	if seen.Text != "explain x := 1" {
+		t.Errorf("added preprocessor saw %q, want the template already filled", seen.Text)
+	}
+}
+
+func TestPreparePromptNamesTheFailingPreprocessor(t *testing.T) {
+	r := newTestRunner(t, preprocessTestConfig)
+	r.AddPreprocessor(failingPreprocessor{})
+
+	_, err := r.preparePrompt(context.Background(), "prompt", nil)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("prompt preprocessor %s", "failing")) {
+		t.Fatalf("error = %v, want it to name the failing preprocessor", err)
+	}
+
+	// The run fails before any worker is asked
+	if _, err := r.Run(context.Background(), "prompt"); err == nil || !strings.Contains(err.Error(), "no thanks") {
+		t.Errorf("Run error = %v, want the preprocessor's", err)
+	}
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evisdrenova/devgru/internal/ide"
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// referencedFiles reads the workspace files a prompt mentions, either as an
+// explicit @path token or as a bare path such as internal/runner/runner.go,
+// and formats them as context for the workers. Files are attached in the
+// order they are mentioned until the file_references token budget runs out;
+// missing explicit references, and ones over the budget, are noted instead.
+func (r *Runner) referencedFiles(prompt, root string) string {
+	budget := r.config.FileReferences.MaxTokens
+	if budget < 0 {
+		return ""
+	}
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return ""
+		}
+		root = wd
+	}
+
+	var files, notes []string
+	seen := make(map[string]bool)
+	used := 0
+
+	for _, field := range strings.Fields(prompt) {
+		mention, explicit := fileMention(field)
+		if mention == "" {
+			continue
+		}
+
+		path, ok := workspacePath(root, mention)
+		if !ok || seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		content, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil {
+			// Bare words like "e.g" look like paths too; only note what was clearly meant as one
+			if explicit || strings.Contains(mention, "/") {
+				notes = append(notes, fmt.Sprintf("- `%s` was mentioned but isn't a readable file in the workspace", mention))
+			}
+			continue
+		}
+		if bytes.IndexByte(content, 0) >= 0 {
+			notes = append(notes, fmt.Sprintf("- `%s` is a binary file and was not attached", path))
+			continue
+		}
+
+		tokens := provider.EstimateTokensSimple(string(content))
+		if used+tokens > budget {
+			notes = append(notes, fmt.Sprintf("- `%s` was not attached: it would exceed the %d token budget for referenced files", path, budget))
+			continue
+		}
+		used += tokens
+
+		files = append(files, fmt.Sprintf("### %s\n%s", path, fenced(string(content))))
+	}
+
+	if len(files) == 0 && len(notes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Referenced Files\n")
+	for _, file := range files {
+		b.WriteString("\n" + file)
+	}
+	if len(notes) > 0 {
+		b.WriteString("\n" + strings.Join(notes, "\n") + "\n")
+	}
+	return b.String()
+}
+
+// fileMention extracts a possible file path from a whitespace-separated word
+// of the prompt; explicit is true for @path tokens
+func fileMention(field string) (mention string, explicit bool) {
+	field = strings.TrimLeft(field, "`'\"([{<")
+	field = strings.TrimRight(field, "`'\")]}>,;:!?.")
+
+	if strings.HasPrefix(field, "@") {
+		return strings.TrimPrefix(field, "@"), true
+	}
+	if strings.Contains(field, "://") {
+		return "", false
+	}
+	if strings.Contains(field, "/") || filepath.Ext(field) != "" {
+		return field, false
+	}
+	return "", false
+}
+
+// workspacePath resolves a mentioned path relative to root, refusing paths
+// that point outside the workspace, whether by .. or through a symlink
+func workspacePath(root, mention string) (string, bool) {
+	path := mention
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	if !ide.WithinWorkspace(root, path) {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return "", false
+	}
+
+	// Paths that don't exist are left for the read to fail on
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil || !ide.WithinWorkspace(realRoot, resolved) {
+			return "", false
+		}
+	}
+	return rel, true
+}
+
+// fenced wraps content in a code fence longer than any backtick run inside it
+func fenced(content string) string {
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	return fmt.Sprintf("%s\n%s\n%s\n", fence, strings.TrimRight(content, "\n"), fence)
+}
@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+// referencesRunner is a runner attaching files within maxTokens
+func referencesRunner(maxTokens int) *Runner {
+	return &Runner{config: &config.Config{FileReferences: config.FileReferences{MaxTokens: maxTokens}}}
+}
+
+// writeWorkspace creates files under a new workspace root and returns it
+func writeWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestReferencedFiles(t *testing.T) {
+	root := writeWorkspace(t, map[string]string{
+		"internal/app.go": "package app\n",
+		"notes.md":        "# Notes\n```go\nx := 1\n```\n",
+		"big.txt":         strings.Repeat("word ", 400),
+		"image.png":       "\x89PNG\x00\x00",
+	})
+
+	tests := []struct {
+		name      string
+		prompt    string
+		maxTokens int
+		want      []string
+		notWant   []string
+	}{
+		{
+			name:   "bare path",
+			prompt: "what does internal/app.go do?",
+			want:   []string{"## Referenced Files", "### internal/app.go", "package app"},
+		},
+		{
+			name:   "explicit mention with punctuation",
+			prompt: "summarize (@notes.md).",
+			want:   []string{"### notes.md", "````\n# Notes"},
+		},
+		{
+			name:    "missing explicit mention is noted",
+			prompt:  "read @missing.go and e.g this",
+			want:    []string{"`missing.go` was mentioned but isn't a readable file"},
+			notWant: []string{"e.g"},
+		},
+		{
+			name:   "binary files are not attached",
+			prompt: "look at image.png",
+			want:   []string{"`image.png` is a binary file"},
+		},
+		{
+			name:      "over the budget",
+			prompt:    "compare internal/app.go with big.txt",
+			maxTokens: 100,
+			want:      []string{"### internal/app.go", "`big.txt` was not attached: it would exceed the 100 token budget"},
+		},
+		{
+			name:    "outside the workspace",
+			prompt:  "read ../secret.txt and /etc/passwd",
+			notWant: []string{"passwd", "secret"},
+		},
+		{
+			name:   "mentioned twice, attached once",
+			prompt: "internal/app.go and @internal/app.go",
+			want:   []string{"### internal/app.go"},
+		},
+		{
+			name:    "URLs are not paths",
+			prompt:  "see https://example.com/docs.html",
+			notWant: []string{"example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxTokens := tt.maxTokens
+			if maxTokens == 0 {
+				maxTokens = config.DefaultFileRefTokens
+			}
+			got := referencesRunner(maxTokens).referencedFiles(tt.prompt, root)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("context doesn't contain %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("context contains %q:\n%s", notWant, got)
+				}
+			}
+			if strings.Count(got, "### internal/app.go") > 1 {
+				t.Errorf("internal/app.go attached more than once:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestReferencedFilesDisabled(t *testing.T) {
+	root := writeWorkspace(t, map[string]string{"app.go": "package app\n"})
+	if got := referencesRunner(-1).referencedFiles("read app.go", root); got != "" {
+		t.Errorf("attached files with file_references disabled:\n%s", got)
+	}
+}
+
+func TestWorkspacePath(t *testing.T) {
+	tests := []struct {
+		mention string
+		want    string
+		ok      bool
+	}{
+		{mention: "a/b.go", want: "a/b.go", ok: true},
+		{mention: "./a/../b.go", want: "b.go", ok: true},
+		{mention: "/work/a/b.go", want: "a/b.go", ok: true},
+		{mention: "/etc/passwd"},
+		{mention: "../b.go"},
+		{mention: "a/../../b.go"},
+		{mention: "."},
+	}
+	for _, tt := range tests {
+		got, ok := workspacePath("/work", tt.mention)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("workspacePath(/work, %q) = %q, %v; want %q, %v", tt.mention, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWorkspacePathRefusesSymlinksOutside(t *testing.T) {
+	outside := writeWorkspace(t, map[string]string{"secret.txt": "hunter2\n"})
+	root := writeWorkspace(t, map[string]string{"app.go": "package app\n"})
+	for link, target := range map[string]string{
+		"secret-link.txt": filepath.Join(outside, "secret.txt"),
+		"outside":         outside,
+		"app-link.go":     filepath.Join(root, "app.go"),
+	} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("can't create symlinks: %v", err)
+		}
+	}
+
+	tests := []struct {
+		mention string
+		ok      bool
+	}{
+		{mention: "secret-link.txt"},
+		{mention: "outside/secret.txt"},
+		{mention: filepath.Join(root, "outside", "secret.txt")},
+		{mention: "app-link.go", ok: true},
+		{mention: "app.go", ok: true},
+	}
+	for _, tt := range tests {
+		if _, ok := workspacePath(root, tt.mention); ok != tt.ok {
+			t.Errorf("workspacePath(%q) ok = %v, want %v", tt.mention, ok, tt.ok)
+		}
+	}
+
+	got := referencesRunner(config.DefaultFileRefTokens).referencedFiles("read @secret-link.txt and outside/secret.txt", root)
+	if strings.Contains(got, "hunter2") {
+		t.Errorf("attached a file outside the workspace:\n%s", got)
+	}
+}
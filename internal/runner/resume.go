@@ -0,0 +1,62 @@
+package runner
+
+import "context"
+
+// WorkerCheckpoint is told about a configured worker's answers (all of its
+// samples, judged when judging runs alongside the workers) as soon as they
+// are in, so an interrupted run can be resumed without asking it again
+type WorkerCheckpoint func(runID, workerID string, results []WorkerResult)
+
+// SetWorkerCheckpoint registers a callback for workers whose every answer
+// succeeded; failed workers are left to run again on resume. Workers run
+// concurrently, so the callback must be safe for concurrent use.
+func (r *Runner) SetWorkerCheckpoint(fn WorkerCheckpoint) {
+	r.checkpoint = fn
+}
+
+// completedWorkersKey is the context key for answers a resumed run reuses
+type completedWorkersKey struct{}
+
+// WithCompletedWorkers returns a context whose run reuses the given answers,
+// keyed by configured worker ID, instead of asking those workers again.
+// Consensus then runs over the reused and the new answers together.
+func WithCompletedWorkers(ctx context.Context, completed map[string][]WorkerResult) context.Context {
+	return context.WithValue(ctx, completedWorkersKey{}, completed)
+}
+
+// completedWorker returns the answers ctx carries for a configured worker
+func completedWorker(ctx context.Context, workerID string) ([]WorkerResult, bool) {
+	completed, _ := ctx.Value(completedWorkersKey{}).(map[string][]WorkerResult)
+	results, ok := completed[workerID]
+	if !ok || len(results) == 0 {
+		return nil, false
+	}
+
+	reused := make([]WorkerResult, len(results))
+	for i, result := range results {
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		if result.WorkerID != workerID {
+			result.Metadata["worker"] = workerID
+		}
+		// Judged before the interruption; don't pay for it twice
+		result.judged = len(result.JudgeResults) > 0 || result.Unscored
+		reused[i] = result
+	}
+	return reused, true
+}
+
+// checkpointWorker reports a worker's answers to the checkpoint callback when
+// they all succeeded
+func (r *Runner) checkpointWorker(ctx context.Context, workerID string, results []WorkerResult) {
+	if r.checkpoint == nil || ctx.Err() != nil {
+		return
+	}
+	for _, result := range results {
+		if result.Error != nil {
+			return
+		}
+	}
+	r.checkpoint(RunIDFrom(ctx), workerID, results)
+}
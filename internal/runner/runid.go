@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// runIDKey is the context key for the run ID
+type runIDKey struct{}
+
+// WithRunID returns a context carrying a run ID. Run uses the ID it finds in
+// its context instead of generating one, so callers can correlate a run with
+// their own IDs (e.g. an HTTP request ID).
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunIDFrom returns the run ID carried by ctx, or "" if there is none
+func RunIDFrom(ctx context.Context) string {
+	runID, _ := ctx.Value(runIDKey{}).(string)
+	return runID
+}
+
+// newRunID returns a short random ID for a run or planning run
+func newRunID() string {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		// Fall back to the clock; IDs only need to tell runs apart
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(id)
+}
@@ -2,10 +2,14 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -22,12 +26,33 @@ import (
 type Runner struct {
 	config          *config.Config
 	providerManager *factories.ProviderManager
+
+	verbose   io.Writer // receives prompt/response traces when set
+	verboseMu sync.Mutex
+
+	recordPrompts bool // keep the assembled prompts on worker and judge results
+
+	seed *int64 // sent with every worker, planner and judge request when set
+
+	judgeObserver func(JudgeEvent) // told when each judge starts and finishes scoring a worker
+
+	checkpoint WorkerCheckpoint // told about each worker's answers as they complete
+
+	judgeSlots chan struct{} // bounds judge calls in flight across runs; nil when unlimited
+
+	preprocessors []PromptPreprocessor // applied in order to every prompt before it reaches the workers
+
+	discardPlans bool // don't save generated plans to plans/*.md
 }
 
 // NewRunner creates a new runner instance
 func NewRunner(cfg *config.Config) (*Runner, error) {
 	factory := factories.NewDefaultFactory()
 	providerManager := factories.NewProviderManager(factory)
+	providerManager.SetBreakerConfig(factories.BreakerConfig{
+		Threshold: max(cfg.Breaker.Threshold, 0),
+		Cooldown:  cfg.Breaker.Cooldown,
+	})
 
 	// Convert config providers to provider configs
 	providerConfigs := make(map[string]provider.ProviderConfig)
@@ -39,36 +64,151 @@ func NewRunner(cfg *config.Config) (*Runner, error) {
 			Host:    configProvider.Host,
 			APIKey:  configProvider.APIKey,
 			Timeout: cfg.Consensus.Timeout,
+			Options: map[string]string{
+				"prompt_caching":   strconv.FormatBool(configProvider.PromptCaching),
+				"max_tokens_param": configProvider.MaxTokensParam,
+			},
+		}
+		if configProvider.SendTemperature != nil {
+			providerConfigs[name].Options["send_temperature"] = strconv.FormatBool(*configProvider.SendTemperature)
+		}
+		if configProvider.StructuredOutputs != nil {
+			providerConfigs[name].Options["structured_outputs"] = strconv.FormatBool(*configProvider.StructuredOutputs)
+		}
+		if configProvider.StreamBufferBytes > 0 {
+			providerConfigs[name].Options["stream_buffer_bytes"] = strconv.Itoa(configProvider.StreamBufferBytes)
+		}
+		if configProvider.EmbeddingModel != "" {
+			providerConfigs[name].Options["embedding_model"] = configProvider.EmbeddingModel
 		}
 	}
 
-	// Create all providers
+	// Create all providers, reporting every one that fails and what uses it
 	if err := providerManager.CreateProviders(providerConfigs); err != nil {
-		return nil, fmt.Errorf("failed to create providers: %w", err)
+		return nil, providerFailures(cfg, providerManager.CreationErrors())
 	}
 
-	return &Runner{
+	// Providers on the same account share its rate limit
+	for name, configProvider := range cfg.Providers {
+		shareKey := name
+		if configProvider.APIKey != "" {
+			shareKey = configProvider.Kind + "|" + configProvider.BaseURL + "|" + configProvider.APIKey
+		}
+		providerManager.SetRateLimit(name, shareKey, configProvider.RequestsPerMinute)
+	}
+
+	warmUpProviders(cfg, providerManager)
+
+	runner := &Runner{
 		config:          cfg,
 		providerManager: providerManager,
-	}, nil
+	}
+	if cfg.Consensus.JudgeConcurrency > 0 {
+		runner.judgeSlots = make(chan struct{}, cfg.Consensus.JudgeConcurrency)
+	}
+	for _, name := range cfg.Prompt.Preprocessors {
+		preprocessor, err := runner.builtinPreprocessor(name)
+		if err != nil {
+			return nil, err
+		}
+		runner.AddPreprocessor(preprocessor)
+	}
+
+	return runner, nil
+}
+
+// warmUpProviders opens connections in the background for providers with
+// preflight enabled; failures are ignored since the real request will retry
+func warmUpProviders(cfg *config.Config, providerManager *factories.ProviderManager) {
+	for name, configProvider := range cfg.Providers {
+		if !configProvider.Preflight {
+			continue
+		}
+
+		prov, err := providerManager.GetProvider(name)
+		if err != nil {
+			continue
+		}
+		if warmer, ok := prov.(provider.Warmer); ok {
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				warmer.Warm(ctx)
+			}()
+		}
+	}
+}
+
+// providerFailures explains the providers that couldn't be created: why, and
+// which workers and judges need them
+func providerFailures(cfg *config.Config, failed map[string]error) error {
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+	for _, name := range names {
+		var users []string
+		for _, worker := range cfg.Workers {
+			if worker.Provider == name {
+				users = append(users, "worker "+worker.ID)
+			} else if worker.FallbackProvider == name {
+				users = append(users, "worker "+worker.ID+" as fallback")
+			}
+		}
+		for _, judge := range cfg.Judges {
+			if judge.Provider == name {
+				users = append(users, "judge "+judge.ID)
+			}
+		}
+		if cfg.Consensus.EmbeddingProvider == name {
+			users = append(users, "consensus.embedding_provider")
+		}
+		if cfg.Consensus.EscalationProvider == name {
+			users = append(users, "consensus.escalation_provider")
+		}
+		usedBy := "unused"
+		if len(users) > 0 {
+			usedBy = "used by " + strings.Join(users, ", ")
+		}
+		errs = append(errs, fmt.Errorf("  provider %s (%s): %w", name, usedBy, failed[name]))
+	}
+	return fmt.Errorf("failed to create providers:\n%w", errors.Join(errs...))
 }
 
 // Run executes the prompt across all configured workers
 func (r *Runner) Run(ctx context.Context, prompt string) (*RunResult, error) {
 	startTime := time.Now()
 
+	// Everything this run logs or traces carries its ID
+	runID := RunIDFrom(ctx)
+	if runID == "" {
+		runID = newRunID()
+		ctx = WithRunID(ctx, runID)
+	}
+
 	result := &RunResult{
+		RunID:     runID,
 		Prompt:    prompt,
 		Workers:   make([]WorkerResult, 0, len(r.config.Workers)),
 		StartTime: startTime,
 	}
 
-	// Create a context with timeout
+	// The consensus timeout caps the whole run; each phase also has its own budget
 	runCtx, cancel := context.WithTimeout(ctx, r.config.Consensus.Timeout)
 	defer cancel()
 
-	// Fan out to all workers concurrently
-	workerResults, err := r.runWorkers(runCtx, prompt)
+	prepared, err := r.preparePrompt(runCtx, prompt, nil)
+	if err != nil {
+		result.EndTime = time.Now()
+		result.TotalDuration = result.EndTime.Sub(result.StartTime)
+		return result, err
+	}
+
+	// Fan out to all workers concurrently, with whatever context the preprocessors added
+	workerResults, err := r.runWorkers(runCtx, prepared.Text, prepared.Context)
 	if err != nil {
 		result.Success = false
 		result.EndTime = time.Now()
@@ -81,8 +221,11 @@ func (r *Runner) Run(ctx context.Context, prompt string) (*RunResult, error) {
 	// Calculate aggregate stats
 	r.calculateAggregateStats(result)
 
-	// Run consensus algorithm
-	consensus, err := r.runConsensus(runCtx, workerResults, prompt)
+	// Run consensus algorithm, judging whatever the pipeline didn't within the judging budget;
+	// judges see the preprocessed prompt the workers answered
+	consensusCtx, cancelConsensus := r.judgingContext(runCtx)
+	defer cancelConsensus()
+	consensus, err := r.runConsensus(consensusCtx, workerResults, prepared.Text)
 	if err != nil {
 		// Even if consensus fails, we still return the worker results
 		result.Success = false
@@ -91,29 +234,131 @@ func (r *Runner) Run(ctx context.Context, prompt string) (*RunResult, error) {
 		return result, fmt.Errorf("consensus failed: %w", err)
 	}
 
+	// Low agreement gets a second round of answers first, when configured,
+	// with a budget of its own rather than what is left of the run's
+	workerResults, consensus = r.escalateLowConfidence(ctx, workerResults, consensus, prepared)
+	result.Workers = workerResults
+	r.calculateAggregateStats(result)
+
 	result.Consensus = consensus
-	result.Success = true
+	result.Success = !r.flagLowConfidence(consensus)
 	result.EndTime = time.Now()
 	result.TotalDuration = result.EndTime.Sub(result.StartTime)
 
 	return result, nil
 }
 
-// runWorkers executes the prompt across all workers concurrently
-func (r *Runner) runWorkers(ctx context.Context, prompt string) ([]WorkerResult, error) {
+// flagLowConfidence marks a consensus whose confidence is below
+// consensus.min_confidence, whichever algorithm produced it, and says why in
+// its reasoning. It reports whether the consensus was flagged.
+func (r *Runner) flagLowConfidence(consensus *Consensus) bool {
+	threshold := r.config.Consensus.MinConfidence
+	if threshold <= 0 || consensus.Confidence >= threshold {
+		return false
+	}
+
+	consensus.LowConfidence = true
+	note := fmt.Sprintf("low consensus confidence: %.2f is below min_confidence %.2f; review the answer before relying on it", consensus.Confidence, threshold)
+	if consensus.Reasoning != "" {
+		note = consensus.Reasoning + " (" + note + ")"
+	}
+	consensus.Reasoning = note
+	return true
+}
+
+// RunWorker sends the prompt to a single worker, skipping the fan-out and
+// consensus; the worker's answer is returned as the consensus content
+func (r *Runner) RunWorker(ctx context.Context, workerID, prompt string) (*RunResult, error) {
+	worker, err := r.config.GetWorkerByID(workerID)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+
+	// Everything this run logs or traces carries its ID
+	runID := RunIDFrom(ctx)
+	if runID == "" {
+		runID = newRunID()
+		ctx = WithRunID(ctx, runID)
+	}
+
+	result := &RunResult{
+		RunID:     runID,
+		Prompt:    prompt,
+		StartTime: startTime,
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, r.config.Consensus.Timeout)
+	defer cancel()
+
+	prepared, err := r.preparePrompt(runCtx, prompt, nil)
+	if err != nil {
+		return result, err
+	}
+
+	// One answer is all that's shown, so don't pay for the worker's other samples
+	single := *worker
+	single.Samples = 0
+	workerResult := r.runSingleWorker(runCtx, single, prepared.Text, prepared.Context)
+	result.Workers = []WorkerResult{workerResult}
+	r.calculateAggregateStats(result)
+	result.EndTime = time.Now()
+	result.TotalDuration = result.EndTime.Sub(result.StartTime)
+
+	if workerResult.Error != nil {
+		result.Success = false
+		return result, fmt.Errorf("worker %s failed: %w", workerID, workerResult.Error)
+	}
+
+	result.Consensus = &Consensus{
+		Algorithm:    "single",
+		Winner:       workerID,
+		Content:      workerResult.Content,
+		Confidence:   1.0,
+		Reasoning:    fmt.Sprintf("Answered by %s alone, without consensus", workerID),
+		Participants: 1,
+	}
+	result.Success = true
+
+	return result, nil
+}
+
+// runWorkers executes the prompt across all workers concurrently; fileContext
+// holds the referenced files sent ahead of the prompt
+func (r *Runner) runWorkers(ctx context.Context, prompt, fileContext string) ([]WorkerResult, error) {
 	g, ctx := errgroup.WithContext(ctx)
-	results := make([]WorkerResult, len(r.config.Workers))
-	var mu sync.Mutex
+	// Each goroutine writes only its own index and g.Wait orders those writes
+	// before the slice is read, so no lock is needed
+	results := make([][]WorkerResult, len(r.config.Workers))
+
+	// Judges can start on a worker as soon as it finishes instead of waiting for the slowest one
+	pipelineJudges := r.pipelinesJudges()
 
 	for i, worker := range r.config.Workers {
 		i, worker := i, worker // Capture loop variables
 
+		// A resumed run already has this worker's answers
+		if completed, ok := completedWorker(ctx, worker.ID); ok {
+			results[i] = completed
+			continue
+		}
+
 		g.Go(func() error {
-			result := r.runSingleWorker(ctx, worker, prompt)
+			workerCtx, cancelWorker := context.WithTimeout(ctx, r.config.Consensus.WorkerTimeout)
+			samples := r.sampleWorker(workerCtx, worker, prompt, fileContext)
+			cancelWorker()
+
+			for j := range samples {
+				if pipelineJudges && samples[j].Error == nil && samples[j].Content != "" {
+					judgeCtx, cancelJudge := r.judgingContext(ctx)
+					r.judgeWorker(judgeCtx, &samples[j], prompt)
+					cancelJudge()
+				}
+			}
 
-			mu.Lock()
-			results[i] = result
-			mu.Unlock()
+			results[i] = samples
+			r.checkpointWorker(ctx, worker.ID, samples)
 
 			return nil // Don't fail the group if one worker fails
 		})
@@ -123,20 +368,111 @@ func (r *Runner) runWorkers(ctx context.Context, prompt string) ([]WorkerResult,
 		return nil, err
 	}
 
-	return results, nil
+	return slices.Concat(results...), nil
+}
+
+// judgingContext bounds judging by the judging budget, and by ctx's own
+// deadline (the overall run cap) when that comes first
+func (r *Runner) judgingContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.config.Consensus.JudgingTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.config.Consensus.JudgingTimeout)
 }
 
-// runSingleWorker executes the prompt on a single worker
-func (r *Runner) runSingleWorker(ctx context.Context, worker config.Worker, prompt string) WorkerResult {
+// pipelinesJudges reports whether workers are judged as soon as they answer,
+// rather than by the consensus algorithm once all of them have
+func (r *Runner) pipelinesJudges() bool {
+	return r.config.Consensus.Algorithm == "score_top1" && len(r.config.Judges) > 0
+}
+
+// runSingleWorker executes the prompt on a single worker, retrying it on the
+// worker's fallback provider when the primary one is down, rate limited or
+// stalls. A fallback answer records the primary provider and its error.
+func (r *Runner) runSingleWorker(ctx context.Context, worker config.Worker, prompt, fileContext string) WorkerResult {
+	result := r.askWorker(ctx, worker, prompt, fileContext)
+	if worker.FallbackProvider == "" || !shouldFallback(result.Error) || ctx.Err() != nil {
+		return result
+	}
+
+	fallback := worker
+	fallback.Provider = worker.FallbackProvider
+	fallbackResult := r.askWorker(ctx, fallback, prompt, fileContext)
+	fallbackResult.Metadata["fallback_from"] = worker.Provider
+	fallbackResult.Metadata["primary_error"] = result.Error.Error()
+
+	return fallbackResult
+}
+
+// workerSystemPrompt puts the configured workers_system_prelude in front of a
+// worker's own system prompt and workers_system_suffix after it, separated
+// by blank lines; empty parts are left out
+func (r *Runner) workerSystemPrompt(own string) string {
+	var parts []string
+	for _, part := range []string{strings.TrimSpace(r.config.WorkersSystemPrelude), own, strings.TrimSpace(r.config.WorkersSystemSuffix)} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// rawOptions merges a provider's raw_options with a worker's; the worker's
+// values win for keys set in both
+func (r *Runner) rawOptions(providerName string, workerOptions map[string]interface{}) map[string]interface{} {
+	providerOptions := r.config.Providers[providerName].RawOptions
+	if len(providerOptions) == 0 && len(workerOptions) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]interface{}, len(providerOptions)+len(workerOptions))
+	for key, value := range providerOptions {
+		merged[key] = value
+	}
+	for key, value := range workerOptions {
+		merged[key] = value
+	}
+	return merged
+}
+
+// shouldFallback reports whether an error means the provider is temporarily
+// unable to serve, rather than the request itself being bad
+func shouldFallback(err error) bool {
+	var provErr *provider.ProviderError
+	if !errors.As(err, &provErr) {
+		return false
+	}
+
+	switch provErr.Type {
+	case provider.ErrorTypeRateLimit, provider.ErrorTypeServerError,
+		provider.ErrorTypeNetwork, provider.ErrorTypeUnavailable, provider.ErrorTypeTimeout:
+		return true
+	}
+	return false
+}
+
+// askWorker sends the prompt to the worker's provider and collects the response
+func (r *Runner) askWorker(ctx context.Context, worker config.Worker, prompt, fileContext string) WorkerResult {
 	result := WorkerResult{
 		WorkerID: worker.ID,
 		Metadata: make(map[string]interface{}),
 	}
+	if len(worker.Tags) > 0 {
+		result.Metadata["tags"] = worker.Tags
+	}
 
 	// Get the provider for this worker
+	// An unavailable provider already says which and why; every worker on it
+	// reports the same reason
 	prov, err := r.providerManager.GetProvider(worker.Provider)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to get provider %s: %w", worker.Provider, err)
+		result.Error = err
+		return result
+	}
+
+	// Fail fast instead of waiting out the timeout on a provider that keeps failing
+	if err := r.providerManager.Allow(worker.Provider); err != nil {
+		result.Error = err
 		return result
 	}
 
@@ -144,8 +480,26 @@ func (r *Runner) runSingleWorker(ctx context.Context, worker config.Worker, prom
 	opts := provider.Options{
 		Temperature:  worker.Temperature,
 		MaxTokens:    worker.MaxTokens,
-		SystemPrompt: worker.SystemPrompt,
+		SystemPrompt: r.workerSystemPrompt(worker.SystemPrompt),
 		Stream:       true, // Always use streaming for better UX
+		Context:      fileContext,
+		RawOptions:   r.rawOptions(worker.Provider, worker.RawOptions),
+		Seed:         r.seed,
+
+		MaxResponseBytes: max(r.config.Consensus.MaxResponseBytes, 0),
+	}
+	if sampler, ok := prov.(provider.MultiSampler); ok && sampler.SupportsN() && worker.Samples > 1 {
+		opts.N = worker.Samples
+	}
+	if worker.ResponseSchema != nil {
+		opts.ResponseSchema = worker.ResponseSchema
+		if enforcer, ok := prov.(provider.SchemaEnforcer); !ok || !enforcer.EnforcesResponseSchema() {
+			opts.SystemPrompt = strings.TrimSpace(opts.SystemPrompt + "\n\n" + schemaInstructions(worker.ResponseSchema))
+		}
+	}
+	defer func() { r.traceWorker(RunIDFrom(ctx), worker, prompt, opts, result) }()
+	if r.recordPrompts {
+		result.Metadata["assembled_prompt"] = assemblePrompt(opts, prompt)
 	}
 
 	// Create stats tracking
@@ -155,23 +509,41 @@ func (r *Runner) runSingleWorker(ctx context.Context, worker config.Worker, prom
 		StartTime: time.Now(),
 	}
 
+	// Cancelling on return stops the provider if the collector gives up early
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Queue behind other workers sharing this provider's rate limit
+	if err := r.providerManager.Wait(reqCtx, worker.Provider); err != nil {
+		r.providerManager.Release(worker.Provider)
+		result.Error = fmt.Errorf("gave up waiting for rate limit: %w", err)
+		result.Stats = stats
+		return result
+	}
+
 	// Execute the request
-	responseChan, err := prov.Ask(ctx, prompt, opts)
+	responseChan, err := prov.Ask(reqCtx, prompt, opts)
 	if err != nil {
+		r.providerManager.RecordResult(worker.Provider, err)
 		result.Error = fmt.Errorf("failed to ask provider: %w", err)
 		result.Stats = stats
 		return result
 	}
 
-	// Collect the streaming response
+	// Collect the streaming response, abandoning streams that stall
 	collector := provider.NewStreamCollector()
-	collector.Collect(ctx, responseChan)
+	collector.IdleTimeout = r.config.Consensus.IdleTimeout
+	collector.MaxBytes = max(r.config.Consensus.MaxResponseBytes, 0)
+	collector.Stats.Provider = prov.GetName()
+	collector.Collect(reqCtx, responseChan)
 
 	// Populate result
 	result.Content = collector.Content
 	result.TokensUsed = collector.TokensUsed
 	result.Error = collector.Error
 	result.Stats = collector.Stats
+	r.providerManager.RecordResult(worker.Provider, result.Error)
+	failIfBlank(&result, prov.GetName())
 
 	// If we don't have token usage from the API, estimate it
 	if result.TokensUsed == nil && result.Error == nil && result.Content != "" {
@@ -206,14 +578,46 @@ func (r *Runner) runSingleWorker(ctx context.Context, worker config.Worker, prom
 		result.Stats.EstimatedCost = provider.EstimateCost(prov.GetModel(), result.TokensUsed)
 	}
 
+	// Hold structured answers to the schema; a mismatch fails the worker
+	checkStructured(worker, &result)
+
 	// Add metadata
+	result.Metadata["provider"] = worker.Provider
 	result.Metadata["provider_kind"] = r.config.Providers[worker.Provider].Kind
 	result.Metadata["temperature"] = worker.Temperature
 	result.Metadata["max_tokens"] = worker.MaxTokens
+	if result.Stats.FinishReason != "" {
+		result.Metadata["finish_reason"] = result.Stats.FinishReason
+	}
+	if result.Stats.RequestID != "" {
+		result.Metadata["request_id"] = result.Stats.RequestID
+	}
+
+	// Completions past the first, when the worker is sampled with n
+	if len(collector.Extra) > 0 && collector.Error == nil {
+		result.samples = splitSamples(worker, &result, collector.Extra)
+	}
 
 	return result
 }
 
+// failIfBlank fails a result whose answer is empty or only whitespace, which
+// some models return under load, so it can't win consensus
+func failIfBlank(result *WorkerResult, providerName string) {
+	if result.Error != nil || strings.TrimSpace(result.Content) != "" {
+		return
+	}
+
+	result.Error = &provider.ProviderError{
+		Provider:  providerName,
+		Type:      provider.ErrorTypeServerError,
+		Message:   "empty response",
+		RequestID: result.Stats.RequestID,
+	}
+	result.Stats.Error = result.Error
+	result.Stats.Success = false
+}
+
 // calculateAggregateStats calculates totals across all workers
 func (r *Runner) calculateAggregateStats(result *RunResult) {
 	var totalTokens int
@@ -233,10 +637,11 @@ func (r *Runner) calculateAggregateStats(result *RunResult) {
 }
 
 // savePlanToFile saves the generated plan to a markdown file
-func (r *Runner) savePlanToFile(prompt, planContent string) error {
-	// Create a filename based on timestamp
+func (r *Runner) savePlanToFile(prompt, runID, planContent string) error {
+	// Create a filename based on timestamp; the run ID keeps plans saved
+	// within the same second from overwriting each other
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := fmt.Sprintf("plan_%s.md", timestamp)
+	filename := fmt.Sprintf("plan_%s_%s.md", timestamp, runID)
 
 	// Create plans directory if it doesn't exist
 	plansDir := "plans"
@@ -251,6 +656,8 @@ func (r *Runner) savePlanToFile(prompt, planContent string) error {
 
 **Generated:** %s
 
+**Run ID:** %s
+
 **Request:** %s
 
 ---
@@ -258,23 +665,65 @@ func (r *Runner) savePlanToFile(prompt, planContent string) error {
 %s
 `,
 		time.Now().Format("2006-01-02 15:04:05"),
+		runID,
 		prompt,
 		planContent)
 
 	// Write to file
-	if err := os.WriteFile(filepath, []byte(markdownContent), 0644); err != nil {
+	if err := writeFileAtomic(filepath, []byte(markdownContent), 0644); err != nil {
 		return fmt.Errorf("failed to write plan file: %w", err)
 	}
 
-	fmt.Printf("📋 Plan saved to: %s\n", filepath)
+	fmt.Fprintf(os.Stderr, "📋 Plan saved to: %s\n", filepath)
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so an interrupted write never leaves a truncated file behind
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Removing after a successful rename is a harmless no-op
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
 // Close cleans up the runner and its resources
 func (r *Runner) Close() error {
 	return r.providerManager.CloseAll()
 }
 
+// AddInterceptor wraps every provider request, worker, planner and judge
+// alike, in interceptor; see provider.Interceptor. Add interceptors before
+// starting runs.
+func (r *Runner) AddInterceptor(interceptor provider.Interceptor) {
+	r.providerManager.Use(interceptor)
+}
+
+// SetSeed sends seed with every worker, planner and judge request, so
+// providers that support seeding return the same answers for the same prompt
+func (r *Runner) SetSeed(seed int64) {
+	r.seed = &seed
+}
+
 // GetStats returns current runner statistics
 func (r *Runner) GetStats() map[string]interface{} {
 	return map[string]interface{}{
@@ -285,26 +734,36 @@ func (r *Runner) GetStats() map[string]interface{} {
 	}
 }
 
-// GeneratePlan uses the configured workers to generate a plan for the given prompt
-func (r *Runner) GeneratePlan(prompt string, ideContext interface{}) (*PlanResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.Consensus.Timeout)
+// SetSavePlans turns saving generated plans to plans/*.md on or off; it's on
+// by default
+func (r *Runner) SetSavePlans(save bool) {
+	r.discardPlans = !save
+}
+
+// GeneratePlan uses the planning worker to generate a plan for the given
+// prompt. The plan takes the run ID carried by ctx, if any.
+func (r *Runner) GeneratePlan(ctx context.Context, prompt string, ideContext interface{}) (*PlanResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Consensus.Timeout)
 	defer cancel()
 
-	// Use the first worker to generate the plan
+	// Plan with the configured planning worker (the first worker unless set)
 	if len(r.config.Workers) == 0 {
 		return nil, fmt.Errorf("no workers configured")
 	}
 
-	worker := r.config.Workers[0]
+	worker := r.config.Planner()
 
 	// Get the provider for this worker
 	prov, err := r.providerManager.GetProvider(worker.Provider)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get provider %s: %w", worker.Provider, err)
+		return nil, fmt.Errorf("planner %s: %w", worker.ID, err)
 	}
 
-	// Build comprehensive context
-	contextInfo := r.buildProjectContext(ideContext)
+	// Project context and referenced files come from the preprocessors
+	prepared, err := r.preparePrompt(ctx, prompt, ideContext)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create a planning-specific prompt with project context
 	planningPrompt := fmt.Sprintf(`Please analyze the following request and create a comprehensive implementation plan:
@@ -312,9 +771,6 @@ func (r *Runner) GeneratePlan(prompt string, ideContext interface{}) (*PlanResul
 ## Request
 %s
 
-## Project Context
-%s
-
 ## Instructions
 Create a detailed implementation plan with:
 1. **Analysis**: What needs to be done and why (considering current project state)
@@ -330,14 +786,23 @@ Create a detailed implementation plan with:
 - End your response with a clear "## Action Items" section containing specific, actionable todos
 - Each action item should be a single, concrete task that can be completed
 
-Format your response as a clear, structured markdown plan.`, prompt, contextInfo)
+Format your response as a clear, structured markdown plan.`, prepared.Text)
 
 	// Set up options for the provider
 	opts := provider.Options{
 		Temperature:  0.3, // Lower temperature for more consistent planning
 		MaxTokens:    worker.MaxTokens,
-		SystemPrompt: "You are a helpful coding assistant that creates detailed implementation plans. Always provide structured, actionable plans in markdown format.",
+		SystemPrompt: r.workerSystemPrompt("You are a helpful coding assistant that creates detailed implementation plans. Always provide structured, actionable plans in markdown format."),
 		Stream:       false, // Don't stream for planning
+		Context:      prepared.Context, // Sent separately so providers can cache it
+		RawOptions:   r.rawOptions(worker.Provider, worker.RawOptions),
+		Seed:         r.seed,
+
+		MaxResponseBytes: max(r.config.Consensus.MaxResponseBytes, 0),
+	}
+
+	if err := r.providerManager.Wait(ctx, worker.Provider); err != nil {
+		return nil, fmt.Errorf("gave up waiting for rate limit: %w", err)
 	}
 
 	// Execute the request
@@ -348,6 +813,7 @@ Format your response as a clear, structured markdown plan.`, prompt, contextInfo
 
 	// Collect the response
 	collector := provider.NewStreamCollector()
+	collector.MaxBytes = max(r.config.Consensus.MaxResponseBytes, 0)
 	collector.Collect(ctx, responseChan)
 
 	if collector.Error != nil {
@@ -357,10 +823,17 @@ Format your response as a clear, structured markdown plan.`, prompt, contextInfo
 	// Extract todos from the generated plan
 	todos := r.extractTodosFromPlan(collector.Content)
 
+	runID := RunIDFrom(ctx)
+	if runID == "" {
+		runID = newRunID()
+	}
+
 	// Save the plan to a markdown file
-	if err := r.savePlanToFile(prompt, collector.Content); err != nil {
-		// Log the error but don't fail the planning process
-		fmt.Printf("Warning: Could not save plan to file: %v\n", err)
+	if !r.discardPlans {
+		if err := r.savePlanToFile(prompt, runID, collector.Content); err != nil {
+			// Log the error but don't fail the planning process
+			fmt.Fprintf(os.Stderr, "Warning: run %s: could not save plan to file: %v\n", runID, err)
+		}
 	}
 
 	// Create enhanced steps from todos
@@ -368,6 +841,8 @@ Format your response as a clear, structured markdown plan.`, prompt, contextInfo
 
 	// Create a structured plan result
 	plan := &PlanResult{
+		Prompt:       prompt,
+		RunID:        runID,
 		TargetFile:   r.extractTargetFileFromContext(ideContext),
 		Steps:        planSteps,
 		SelectedPlan: prov.GetModel(),
@@ -391,7 +866,7 @@ func (r *Runner) buildProjectContext(ideContext interface{}) string {
 	if ctx, ok := ideContext.(*ide.IDEContext); ok {
 		// Active file information
 		if ctx.ActiveFile != "" {
-			contextParts = append(contextParts, fmt.Sprintf("**Active File**: %s", ctx.ActiveFile))
+			contextParts = append(contextParts, fmt.Sprintf("**Active File**: %s", ctx.DisplayPath(ctx.ActiveFile)))
 		}
 
 		// Selected text information
@@ -407,23 +882,25 @@ func (r *Runner) buildProjectContext(ideContext interface{}) string {
 
 		// Open files
 		if len(ctx.OpenFiles) > 0 {
-			openFilesStr := strings.Join(ctx.OpenFiles, ", ")
-			if len(openFilesStr) > 200 { // Truncate if too long
-				openFilesStr = openFilesStr[:200] + "..."
+			openFiles := make([]string, len(ctx.OpenFiles))
+			for i, file := range ctx.OpenFiles {
+				openFiles[i] = ctx.DisplayPath(file)
 			}
+			openFilesStr := joinWithinBudget(openFiles, r.config.Ide.Context.MaxOpenFiles, r.config.Ide.Context.OpenFilesTokens)
 			contextParts = append(contextParts, fmt.Sprintf("**Open Files**: %s", openFilesStr))
 		}
 
-		// Diagnostics (errors/warnings)
+		// Diagnostics (errors/warnings), most recent last
 		if len(ctx.Diagnostics) > 0 {
 			var diagStrings []string
-			for i, diag := range ctx.Diagnostics {
-				if i >= 5 { // Limit to first 5 diagnostics
-					diagStrings = append(diagStrings, "...")
-					break
-				}
+			diagnostics := ctx.Diagnostics
+			if limit := r.config.Ide.Context.MaxDiagnostics; limit > 0 && len(diagnostics) > limit {
+				diagStrings = append(diagStrings, fmt.Sprintf("... (%d earlier)", len(diagnostics)-limit))
+				diagnostics = diagnostics[len(diagnostics)-limit:]
+			}
+			for _, diag := range diagnostics {
 				diagStrings = append(diagStrings, fmt.Sprintf("- %s:%d: [%s] %s", 
-					diag.File, diag.Line, diag.Severity, diag.Message))
+					ctx.DisplayPath(diag.File), diag.Line, diag.Severity, diag.Message))
 			}
 			if len(diagStrings) > 0 {
 				contextParts = append(contextParts, fmt.Sprintf("**Current Issues**:\n%s", strings.Join(diagStrings, "\n")))
@@ -438,6 +915,23 @@ func (r *Runner) buildProjectContext(ideContext interface{}) string {
 	return strings.Join(contextParts, "\n\n")
 }
 
+// joinWithinBudget joins at most maxItems whole items, stopping early when the
+// next one would exceed the token budget, so entries (and the runes inside
+// them) are never cut in half
+func joinWithinBudget(items []string, maxItems, budget int) string {
+	var kept []string
+	used := 0
+	for _, item := range items {
+		cost := provider.EstimateTokensSimple(item + ", ")
+		if len(kept) > 0 && (used+cost > budget || (maxItems > 0 && len(kept) >= maxItems)) {
+			return strings.Join(kept, ", ") + fmt.Sprintf(", ... (%d more)", len(items)-len(kept))
+		}
+		kept = append(kept, item)
+		used += cost
+	}
+	return strings.Join(kept, ", ")
+}
+
 // extractTodosFromPlan extracts action items from the generated plan
 func (r *Runner) extractTodosFromPlan(planContent string) []string {
 	var todos []string
@@ -536,17 +1030,78 @@ func (r *Runner) extractTargetFileFromContext(ideContext interface{}) string {
 
 	if ctx, ok := ideContext.(*ide.IDEContext); ok {
 		if ctx.ActiveFile != "" {
-			return ctx.ActiveFile
+			return ctx.DisplayPath(ctx.ActiveFile)
 		}
 	}
 	
 	return "based on context"
 }
 
+// fileBlockInstructions tells workers how to propose file edits so extractDiffs can find them
+const fileBlockInstructions = `When a file needs to change, output its complete new contents in a fenced code block whose info string is the language followed by the file path relative to the workspace root, for example:
+` + "```" + `go internal/example/example.go
+package example
+` + "```"
+
+// planRunContext carries the run ID of executing part of a plan: the plan's
+// run ID with part appended, which ties the execution to the plan in logs
+// while each execution keeps traces and checkpoints of its own
+func planRunContext(plan *PlanResult, part string) context.Context {
+	if plan.RunID == "" {
+		return context.Background()
+	}
+	return WithRunID(context.Background(), plan.RunID+"-"+part)
+}
+
+// ExecutePlanStep runs a single step of the plan, so callers can pause,
+// skip or stop between steps. Workers see the whole plan for context but are
+// asked to carry out only this step.
+func (r *Runner) ExecutePlanStep(plan *PlanResult, index int, ideContext interface{}) (*RunResult, error) {
+	if index < 0 || index >= len(plan.Steps) {
+		return nil, fmt.Errorf("plan has no step %d", index+1)
+	}
+	step := plan.Steps[index]
+
+	var outline strings.Builder
+	for i, s := range plan.Steps {
+		marker := " "
+		switch {
+		case i < index:
+			marker = "done"
+		case i == index:
+			marker = "now"
+		}
+		fmt.Fprintf(&outline, "%d. [%s] %s\n", s.Number, marker, s.Title)
+	}
+
+	stepPrompt := fmt.Sprintf(`You are carrying out a plan one step at a time.
+
+Plan:
+%s
+Steps:
+%s
+Carry out only step %d: %s
+Earlier steps are already done; leave later steps for later.
+
+`, plan.SelectedPlan, outline.String(), step.Number, step.Title) + fileBlockInstructions
+
+	// Run applies the consensus timeout and phase budgets itself
+	result, err := r.Run(planRunContext(plan, fmt.Sprintf("step%d", step.Number)), stepPrompt)
+	if err != nil {
+		return result, err
+	}
+
+	if result.Consensus != nil {
+		result.Diffs = r.extractDiffs(result.Consensus.Content, ideContext)
+	}
+
+	return result, nil
+}
+
 // ExecutePlan executes the given plan using the configured workers
 func (r *Runner) ExecutePlan(plan *PlanResult, ideContext interface{}) (*RunResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.config.Consensus.Timeout)
-	defer cancel()
+	// Run applies the consensus timeout and phase budgets itself
+	ctx := planRunContext(plan, "exec")
 
 	// Create an execution prompt based on the plan
 	executionPrompt := fmt.Sprintf(`Execute the following plan:
@@ -555,8 +1110,20 @@ Plan: %s
 
 Reasoning: %s
 
-Please implement the solution step by step.`, plan.SelectedPlan, plan.Reasoning)
+Please implement the solution step by step.
+
+`, plan.SelectedPlan, plan.Reasoning) + fileBlockInstructions
 
 	// Use the existing Run method to execute the plan
-	return r.Run(ctx, executionPrompt)
+	result, err := r.Run(ctx, executionPrompt)
+	if err != nil {
+		return result, err
+	}
+
+	// Collect proposed edits so the caller can review them before anything is written
+	if result.Consensus != nil {
+		result.Diffs = r.extractDiffs(result.Consensus.Content, ideContext)
+	}
+
+	return result, nil
 }
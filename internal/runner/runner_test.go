@@ -0,0 +1,425 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/ide"
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// fakeOpenAI serves /chat/completions like the OpenAI API: streamed requests
+// (workers) get answer after delay, others (judges) a score of 8
+func fakeOpenAI(t *testing.T, answer string, delay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"choices": []map[string]interface{}{{"delta": map[string]string{"content": answer}}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			fmt.Fprintf(w, "data: [DONE]\n\n")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": `{"score": 8, "reason": "fine"}`}}},
+			"usage":   map[string]int{"prompt_tokens": 5, "completion_tokens": 3, "total_tokens": 8},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// loadTestConfig loads a devgru.yaml holding yamlText, with a test API key
+func loadTestConfig(t *testing.T, yamlText string) *config.Config {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	path := filepath.Join(t.TempDir(), "devgru.yaml")
+	if err := os.WriteFile(path, []byte(yamlText), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return cfg
+}
+
+// newTestRunner creates a runner from yamlText
+func newTestRunner(t *testing.T, yamlText string) *Runner {
+	t.Helper()
+	r, err := NewRunner(loadTestConfig(t, yamlText))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestAskWorkerReleasesBreakerTrialWhenWaitFails(t *testing.T) {
+	server := fakeOpenAI(t, "answer", 0)
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+    requests_per_minute: 1
+workers:
+  - id: worker
+    provider: openai
+consensus:
+  algorithm: majority
+circuit_breaker:
+  threshold: 1
+  cooldown: 10ms
+`, server.URL))
+
+	// Trip the breaker and let the cooldown pass, so the next request is the trial
+	r.providerManager.RecordResult("openai", &provider.ProviderError{Provider: "openai", Type: provider.ErrorTypeNetwork, Message: "down"})
+	time.Sleep(20 * time.Millisecond)
+
+	// Spend the only request the rate limit allows, so the worker has to wait
+	if err := r.providerManager.Wait(context.Background(), "openai"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result := r.askWorker(ctx, r.config.Workers[0], "prompt", "")
+	if result.Error == nil {
+		t.Fatal("worker succeeded, want it to give up waiting for the rate limit")
+	}
+
+	if err := r.providerManager.Allow("openai"); err != nil {
+		t.Fatalf("provider still blocked after the trial was abandoned: %v", err)
+	}
+}
+
+func TestJudgeAttemptKeepsBreakerTrialWhenNoSlotFrees(t *testing.T) {
+	server := fakeOpenAI(t, "answer", 0)
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+workers:
+  - id: worker
+    provider: openai
+judges:
+  - id: judge
+    provider: openai
+    system_prompt: Score the answer.
+consensus:
+  algorithm: score_top1
+  judge_concurrency: 1
+circuit_breaker:
+  threshold: 1
+  cooldown: 10ms
+`, server.URL))
+
+	r.providerManager.RecordResult("openai", &provider.ProviderError{Provider: "openai", Type: provider.ErrorTypeNetwork, Message: "down"})
+	time.Sleep(20 * time.Millisecond)
+
+	// Hold the only judge slot so the judge call gives up queueing
+	r.judgeSlots <- struct{}{}
+	defer func() { <-r.judgeSlots }()
+
+	prov, err := r.providerManager.GetProvider("openai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result := r.judgeAttempt(ctx, prov, WorkerResult{WorkerID: "worker", Content: "answer"}, "prompt", r.config.Judges[0], nil)
+	if result.Error == nil {
+		t.Fatal("judge succeeded, want it to give up waiting for a slot")
+	}
+
+	if err := r.providerManager.Allow("openai"); err != nil {
+		t.Fatalf("provider still blocked after the judge gave up queueing: %v", err)
+	}
+}
+
+func TestSlowWorkerLeavesJudgingBudget(t *testing.T) {
+	fast := fakeOpenAI(t, "fast answer", 0)
+	slow := fakeOpenAI(t, "slow answer", 5*time.Second)
+	judge := fakeOpenAI(t, "", 500*time.Millisecond) // still judging after worker_timeout
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  fast:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+  slow:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+  judge:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+workers:
+  - id: fast
+    provider: fast
+  - id: slow
+    provider: slow
+judges:
+  - id: judge
+    provider: judge
+    system_prompt: Score the answer.
+consensus:
+  algorithm: score_top1
+  timeout: 1s
+  worker_timeout: 300ms
+  judging_timeout: 700ms
+  judge_retries: -1
+`, fast.URL, slow.URL, judge.URL))
+
+	result, err := r.Run(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	for _, worker := range result.Workers {
+		switch worker.WorkerID {
+		case "slow":
+			if worker.Error == nil {
+				t.Error("slow worker answered, want it cut off at worker_timeout")
+			}
+		case "fast":
+			if len(worker.JudgeResults) != 1 || worker.JudgeResults[0].Error != nil {
+				t.Fatalf("fast worker judge results = %+v, want one successful judgement", worker.JudgeResults)
+			}
+		}
+	}
+	if result.Consensus.Winner != "fast" {
+		t.Errorf("winner = %q, want fast", result.Consensus.Winner)
+	}
+	if result.TotalDuration >= time.Second {
+		t.Errorf("run took %v, want it done before the 1s timeout", result.TotalDuration)
+	}
+}
+
+func TestSavePlanToFileKeepsPlansFromTheSameSecond(t *testing.T) {
+	t.Chdir(t.TempDir())
+	r := &Runner{}
+
+	// Saved back to back, both plans get the same timestamp in their name
+	plans := []string{"first plan", "second plan"}
+	for _, plan := range plans {
+		if err := r.savePlanToFile("prompt", newRunID(), plan); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir("plans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(plans) {
+		t.Fatalf("plans directory holds %d files, want %d (no overwrites, no temp files left)", len(entries), len(plans))
+	}
+	saved := ""
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join("plans", entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved += string(data)
+	}
+	for _, plan := range plans {
+		if !strings.Contains(saved, plan) {
+			t.Errorf("%q was overwritten", plan)
+		}
+	}
+}
+
+func TestRunWrapsConsensusSentinelErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		answer    string
+		consensus string
+		want      error
+	}{
+		{name: "no successful workers", answer: "", consensus: "algorithm: majority", want: ErrNoSuccessfulWorkers},
+		{name: "below min score", answer: "answer", consensus: "algorithm: score_top1\n  min_score: 9", want: ErrBelowMinScore},
+		{name: "unimplemented algorithm", answer: "answer", consensus: "algorithm: referee", want: ErrUnknownAlgorithm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakeOpenAI(t, tt.answer, 0)
+			r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+workers:
+  - id: worker
+    provider: openai
+judges:
+  - id: judge
+    provider: openai
+    system_prompt: Score the answer.
+consensus:
+  %s
+`, server.URL, tt.consensus))
+
+			_, err := r.Run(context.Background(), "prompt")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Run error = %v, want it to wrap %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWorkerAssignsRunID(t *testing.T) {
+	server := fakeOpenAI(t, "answer", 0)
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+workers:
+  - id: worker
+    provider: openai
+`, server.URL))
+
+	result, err := r.RunWorker(context.Background(), "worker", "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RunID == "" {
+		t.Error("RunWorker left the run without an ID")
+	}
+
+	result, err = r.RunWorker(WithRunID(context.Background(), "given"), "worker", "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RunID != "given" {
+		t.Errorf("RunID = %q, want the context's %q", result.RunID, "given")
+	}
+}
+
+func TestExecutingAPlanGetsRunIDsOfItsOwn(t *testing.T) {
+	server := fakeOpenAI(t, "done", 0)
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+workers:
+  - id: worker
+    provider: openai
+consensus:
+  algorithm: majority
+`, server.URL))
+	plan := &PlanResult{RunID: "plan1", Steps: []PlanStep{{Number: 1, Title: "first"}, {Number: 2, Title: "second"}}}
+
+	result, err := r.ExecutePlan(plan, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RunID != "plan1-exec" {
+		t.Errorf("execution run ID = %q, want plan1-exec", result.RunID)
+	}
+
+	result, err = r.ExecutePlanStep(plan, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RunID != "plan1-step2" {
+		t.Errorf("step run ID = %q, want plan1-step2", result.RunID)
+	}
+}
+
+// suffixPreprocessor appends a marker to the prompt
+type suffixPreprocessor struct{}
+
+func (suffixPreprocessor) Name() string { return "suffix" }
+
+func (suffixPreprocessor) Process(ctx context.Context, prompt PreparedPrompt, ideContext *ide.IDEContext) (PreparedPrompt, error) {
+	prompt.Text += " [prepared]"
+	return prompt, nil
+}
+
+func TestConsensusJudgesThePreparedPrompt(t *testing.T) {
+	var mu sync.Mutex
+	var judgePrompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		judgePrompts = append(judgePrompts, body.Messages[len(body.Messages)-1].Content)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": `{"score": 8, "reason": "fine"}`}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+workers:
+  - id: worker
+    provider: openai
+judges:
+  - id: judge
+    provider: openai
+    system_prompt: Score the answer.
+consensus:
+  algorithm: score_top1
+`, server.URL))
+	r.AddPreprocessor(suffixPreprocessor{})
+
+	// A resumed answer wasn't judged alongside the workers, so consensus judges it
+	ctx := WithCompletedWorkers(context.Background(), map[string][]WorkerResult{
+		"worker": {{WorkerID: "worker", Content: "answer", Metadata: map[string]interface{}{}}},
+	})
+	if _, err := r.Run(ctx, "prompt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(judgePrompts) != 1 {
+		t.Fatalf("judges were asked %d times, want once", len(judgePrompts))
+	}
+	if !strings.Contains(judgePrompts[0], "prompt [prepared]") {
+		t.Errorf("judge prompt = %q, want the preprocessed prompt", judgePrompts[0])
+	}
+}
@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// sampleWorker draws the worker's configured number of answers. Providers
+// that support n generate them all in one request; for the rest the request
+// is repeated, concurrently. Sampled results are named <id>#1, <id>#2, ...
+func (r *Runner) sampleWorker(ctx context.Context, worker config.Worker, prompt, fileContext string) []WorkerResult {
+	if worker.Samples <= 1 {
+		return []WorkerResult{r.runSingleWorker(ctx, worker, prompt, fileContext)}
+	}
+
+	var results []WorkerResult
+	if r.samplesNatively(worker.Provider) {
+		result := r.runSingleWorker(ctx, worker, prompt, fileContext)
+		results = append([]WorkerResult{result}, result.samples...)
+	} else {
+		results = make([]WorkerResult, worker.Samples)
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = r.runSingleWorker(ctx, worker, prompt, fileContext)
+			}()
+		}
+		wg.Wait()
+	}
+
+	for i := range results {
+		results[i].samples = nil
+		results[i].WorkerID = fmt.Sprintf("%s#%d", worker.ID, i+1)
+		results[i].Metadata["worker"] = worker.ID
+		results[i].Metadata["sample"] = i + 1
+	}
+	return results
+}
+
+// sampledWorkerID returns the ID of the configured worker a result came from,
+// which differs from its WorkerID for sampled workers
+func sampledWorkerID(result *WorkerResult) string {
+	if id, ok := result.Metadata["worker"].(string); ok {
+		return id
+	}
+	return result.WorkerID
+}
+
+// samplesNatively reports whether a provider generates several completions
+// in one request
+func (r *Runner) samplesNatively(providerName string) bool {
+	prov, err := r.providerManager.GetProvider(providerName)
+	if err != nil {
+		return false
+	}
+	sampler, ok := prov.(provider.MultiSampler)
+	return ok && sampler.SupportsN()
+}
+
+// splitSamples turns the extra completions of a sampled request into results
+// of their own alongside first. The request's token usage and cost are shared
+// out evenly, since the provider reports them for all completions together.
+func splitSamples(worker config.Worker, first *WorkerResult, extra []provider.Choice) []WorkerResult {
+	samples := make([]WorkerResult, len(extra))
+	for i, choice := range extra {
+		stats := *first.Stats
+		stats.FinishReason = choice.FinishReason
+		stats.Success, stats.Error = true, nil
+
+		sample := *first
+		sample.Content = choice.Content
+		sample.StructuredContent = nil
+		sample.Error = nil
+		sample.Stats = &stats
+		sample.Metadata = maps.Clone(first.Metadata)
+		delete(sample.Metadata, "finish_reason")
+		if choice.FinishReason != "" {
+			sample.Metadata["finish_reason"] = choice.FinishReason
+		}
+		failIfBlank(&sample, stats.Provider)
+		checkStructured(worker, &sample)
+		samples[i] = sample
+	}
+
+	n := len(samples) + 1
+	cost := first.Stats.EstimatedCost / float64(n)
+	shares := splitUsage(first.TokensUsed, n)
+	first.TokensUsed, first.Stats.TokensUsed, first.Stats.EstimatedCost = shares[0], shares[0], cost
+	for i := range samples {
+		samples[i].TokensUsed, samples[i].Stats.TokensUsed, samples[i].Stats.EstimatedCost = shares[i+1], shares[i+1], cost
+	}
+	return samples
+}
+
+// splitUsage divides token usage into n shares that add up to it, the first
+// share taking any remainder
+func splitUsage(usage *provider.TokenUsage, n int) []*provider.TokenUsage {
+	shares := make([]*provider.TokenUsage, n)
+	if usage == nil {
+		return shares
+	}
+
+	for i := range shares {
+		share := provider.TokenUsage{
+			PromptTokens:        usage.PromptTokens / n,
+			CompletionTokens:    usage.CompletionTokens / n,
+			CacheCreationTokens: usage.CacheCreationTokens / n,
+			CacheReadTokens:     usage.CacheReadTokens / n,
+		}
+		if i == 0 {
+			share.PromptTokens += usage.PromptTokens % n
+			share.CompletionTokens += usage.CompletionTokens % n
+			share.CacheCreationTokens += usage.CacheCreationTokens % n
+			share.CacheReadTokens += usage.CacheReadTokens % n
+		}
+		share.TotalTokens = share.PromptTokens + share.CompletionTokens
+		shares[i] = &share
+	}
+	return shares
+}
@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+func TestSplitUsageAddsUp(t *testing.T) {
+	usage := &provider.TokenUsage{PromptTokens: 10, CompletionTokens: 7, CacheReadTokens: 5, CacheCreationTokens: 1}
+	shares := splitUsage(usage, 3)
+
+	var total provider.TokenUsage
+	for _, share := range shares {
+		total.PromptTokens += share.PromptTokens
+		total.CompletionTokens += share.CompletionTokens
+		total.CacheReadTokens += share.CacheReadTokens
+		total.CacheCreationTokens += share.CacheCreationTokens
+		if share.TotalTokens != share.PromptTokens+share.CompletionTokens {
+			t.Errorf("share %+v doesn't add up to its total", share)
+		}
+	}
+	if total.PromptTokens != 10 || total.CompletionTokens != 7 || total.CacheReadTokens != 5 || total.CacheCreationTokens != 1 {
+		t.Errorf("shares add up to %+v, want %+v", total, *usage)
+	}
+
+	if shares := splitUsage(nil, 2); len(shares) != 2 || shares[0] != nil {
+		t.Errorf("shares of unknown usage = %v, want two nils", shares)
+	}
+}
+
+func TestSampleWorkerRepeatsRequests(t *testing.T) {
+	// Ollama has no n parameter, so each sample is a request of its own
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":           map[string]string{"content": fmt.Sprintf("answer %d", n)},
+			"done":              true,
+			"prompt_eval_count": 4,
+			"eval_count":        2,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  local:
+    kind: ollama
+    model: llama3.1
+    host: %s
+workers:
+  - id: worker
+    provider: local
+    samples: 3
+consensus:
+  algorithm: majority
+`, server.URL))
+
+	results := r.sampleWorker(context.Background(), r.config.Workers[0], "prompt", "")
+	if len(results) != 3 || requests.Load() != 3 {
+		t.Fatalf("%d results from %d requests, want 3 of each", len(results), requests.Load())
+	}
+
+	var contents []string
+	for i, result := range results {
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+		if want := fmt.Sprintf("worker#%d", i+1); result.WorkerID != want {
+			t.Errorf("result %d is named %s, want %s", i, result.WorkerID, want)
+		}
+		if sampledWorkerID(&result) != "worker" {
+			t.Errorf("%s comes from %s, want worker", result.WorkerID, sampledWorkerID(&result))
+		}
+		contents = append(contents, result.Content)
+	}
+	sort.Strings(contents)
+	if contents[0] == contents[1] || contents[1] == contents[2] {
+		t.Errorf("contents = %v, want each request's own answer", contents)
+	}
+}
+
+func TestSampleWorkerUsesN(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["n"] != float64(2) {
+			t.Errorf("n = %v, want 2", body["n"])
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, content := range []string{"first", "second"} {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"choices": []map[string]interface{}{{"index": i, "delta": map[string]string{"content": content}, "finish_reason": "stop"}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		usage, _ := json.Marshal(map[string]interface{}{
+			"choices": []interface{}{},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+		})
+		fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", usage)
+	}))
+	t.Cleanup(server.Close)
+
+	r := newTestRunner(t, fmt.Sprintf(`
+providers:
+  openai:
+    kind: openai
+    model: gpt-4o-mini
+    base_url: %s
+workers:
+  - id: worker
+    provider: openai
+    samples: 2
+consensus:
+  algorithm: majority
+`, server.URL))
+
+	results := r.sampleWorker(context.Background(), r.config.Workers[0], "prompt", "")
+	if requests.Load() != 1 {
+		t.Errorf("%d requests, want one asking for both samples", requests.Load())
+	}
+	if len(results) != 2 {
+		t.Fatalf("%d results, want 2", len(results))
+	}
+	if results[0].Content != "first" || results[1].Content != "second" {
+		t.Errorf("contents = %q, %q; want first, second", results[0].Content, results[1].Content)
+	}
+	if results[1].WorkerID != "worker#2" {
+		t.Errorf("second sample is named %s, want worker#2", results[1].WorkerID)
+	}
+
+	// The request's usage is shared out between the samples
+	var total int
+	for _, result := range results {
+		if result.Error != nil {
+			t.Fatal(result.Error)
+		}
+		if result.TokensUsed != nil {
+			total += result.TokensUsed.TotalTokens
+		}
+	}
+	if total != 15 {
+		t.Errorf("samples used %d tokens in all, want the request's 15", total)
+	}
+}
@@ -0,0 +1,182 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+// checkStructured holds a worker's answer to its response_schema, setting
+// StructuredContent when it matches and failing the result when it doesn't
+func checkStructured(worker config.Worker, result *WorkerResult) {
+	if worker.ResponseSchema == nil || result.Error != nil {
+		return
+	}
+
+	if value, err := parseStructured(result.Content); err != nil {
+		result.Error = fmt.Errorf("response is not valid JSON for response_schema: %w", err)
+	} else if err := validateSchema(value, worker.ResponseSchema, ""); err != nil {
+		result.Error = fmt.Errorf("response doesn't match response_schema: %w", err)
+	} else {
+		result.StructuredContent = value
+	}
+}
+
+// parseStructured reads the JSON value in a worker's answer. Providers that
+// enforce the schema return bare JSON; for the rest, a fenced json block or
+// the outermost object or array in the text is taken as a best effort.
+func parseStructured(content string) (interface{}, error) {
+	var value interface{}
+	trimmed := strings.TrimSpace(content)
+	if err := json.Unmarshal([]byte(trimmed), &value); err == nil {
+		return value, nil
+	}
+
+	for _, match := range codeBlockPattern.FindAllStringSubmatch(content, -1) {
+		if blockLanguage(match[1]) == "json" || strings.TrimSpace(match[1]) == "" {
+			if err := json.Unmarshal([]byte(match[2]), &value); err == nil {
+				return value, nil
+			}
+		}
+	}
+
+	for _, pair := range []string{"{}", "[]"} {
+		start := strings.IndexByte(trimmed, pair[0])
+		end := strings.LastIndexByte(trimmed, pair[1])
+		if start >= 0 && end > start {
+			if err := json.Unmarshal([]byte(trimmed[start:end+1]), &value); err == nil {
+				return value, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no JSON value found in the response")
+}
+
+// validateSchema checks a decoded JSON value against the subset of JSON
+// Schema devgru understands: type, properties, required,
+// additionalProperties (as a boolean), items and enum. Other keywords are
+// left to providers that enforce the schema natively.
+func validateSchema(value interface{}, schema map[string]interface{}, path string) error {
+	if path == "" {
+		path = "$"
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		actual := jsonType(value)
+		matched := false
+		for _, t := range types {
+			if t == actual || (t == "number" && actual == "integer") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual)
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		encoded, _ := json.Marshal(value)
+		found := false
+		for _, option := range enum {
+			if optionJSON, _ := json.Marshal(option); string(optionJSON) == string(encoded) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %s is not one of the allowed values", path, encoded)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if key, ok := name.(string); ok {
+					if _, present := v[key]; !present {
+						return fmt.Errorf("%s: missing required property %q", path, key)
+					}
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			propertySchema, known := properties[key].(map[string]interface{})
+			if !known {
+				if allowed, ok := schema["additionalProperties"].(bool); ok && !allowed {
+					return fmt.Errorf("%s: unexpected property %q", path, key)
+				}
+				continue
+			}
+			if err := validateSchema(v[key], propertySchema, path+"."+key); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchema(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// schemaTypes reads a schema's type keyword, which may be a name or a list
+func schemaTypes(raw interface{}) []string {
+	switch t := raw.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// jsonType names the JSON Schema type of a decoded JSON value
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaInstructions asks models without native structured output for JSON
+// matching the schema
+func schemaInstructions(schema map[string]interface{}) string {
+	encoded, _ := json.MarshalIndent(schema, "", "  ")
+	return "Respond with a single JSON value, and nothing else, that matches this JSON Schema:\n" + string(encoded)
+}
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+// personSchema is a response_schema used across the schema tests
+const personSchema = `{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string"},
+    "age": {"type": "integer"},
+    "height": {"type": "number"},
+    "role": {"enum": ["admin", "user"]},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "manager": {"type": ["object", "null"], "properties": {"name": {"type": "string"}}}
+  },
+  "required": ["name"],
+  "additionalProperties": false
+}`
+
+func decodeSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(personSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string // empty: valid
+	}{
+		{name: "valid", value: `{"name": "Ada", "age": 36, "height": 1.65, "role": "admin", "tags": ["a"], "manager": null}`},
+		{name: "integer counts as number", value: `{"name": "Ada", "height": 2}`},
+		{name: "nested object", value: `{"name": "Ada", "manager": {"name": "Bob"}}`},
+		{name: "missing required", value: `{"age": 36}`, wantErr: `$: missing required property "name"`},
+		{name: "wrong type", value: `{"name": 1}`, wantErr: "$.name: expected string, got integer"},
+		{name: "fraction for integer", value: `{"name": "Ada", "age": 36.5}`, wantErr: "$.age: expected integer, got number"},
+		{name: "not in enum", value: `{"name": "Ada", "role": "root"}`, wantErr: `$.role: "root" is not one of the allowed values`},
+		{name: "bad array item", value: `{"name": "Ada", "tags": ["a", 2]}`, wantErr: "$.tags[1]: expected string"},
+		{name: "bad nested property", value: `{"name": "Ada", "manager": {"name": false}}`, wantErr: "$.manager.name: expected string"},
+		{name: "additional property", value: `{"name": "Ada", "email": "a@b.c"}`, wantErr: `$: unexpected property "email"`},
+		{name: "not an object", value: `["Ada"]`, wantErr: "$: expected object, got array"},
+	}
+
+	schema := decodeSchema(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			err := validateSchema(value, schema, "")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseStructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string // canonical JSON; empty: no JSON found
+	}{
+		{name: "bare JSON", content: ` {"a": 1} `, want: `{"a":1}`},
+		{name: "fenced json block", content: "Here it is:\n```json\n{\"a\": 1}\n```\nDone.", want: `{"a":1}`},
+		{name: "unlabelled fence", content: "```\n[1, 2]\n```", want: `[1,2]`},
+		{name: "object in prose", content: `The answer is {"a": {"b": 2}} as requested.`, want: `{"a":{"b":2}}`},
+		{name: "no JSON", content: "I can't help with that."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := parseStructured(tt.content)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("found %v, want no JSON", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := canonicalJSON(value); got != tt.want {
+				t.Errorf("value = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckStructured(t *testing.T) {
+	worker := config.Worker{ID: "worker", ResponseSchema: decodeSchema(t)}
+
+	valid := WorkerResult{Content: "```json\n{\"name\": \"Ada\"}\n```"}
+	checkStructured(worker, &valid)
+	if valid.Error != nil || valid.StructuredContent == nil {
+		t.Errorf("valid answer: error %v, structured %v; want it kept", valid.Error, valid.StructuredContent)
+	}
+
+	invalid := WorkerResult{Content: `{"age": 1}`}
+	checkStructured(worker, &invalid)
+	if invalid.Error == nil || invalid.StructuredContent != nil {
+		t.Errorf("invalid answer: error %v, structured %v; want it failed", invalid.Error, invalid.StructuredContent)
+	}
+
+	// Workers without a schema are left alone
+	plain := WorkerResult{Content: "not JSON"}
+	checkStructured(config.Worker{ID: "plain"}, &plain)
+	if plain.Error != nil || plain.StructuredContent != nil {
+		t.Errorf("worker without a schema: error %v, structured %v", plain.Error, plain.StructuredContent)
+	}
+}
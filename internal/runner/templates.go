@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// editorPlaceholders are filled by the template preprocessor from the
+// editor's context, so prompt templates leave them for it
+var editorPlaceholders = []string{"selection", "active_file", "workspace"}
+
+// TemplateNames returns the configured prompt template names, sorted
+func (r *Runner) TemplateNames() []string {
+	names := make([]string, 0, len(r.config.Templates))
+	for name := range r.config.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenderTemplate fills the {{placeholders}} of a configured prompt template
+// with vars. Editor placeholders ({{selection}}, {{active_file}},
+// {{workspace}}) are left for the template preprocessor unless vars sets
+// them; any other placeholder without a value is an error.
+func (r *Runner) RenderTemplate(name string, vars map[string]string) (string, error) {
+	template, ok := r.config.Templates[name]
+	if !ok {
+		if len(r.config.Templates) == 0 {
+			return "", fmt.Errorf("unknown template %s: no templates are configured", name)
+		}
+		return "", fmt.Errorf("unknown template %s (available: %s)", name, strings.Join(r.TemplateNames(), ", "))
+	}
+
+	var missing []string
+	rendered := placeholderPattern.ReplaceAllStringFunc(template, func(match string) string {
+		key := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := vars[key]; ok {
+			return value
+		}
+		if !slices.Contains(editorPlaceholders, key) && !slices.Contains(missing, key) {
+			missing = append(missing, key)
+		}
+		return match
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("template %s needs a value for: %s", name, strings.Join(missing, ", "))
+	}
+	return rendered, nil
+}
+
+// ParseTemplateVars splits key=value template variables
+func ParseTemplateVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("template variable %q must look like name=value", pair)
+		}
+		vars[strings.TrimSpace(key)] = value
+	}
+	return vars, nil
+}
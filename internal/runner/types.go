@@ -3,6 +3,7 @@ package runner
 import (
 	"time"
 
+	"github.com/evisdrenova/devgru/internal/ide"
 	"github.com/evisdrenova/devgru/internal/provider"
 )
 
@@ -14,6 +15,20 @@ type JudgeResult struct {
 	Reason   string        `json:"reason"`
 	Error    error         `json:"error"`
 	Duration time.Duration `json:"duration"`
+
+	RawResponse string `json:"raw_response,omitempty"` // Unparsed judge output
+	Attempts    int    `json:"attempts"`               // Judge calls made, including retries
+	Corrected   bool   `json:"corrected,omitempty"`    // Last call re-asked for valid JSON after an unparseable answer
+	Prompt      string `json:"prompt,omitempty"`       // Assembled evaluation prompt, when recorded
+}
+
+// JudgeEvent reports a judge starting or finishing its evaluation of a worker
+type JudgeEvent struct {
+	JudgeID  string
+	WorkerID string
+	Done     bool
+	Score    int   // set when Done and Err is nil
+	Err      error // set when Done and the judge failed
 }
 
 // WorkerResult represents the result from a single worker
@@ -26,19 +41,35 @@ type WorkerResult struct {
 	Metadata     map[string]interface{} `json:"metadata"`
 	JudgeResults []JudgeResult          `json:"judge_results,omitempty"`
 	AverageScore float64                `json:"average_score,omitempty"`
+	Unscored     bool                   `json:"unscored,omitempty"` // every judge failed to score this worker
+
+	// StructuredContent is the parsed JSON answer of a worker with a
+	// response_schema, set only when it matched the schema
+	StructuredContent interface{} `json:"structured_content,omitempty"`
+
+	judged  bool           // judges already ran for this worker
+	samples []WorkerResult // further completions from the same request, for sampled workers
+}
+
+// Truncated reports whether the worker's answer was cut off by its max_tokens
+// limit, so it may stop mid-thought
+func (w *WorkerResult) Truncated() bool {
+	return w.Stats.Truncated()
 }
 
 // RunResult contains the results from all workers
 type RunResult struct {
-	Prompt        string         `json:"prompt"`
-	Workers       []WorkerResult `json:"workers"`
-	Consensus     *Consensus     `json:"consensus"`
-	TotalDuration time.Duration  `json:"total_duration"`
-	TotalTokens   int            `json:"total_tokens"`
-	EstimatedCost float64        `json:"estimated_cost"`
-	Success       bool           `json:"success"`
-	StartTime     time.Time      `json:"start_time"`
-	EndTime       time.Time      `json:"end_time"`
+	RunID         string           `json:"run_id"` // correlates the run's traces, logs and saved output
+	Prompt        string           `json:"prompt"`
+	Workers       []WorkerResult   `json:"workers"`
+	Consensus     *Consensus       `json:"consensus"`
+	TotalDuration time.Duration    `json:"total_duration"`
+	TotalTokens   int              `json:"total_tokens"`
+	EstimatedCost float64          `json:"estimated_cost"`
+	Success       bool             `json:"success"`
+	StartTime     time.Time        `json:"start_time"`
+	EndTime       time.Time        `json:"end_time"`
+	Diffs         []ide.DiffResult `json:"diffs,omitempty"` // Proposed file edits awaiting review
 }
 
 // Consensus represents the final consensus result
@@ -49,6 +80,10 @@ type Consensus struct {
 	Confidence   float64 `json:"confidence"`   // Confidence score (0-1)
 	Reasoning    string  `json:"reasoning"`    // Why this consensus was chosen
 	Participants int     `json:"participants"` // Number of workers that succeeded
+
+	// LowConfidence is set when Confidence fell short of consensus.min_confidence;
+	// the content is kept but the run isn't counted as a success
+	LowConfidence bool `json:"low_confidence,omitempty"`
 }
 
 // PlanStepType represents the type of a plan step
@@ -72,6 +107,8 @@ type PlanStep struct {
 
 // PlanResult represents the result of a planning phase
 type PlanResult struct {
+	Prompt       string     `json:"prompt"` // the request the plan was generated for
+	RunID        string     `json:"run_id"` // identifies the planning run, also in the saved plan's file name
 	TargetFile   string     `json:"target_file"`
 	Steps        []PlanStep `json:"steps"`
 	SelectedPlan string     `json:"selected_plan"`
@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/provider"
+)
+
+// SetVerboseOutput makes the runner print every worker and judge exchange
+// (prompts, raw responses, usage and timings) to w as it completes
+func (r *Runner) SetVerboseOutput(w io.Writer) {
+	r.verbose = w
+}
+
+// SetRecordPrompts makes the runner keep the fully assembled prompt of every
+// worker (Metadata["assembled_prompt"]) and judge (JudgeResult.Prompt)
+func (r *Runner) SetRecordPrompts(record bool) {
+	r.recordPrompts = record
+}
+
+// assemblePrompt renders everything a provider receives for a request as
+// clearly delimited sections
+func assemblePrompt(opts provider.Options, prompt string) string {
+	var b strings.Builder
+	writeSection(&b, "system prompt", opts.SystemPrompt)
+	if opts.Context != "" {
+		writeSection(&b, "context", opts.Context)
+	}
+	writeSection(&b, "prompt", prompt)
+	return b.String()
+}
+
+// traceWorker prints the full exchange with a worker when verbose output is enabled
+func (r *Runner) traceWorker(runID string, worker config.Worker, prompt string, opts provider.Options, result WorkerResult) {
+	if r.verbose == nil {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "===== run %s: worker %s (provider %s) =====\n", runID, worker.ID, worker.Provider)
+	fmt.Fprintf(&b, "temperature: %.2f • max_tokens: %d", opts.Temperature, opts.MaxTokens)
+	if opts.Seed != nil {
+		fmt.Fprintf(&b, " • seed: %d", *opts.Seed)
+	}
+	b.WriteString("\n")
+	writeSection(&b, "system prompt", opts.SystemPrompt)
+	writeSection(&b, "prompt", prompt)
+
+	if result.Error != nil {
+		writeSection(&b, "error", result.Error.Error())
+	} else {
+		writeSection(&b, "response", result.Content)
+	}
+	writeStats(&b, result.TokensUsed, result.Stats)
+
+	r.writeVerbose(b.String())
+}
+
+// traceJudge prints the full exchange with a judge when verbose output is enabled
+func (r *Runner) traceJudge(runID string, judge config.Judge, prompt string, opts provider.Options, result JudgeResult) {
+	if r.verbose == nil {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "===== run %s: judge %s → worker %s (provider %s) =====\n", runID, judge.ID, result.WorkerID, judge.Provider)
+	if result.Corrected {
+		fmt.Fprintln(&b, "corrective re-prompt: the previous answer was not valid judge JSON")
+	}
+	writeSection(&b, "system prompt", opts.SystemPrompt)
+	writeSection(&b, "evaluation prompt", prompt)
+	writeSection(&b, "raw response", result.RawResponse)
+
+	if result.Error != nil {
+		writeSection(&b, "error", result.Error.Error())
+	} else {
+		fmt.Fprintf(&b, "parsed score: %d/10 • reason: %s\n", result.Score, result.Reason)
+	}
+	fmt.Fprintf(&b, "latency: %v\n", result.Duration.Round(time.Millisecond))
+
+	r.writeVerbose(b.String())
+}
+
+// writeVerbose writes one complete trace block so concurrent traces don't interleave
+func (r *Runner) writeVerbose(block string) {
+	r.verboseMu.Lock()
+	defer r.verboseMu.Unlock()
+	fmt.Fprintln(r.verbose, block)
+}
+
+func writeSection(b *strings.Builder, title, content string) {
+	fmt.Fprintf(b, "--- %s ---\n", title)
+	if content == "" {
+		b.WriteString("(empty)\n")
+		return
+	}
+	b.WriteString(strings.TrimRight(content, "\n"))
+	b.WriteString("\n")
+}
+
+func writeStats(b *strings.Builder, tokens *provider.TokenUsage, stats *provider.Stats) {
+	if tokens != nil {
+		fmt.Fprintf(b, "tokens: prompt %d • completion %d • total %d\n",
+			tokens.PromptTokens, tokens.CompletionTokens, tokens.TotalTokens)
+	}
+	if stats != nil {
+		fmt.Fprintf(b, "model: %s • latency: %v • cost: $%.6f\n",
+			stats.Model, stats.Duration.Round(time.Millisecond), stats.EstimatedCost)
+	}
+}
@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evisdrenova/devgru/internal/config"
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// minExplainColumnWidth is the narrowest column before the explain view stacks workers
+const minExplainColumnWidth = 40
+
+// minExplainPanelWidth is the narrowest a stacked explain panel gets, even on
+// a terminal narrower than that (or before its size is known)
+const minExplainPanelWidth = 20
+
+// runSlashCommand handles session commands typed into the input, e.g. /explain or /model
+func (m *InteractiveModel) runSlashCommand(input string) tea.Cmd {
+	fields := strings.Fields(input)
+	command := strings.ToLower(fields[0])
+
+	switch command {
+	case "/explain":
+		result := m.lastRunResult()
+		if result == nil {
+			m.addCommandError("Nothing to explain yet: run a prompt first")
+			return nil
+		}
+
+		m.addBlockAsChild(Block{
+			ID:        fmt.Sprintf("explain_%d", len(m.blocks)),
+			Type:      BlockEntryExplain,
+			Content:   "Worker comparison",
+			Timestamp: time.Now(),
+			Data:      result,
+			ParentID:  m.currentUserID,
+			IsLast:    true,
+		})
+
+	case "/model":
+		m.selectModel(fields[1:])
+
+	default:
+		if _, ok := m.config.Templates[strings.TrimPrefix(fields[0], "/")]; ok {
+			return m.runTemplate(strings.TrimPrefix(fields[0], "/"), fields[1:])
+		}
+
+		var available []string
+		for _, name := range config.SlashCommands {
+			available = append(available, "/"+name)
+		}
+		for _, name := range m.runner.TemplateNames() {
+			available = append(available, "/"+name)
+		}
+		m.addCommandError(fmt.Sprintf("Unknown command %s (available: %s)", command, strings.Join(available, ", ")))
+	}
+
+	return nil
+}
+
+// runTemplate runs a prompt template typed as /name: name=value arguments
+// fill its placeholders and any other words are added after it
+func (m *InteractiveModel) runTemplate(name string, args []string) tea.Cmd {
+	var pairs, extra []string
+	for _, arg := range args {
+		if strings.Contains(arg, "=") {
+			pairs = append(pairs, arg)
+		} else {
+			extra = append(extra, arg)
+		}
+	}
+
+	vars, err := runner.ParseTemplateVars(pairs)
+	if err != nil {
+		m.addCommandError(err.Error())
+		return nil
+	}
+	prompt, err := m.runner.RenderTemplate(name, vars)
+	if err != nil {
+		m.addCommandError(err.Error())
+		return nil
+	}
+
+	if len(extra) > 0 {
+		prompt += "\n\n" + strings.Join(extra, " ")
+	}
+	return m.submitPrompt(prompt)
+}
+
+// selectModel picks the worker that answers the next prompt on its own.
+// With no argument it lists the workers; "all" goes back to consensus.
+func (m *InteractiveModel) selectModel(args []string) {
+	if len(args) == 0 {
+		var lines []string
+		lines = append(lines, "Pick a worker for the next prompt with /model <number|id> (/model all for consensus):")
+		for i, worker := range m.config.Workers {
+			line := fmt.Sprintf("  %d. %s (%s", i+1, worker.ID, worker.Provider)
+			if provider, ok := m.config.Providers[worker.Provider]; ok && provider.Model != "" {
+				line += ", " + provider.Model
+			}
+			line += ")"
+			if worker.ID == m.selectedWorker {
+				line += " ← selected"
+			}
+			lines = append(lines, line)
+		}
+		m.addCommandMessage(strings.Join(lines, "\n"))
+		return
+	}
+
+	choice := args[0]
+	if strings.EqualFold(choice, "all") {
+		m.selectedWorker = ""
+		m.addCommandMessage("Next prompt runs across all workers")
+		return
+	}
+
+	for i, worker := range m.config.Workers {
+		if choice == worker.ID || choice == strconv.Itoa(i+1) {
+			m.selectedWorker = worker.ID
+			m.addCommandMessage(fmt.Sprintf("Next prompt goes to %s only, without planning or consensus", worker.ID))
+			return
+		}
+	}
+
+	m.addCommandError(fmt.Sprintf("Unknown worker %s: /model lists the available workers", choice))
+}
+
+// addCommandMessage shows a slash command's output under the command's block
+func (m *InteractiveModel) addCommandMessage(message string) {
+	m.addBlockAsChild(Block{
+		ID:        fmt.Sprintf("system_%d", len(m.blocks)),
+		Type:      BlockEntrySystem,
+		Content:   message,
+		Timestamp: time.Now(),
+		ParentID:  m.currentUserID,
+		IsLast:    true,
+	})
+}
+
+// addCommandError reports a slash command failure under the command's block
+func (m *InteractiveModel) addCommandError(message string) {
+	m.addBlockAsChild(Block{
+		ID:        fmt.Sprintf("error_%d", len(m.blocks)),
+		Type:      BlockEntryError,
+		Content:   message,
+		Timestamp: time.Now(),
+		ParentID:  m.currentUserID,
+		IsLast:    true,
+	})
+}
+
+// lastRunResult returns the most recent run result shown in the session
+func (m *InteractiveModel) lastRunResult() *runner.RunResult {
+	for i := len(m.blocks) - 1; i >= 0; i-- {
+		if result, ok := m.blocks[i].Data.(*runner.RunResult); ok && result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+// renderExplainBlock lays out every worker's full response with its scores,
+// side by side when the terminal is wide enough and stacked otherwise
+func (m *InteractiveModel) renderExplainBlock(block Block) string {
+	result, ok := block.Data.(*runner.RunResult)
+	if !ok || len(result.Workers) == 0 {
+		return ""
+	}
+
+	available := m.width - 4
+	columns := len(result.Workers)
+	if columns*minExplainColumnWidth > available {
+		columns = 1
+	}
+	columnWidth := max(available/columns-2, minExplainPanelWidth)
+
+	var panels []string
+	for _, worker := range result.Workers {
+		panels = append(panels, m.renderExplainPanel(worker, result.Consensus, columnWidth))
+	}
+
+	var body string
+	if columns > 1 {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, panels...)
+	} else {
+		body = lipgloss.JoinVertical(lipgloss.Left, panels...)
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("214")).
+		Padding(0, 1).
+		Render(fmt.Sprintf("%s (%d workers)", block.Content, len(result.Workers)))
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, body)
+}
+
+// renderExplainPanel renders one labeled worker column for the explain view
+func (m *InteractiveModel) renderExplainPanel(worker runner.WorkerResult, consensus *runner.Consensus, width int) string {
+	borderColor := lipgloss.Color("241")
+	label := worker.WorkerID
+	if consensus != nil && consensus.Winner == worker.WorkerID {
+		borderColor = lipgloss.Color("28")
+		label += " 🏆"
+	}
+	if worker.Error != nil {
+		borderColor = lipgloss.Color("196")
+	}
+
+	var header []string
+	header = append(header, lipgloss.NewStyle().Bold(true).Render(label))
+	if worker.Stats != nil {
+		header = append(header, fmt.Sprintf("%s • %v", worker.Stats.Model, worker.Stats.Duration.Round(time.Millisecond)))
+	}
+	if len(worker.JudgeResults) > 0 {
+		var scores []string
+		for _, judge := range worker.JudgeResults {
+			scores = append(scores, fmt.Sprintf("%s: %d", judge.JudgeID, judge.Score))
+		}
+		header = append(header, fmt.Sprintf("Score %.1f/10 (%s)", worker.AverageScore, strings.Join(scores, ", ")))
+	} else if worker.Unscored {
+		header = append(header, "Unscored (judges failed)")
+	}
+	if worker.Truncated() {
+		header = append(header, "⚠ cut off at max_tokens")
+	}
+
+	var content string
+	if worker.Error != nil {
+		content = fmt.Sprintf("Error: %v", worker.Error)
+	} else {
+		content = worker.Content
+	}
+
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1).
+		Width(width)
+
+	return style.Render(strings.Join(header, "\n") + "\n\n" + content)
+}
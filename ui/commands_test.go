@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+func TestRenderExplainBlockNarrowTerminal(t *testing.T) {
+	block := Block{
+		Content: "Worker responses",
+		Data: &runner.RunResult{Workers: []runner.WorkerResult{
+			{WorkerID: "first", Content: "an answer long enough to need wrapping on a narrow terminal"},
+			{WorkerID: "second", Content: "another answer"},
+		}},
+	}
+
+	for _, width := range []int{0, 10, 30, 200} {
+		m := &InteractiveModel{width: width}
+		out := m.renderExplainBlock(block)
+		for _, id := range []string{"first", "second"} {
+			if !strings.Contains(out, id) {
+				t.Errorf("width %d: %s missing from the explain view:\n%s", width, id, out)
+			}
+		}
+		// Panels never shrink below their minimum, and stay within wide terminals
+		if got := lipgloss.Width(out); got < minExplainPanelWidth || (width > minExplainPanelWidth && got > width) {
+			t.Errorf("width %d: explain view is %d columns wide", width, got)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// Colors of the lines only one compared answer has
+var (
+	leftOnlyStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("214")) // Orange
+	rightOnlyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))  // Bright blue
+)
+
+// CompareModel shows two workers' answers diffed line by line: side by side
+// when the terminal is wide enough and interleaved otherwise, with the lines
+// only one of them has highlighted
+type CompareModel struct {
+	comparison   *runner.Comparison
+	width        int
+	height       int
+	keys         KeyMap
+	scrollOffset int
+	totalHeight  int
+}
+
+// NewCompareModel creates a comparison view
+func NewCompareModel(comparison *runner.Comparison) *CompareModel {
+	return &CompareModel{
+		comparison: comparison,
+		keys:       DefaultKeyMap(),
+	}
+}
+
+// Init implements bubbletea.Model
+func (m *CompareModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements bubbletea.Model
+func (m *CompareModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case tea.KeyMsg:
+		maxScroll := max(m.totalHeight-m.height+2, 0)
+		switch {
+		case key.Matches(msg, m.keys.Quit), msg.String() == "esc":
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Up), key.Matches(msg, m.keys.ScrollUp):
+			m.scrollOffset = max(m.scrollOffset-1, 0)
+		case key.Matches(msg, m.keys.Down), key.Matches(msg, m.keys.ScrollDown):
+			m.scrollOffset = min(m.scrollOffset+1, maxScroll)
+		case key.Matches(msg, m.keys.PageUp):
+			m.scrollOffset = max(m.scrollOffset-m.height/2, 0)
+		case key.Matches(msg, m.keys.PageDown):
+			m.scrollOffset = min(m.scrollOffset+m.height/2, maxScroll)
+		}
+	}
+	return m, nil
+}
+
+// View implements bubbletea.Model
+func (m *CompareModel) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+
+	var body string
+	if available := m.width - 4; available >= 2*minExplainColumnWidth {
+		body = m.renderColumns(available/2 - 1)
+	} else {
+		body = m.renderInterleaved(available)
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, m.renderHeader(), body)
+
+	lines := strings.Split(content, "\n")
+	m.totalHeight = len(lines)
+	viewportHeight := m.height - 2 // Reserve space for footer
+	m.scrollOffset = min(m.scrollOffset, max(m.totalHeight-viewportHeight, 0))
+	lines = lines[m.scrollOffset:]
+	if len(lines) > viewportHeight {
+		lines = lines[:viewportHeight]
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, strings.Join(lines, "\n"), m.renderFooter())
+}
+
+// renderHeader names the compared workers and how much their answers agree
+func (m *CompareModel) renderHeader() string {
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("39")).
+		Background(lipgloss.Color("235")).
+		Padding(1, 2).
+		Width(m.width - 4).
+		Align(lipgloss.Center)
+
+	left, right := m.comparison.Left(), m.comparison.Right()
+	content := fmt.Sprintf("DEVGRU COMPARE • %.0f%% of lines agree\n", m.comparison.Agreement*100)
+	content += leftOnlyStyle.Render(compareLabel(left)) + "  vs  " + rightOnlyStyle.Render(compareLabel(right))
+	return headerStyle.Render(content) + "\n"
+}
+
+// renderColumns lays the answers out side by side, shared lines across both
+// columns and differing stretches next to each other
+func (m *CompareModel) renderColumns(width int) string {
+	cell := lipgloss.NewStyle().Width(width).PaddingRight(1)
+	var rows []string
+	var lefts, rights []string
+	flush := func() {
+		for i := 0; i < max(len(lefts), len(rights)); i++ {
+			var l, r string
+			if i < len(lefts) {
+				l = leftOnlyStyle.Render(lefts[i])
+			}
+			if i < len(rights) {
+				r = rightOnlyStyle.Render(rights[i])
+			}
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cell.Render(l), cell.Render(r)))
+		}
+		lefts, rights = nil, nil
+	}
+
+	left, right := m.comparison.Left(), m.comparison.Right()
+	title := lipgloss.NewStyle().Bold(true)
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top,
+		cell.Render(title.Inherit(leftOnlyStyle).Render(left.WorkerID)),
+		cell.Render(title.Inherit(rightOnlyStyle).Render(right.WorkerID))))
+	for _, line := range m.comparison.Lines {
+		switch line.Kind {
+		case runner.DiffLeft:
+			lefts = append(lefts, line.Text)
+		case runner.DiffRight:
+			rights = append(rights, line.Text)
+		default:
+			flush()
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cell.Render(line.Text), cell.Render(line.Text)))
+		}
+	}
+	flush()
+	return strings.Join(rows, "\n")
+}
+
+// renderInterleaved lists the diff in one column, marking the lines only one
+// answer has with < or >
+func (m *CompareModel) renderInterleaved(width int) string {
+	wrap := lipgloss.NewStyle().Width(width)
+	var lines []string
+	for _, line := range m.comparison.Lines {
+		switch line.Kind {
+		case runner.DiffLeft:
+			lines = append(lines, leftOnlyStyle.Inherit(wrap).Render("< "+line.Text))
+		case runner.DiffRight:
+			lines = append(lines, rightOnlyStyle.Inherit(wrap).Render("> "+line.Text))
+		default:
+			lines = append(lines, wrap.Render("  "+line.Text))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderFooter renders the key help and scroll position
+func (m *CompareModel) renderFooter() string {
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("241")).
+		Background(lipgloss.Color("233")).
+		Padding(0, 2).
+		Width(m.width - 4)
+
+	help := "↑/↓: scroll • PgUp/PgDn: page"
+	if maxScroll := m.totalHeight - m.height + 2; maxScroll > 0 {
+		help += fmt.Sprintf(" • Scroll: %d%%", m.scrollOffset*100/maxScroll)
+	}
+	help += " • q: quit"
+	return footerStyle.Render(help)
+}
+
+// compareLabel names a compared worker with its model and timing
+func compareLabel(worker runner.WorkerResult) string {
+	if worker.Stats == nil {
+		return worker.WorkerID
+	}
+	return fmt.Sprintf("%s (%s, %v)", worker.WorkerID, worker.Stats.Model, worker.Stats.Duration.Round(time.Millisecond))
+}
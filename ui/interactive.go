@@ -1,8 +1,12 @@
 package ui
 
 import (
+	"context"
 	_ "embed"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -42,6 +46,18 @@ func DefaultGlobalKeyMap() GlobalKeyMap {
 			key.WithKeys("down"),
 			key.WithHelp("↓", "scroll down"),
 		),
+		Accept: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "apply diff"),
+		),
+		Reject: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "skip diff"),
+		),
+		Abort: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "stop remaining steps"),
+		),
 	}
 }
 
@@ -49,6 +65,7 @@ func (m *InteractiveModel) Init() tea.Cmd {
 	return tea.Batch(
 		m.pollIDEContext(),
 		m.tickTimer(),
+		m.waitForJudgeEvent(),
 	)
 }
 
@@ -69,6 +86,10 @@ func NewInteractiveModel(r *runner.Runner, cfg *config.Config, ideServer *ide.Se
 	ta.BlurredStyle.CursorLine = lipgloss.NewStyle()
 	ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
 
+	// Queued without blocking so a busy UI never stalls judging
+	judgeEvents := newJudgeEventQueue()
+	r.SetJudgeObserver(judgeEvents.push)
+
 	return &InteractiveModel{
 		runner:          r,
 		config:          cfg,
@@ -80,6 +101,7 @@ func NewInteractiveModel(r *runner.Runner, cfg *config.Config, ideServer *ide.Se
 		keys:            DefaultGlobalKeyMap(),
 		processingSteps: make(map[string]int),
 		lastTimerUpdate: time.Now(),
+		judgeEvents:     judgeEvents,
 	}
 }
 
@@ -142,9 +164,13 @@ func (m *InteractiveModel) buildStatusLine() string {
 		statusLeft = "Not Connected"
 	}
 
+	if m.selectedWorker != "" {
+		statusLeft += fmt.Sprintf(" • Next prompt: %s only", m.selectedWorker)
+	}
+
 	var statusRight string
 	if m.ideContext.ActiveFile != "" {
-		statusRight = fmt.Sprintf("📁 %s", m.ideContext.ActiveFile)
+		statusRight = fmt.Sprintf("📁 %s", m.ideContext.DisplayPath(m.ideContext.ActiveFile))
 	}
 
 	if statusLeft == "" && statusRight == "" {
@@ -185,7 +211,18 @@ func (m *InteractiveModel) buildInputArea() string {
 		Foreground(lipgloss.Color("241")).
 		Padding(0, 1)
 
-	help := helpStyle.Render("enter: submit • ctrl+l: clear • ↑/↓: scroll • ctrl+c: quit")
+	helpText := "enter: submit • /model: ask one worker • /explain: compare workers • ctrl+l: clear • ↑/↓: scroll • ctrl+c: quit"
+	if m.pendingRun != "" {
+		helpText = "y: run • n: cancel • ↑/↓: scroll • ctrl+c: quit"
+	}
+	if len(m.pendingDiffs) > 0 {
+		helpText = fmt.Sprintf("y: apply diff • n: skip diff (%d remaining) • ↑/↓: scroll • ctrl+c: quit", len(m.pendingDiffs))
+	}
+	if m.stepPaused {
+		helpText = "y: run next step • n: skip it • a: stop • ↑/↓: scroll • ctrl+c: quit"
+	}
+
+	help := helpStyle.Render(helpText)
 
 	return lipgloss.JoinVertical(lipgloss.Left, statusLine, inputSection, help)
 }
@@ -261,6 +298,12 @@ func (m *InteractiveModel) renderBlock(block Block) string {
 		}
 		return style.Render(content)
 
+	case BlockEntryDiff:
+		return m.renderDiffBlock(block)
+
+	case BlockEntryExplain:
+		return m.renderExplainBlock(block)
+
 	case BlockEntrySystem:
 		// System message
 		style := lipgloss.NewStyle().
@@ -346,6 +389,12 @@ func (m *InteractiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case JudgeProgressMsg:
+		for _, event := range msg.events {
+			m.updateJudgeProgress(event)
+		}
+		return m, m.waitForJudgeEvent()
+
 	case PlanningCompleteMsg:
 		if msg.err != nil {
 			m.addBlockAsChild(Block{
@@ -370,8 +419,14 @@ func (m *InteractiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				ParentID:  m.currentUserID,
 			})
 
-			// Auto-execute the plan
-			cmds = append(cmds, m.executePlan())
+			// Auto-execute the plan, or just its first step when stepping through it
+			if m.config.Planning.StepByStep && len(msg.plan.Steps) > 0 {
+				m.stepPlan = msg.plan
+				m.stepIndex = 0
+				cmds = append(cmds, m.executeStep(0))
+			} else {
+				cmds = append(cmds, m.executePlan())
+			}
 		}
 		return m, tea.Batch(cmds...)
 
@@ -381,7 +436,7 @@ func (m *InteractiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.addBlockAsChild(Block{
 				ID:        fmt.Sprintf("error_%d", len(m.blocks)),
 				Type:      BlockEntryError,
-				Content:   fmt.Sprintf("Execution failed: %s", msg.err.Error()),
+				Content:   fmt.Sprintf("Execution failed: %s%s", msg.err.Error(), failureHint(msg.err)),
 				Timestamp: time.Now(),
 				ParentID:  m.currentUserID,
 				IsLast:    true,
@@ -398,19 +453,141 @@ func (m *InteractiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				ParentID:  m.currentUserID,
 				IsLast:    true,
 			})
+
+			// Hold proposed edits for review instead of writing them straight away
+			if len(msg.result.Diffs) > 0 {
+				m.pendingDiffs = msg.result.Diffs
+				m.isProcessing = true
+				m.showNextDiff()
+			}
+		}
+		return m, nil
+
+	case StepCompleteMsg:
+		if m.stepPlan == nil {
+			// Cleared while the step was running
+			return m, nil
+		}
+		heading := m.stepHeading(msg.index)
+		if msg.err != nil {
+			m.addBlockAsChild(Block{
+				ID:        fmt.Sprintf("error_%d", len(m.blocks)),
+				Type:      BlockEntryError,
+				Content:   fmt.Sprintf("Failed to run %s: %s%s", heading, msg.err.Error(), failureHint(msg.err)),
+				Timestamp: time.Now(),
+				ParentID:  m.currentUserID,
+				IsLast:    true,
+			})
+			// stepIndex still points at the failed step, so y retries it
+			m.pauseAtStep()
+			return m, nil
 		}
+
+		m.addBlockAsChild(Block{
+			ID:        fmt.Sprintf("result_%d", len(m.blocks)),
+			Type:      BlockEntryResult,
+			Content:   fmt.Sprintf("Result of %s\n\n%s", heading, m.formatRunResult(msg.result)),
+			Timestamp: time.Now(),
+			Data:      msg.result,
+			ParentID:  m.currentUserID,
+			IsLast:    true,
+		})
+		m.stepIndex = msg.index + 1
+
+		// Review the step's edits first; showNextDiff pauses once they're decided
+		if len(msg.result.Diffs) > 0 {
+			m.pendingDiffs = msg.result.Diffs
+			m.showNextDiff()
+			return m, nil
+		}
+		m.pauseAtStep()
+		return m, nil
+
+	case DiffAppliedMsg:
+		m.applyingDiff = false
+		if i := m.reviewBlockIndex(); i >= 0 {
+			switch {
+			case msg.err != nil && msg.sent:
+				m.blocks[i].Status = StatusError
+				m.blocks[i].Content = fmt.Sprintf("Failed to send %s to the editor: %s", msg.diff.File, msg.err.Error())
+			case msg.err != nil:
+				m.blocks[i].Status = StatusError
+				m.blocks[i].Content = fmt.Sprintf("Failed to apply %s: %s", msg.diff.File, msg.err.Error())
+			case msg.sent:
+				m.blocks[i].Status = StatusSent
+			default:
+				m.blocks[i].Status = StatusComplete
+			}
+		}
+		m.pendingDiffs = m.pendingDiffs[1:]
+		m.showNextDiff()
 		return m, nil
 
 	case IDEContextUpdateMsg:
 		if msg.context != nil {
 			m.ideContext = msg.context
 		}
+		if m.quitting {
+			return m, nil
+		}
 		return m, m.pollIDEContext()
 
 	case tea.KeyMsg:
+		// A run waiting on cost confirmation takes y/n before anything else
+		if m.pendingRun != "" && !key.Matches(msg, m.keys.Quit, m.keys.Up, m.keys.Down) {
+			switch {
+			case key.Matches(msg, m.keys.Accept):
+				prompt := m.pendingRun
+				m.pendingRun = ""
+				return m, m.startPlanning(prompt)
+			case key.Matches(msg, m.keys.Reject):
+				m.pendingRun = ""
+				m.isProcessing = false
+				m.addCommandMessage("Run cancelled")
+			}
+			return m, nil
+		}
+
+		// Between plan steps, y runs the next one, n skips it and a stops the rest
+		if m.stepPaused && !key.Matches(msg, m.keys.Quit, m.keys.Up, m.keys.Down) {
+			switch {
+			case key.Matches(msg, m.keys.Accept):
+				m.stepPaused = false
+				return m, m.executeStep(m.stepIndex)
+			case key.Matches(msg, m.keys.Reject):
+				m.addCommandMessage(fmt.Sprintf("Skipped %s", m.stepHeading(m.stepIndex)))
+				m.stepIndex++
+				m.pauseAtStep()
+			case key.Matches(msg, m.keys.Abort):
+				m.addCommandMessage(fmt.Sprintf("Stopped with %d of %d steps not run",
+					len(m.stepPlan.Steps)-m.stepIndex, len(m.stepPlan.Steps)))
+				m.finishSteps()
+			}
+			return m, nil
+		}
+
+		// While a diff is under review, y/n decide it and nothing reaches the input
+		if len(m.pendingDiffs) > 0 && !key.Matches(msg, m.keys.Quit, m.keys.Up, m.keys.Down) {
+			switch {
+			case m.applyingDiff:
+				// Already decided; the next diff is shown once this one is applied
+			case key.Matches(msg, m.keys.Accept):
+				m.applyingDiff = true
+				return m, m.applyDiff(m.pendingDiffs[0])
+			case key.Matches(msg, m.keys.Reject):
+				if i := m.reviewBlockIndex(); i >= 0 {
+					m.blocks[i].Status = StatusSkipped
+				}
+				m.pendingDiffs = m.pendingDiffs[1:]
+				m.showNextDiff()
+			}
+			return m, nil
+		}
+
 		// Handle key bindings
 		switch {
 		case key.Matches(msg, m.keys.Quit):
+			m.quitting = true
 			return m, tea.Quit
 
 		case key.Matches(msg, m.keys.Submit):
@@ -430,11 +607,13 @@ func (m *InteractiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 					// Clear input
 					m.textArea.SetValue("")
-					m.currentPrompt = input
-					m.isProcessing = true
 
-					// Start processing
-					return m, m.startPlanning(input)
+					// Slash commands act on the session instead of starting a run
+					if strings.HasPrefix(input, "/") {
+						return m, m.runSlashCommand(input)
+					}
+
+					return m, m.submitPrompt(input)
 				}
 			}
 			return m, nil
@@ -444,6 +623,10 @@ func (m *InteractiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.blocks = []Block{}
 			m.currentUserID = ""
 			m.processingSteps = make(map[string]int)
+			m.pendingDiffs = nil
+			m.stepPlan = nil
+			m.stepPaused = false
+			m.selectedWorker = ""
 			m.isProcessing = false
 			m.lastTimerUpdate = time.Now()
 			return m, nil
@@ -467,9 +650,20 @@ func (m *InteractiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// AddSystemMessage shows an informational message in the session
+func (m *InteractiveModel) AddSystemMessage(content string) {
+	m.addBlock(Block{
+		ID:        fmt.Sprintf("system_%d", len(m.blocks)),
+		Type:      BlockEntrySystem,
+		Content:   content,
+		Timestamp: time.Now(),
+	})
+}
+
 func (m *InteractiveModel) addBlock(block Block) {
 	m.blocks = append(m.blocks, block)
 	m.viewport.GotoBottom()
+	m.writeTranscript(block)
 }
 
 func (m *InteractiveModel) addBlockAsChild(block Block) {
@@ -477,6 +671,7 @@ func (m *InteractiveModel) addBlockAsChild(block Block) {
 
 	m.blocks = append(m.blocks, block)
 	m.viewport.GotoBottom()
+	m.writeTranscript(block)
 }
 
 func (m *InteractiveModel) updateLastChildStatus(parentID string) {
@@ -492,6 +687,10 @@ func (m *InteractiveModel) updateLastChildStatus(parentID string) {
 func (m *InteractiveModel) formatPlanResult(plan *runner.PlanResult) string {
 	var content string
 
+	if m.config.Display.ShowPlanPrompt && plan.Prompt != "" {
+		content += fmt.Sprintf("Request: %s\n\n", plan.Prompt)
+	}
+
 	// Show the actual plan reasoning/content
 	if plan.Reasoning != "" {
 		content += plan.Reasoning
@@ -520,6 +719,7 @@ func (m *InteractiveModel) formatPlanResult(plan *runner.PlanResult) string {
 
 func (m *InteractiveModel) formatRunResult(result *runner.RunResult) string {
 	var content string
+	var truncated bool
 
 	if len(result.Workers) > 0 {
 		content += "\n\nResults:"
@@ -527,19 +727,184 @@ func (m *InteractiveModel) formatRunResult(result *runner.RunResult) string {
 			if worker.Error != nil {
 				content += fmt.Sprintf("\n✗ %s: %s", worker.WorkerID, worker.Error.Error())
 			} else {
-				// Truncate long content for display
-				workerContent := worker.Content
-				if len(workerContent) > 200 {
-					workerContent = workerContent[:200] + "..."
+				// Show a preview; /explain renders the full responses
+				workerContent, cut := truncateRunes(worker.Content, m.config.Display.MaxWorkerChars)
+				truncated = truncated || cut
+				if worker.Truncated() {
+					workerContent = "(cut off at max_tokens) " + workerContent
 				}
 				content += fmt.Sprintf("\n✓ %s: %s", worker.WorkerID, workerContent)
 			}
 		}
 	}
 
+	if truncated {
+		content += "\n\nResponses shortened for display • /explain shows them in full"
+	}
+
+	if result.Consensus != nil && result.Consensus.LowConfidence {
+		content += fmt.Sprintf("\n\n⚠ Low consensus confidence (%.0f%%, below min_confidence %.0f%%): review the answers before relying on them",
+			result.Consensus.Confidence*100, m.config.Consensus.MinConfidence*100)
+	}
+
 	return content
 }
 
+// failureHint suggests what to do about a failed run, for the failures the
+// runner tells apart
+func failureHint(err error) string {
+	switch {
+	case errors.Is(err, runner.ErrNoSuccessfulWorkers):
+		return "\nEvery worker failed; check their errors, API keys and providers, then try again."
+	case errors.Is(err, runner.ErrBelowMinScore):
+		return "\nThe judges rated every answer below consensus.min_score; review the answers, rephrase the prompt or lower the threshold."
+	case errors.Is(err, runner.ErrUnknownAlgorithm):
+		return "\nPick a supported consensus.algorithm in devgru.yaml."
+	}
+	return ""
+}
+
+// showNextDiff adds a review block for the next pending diff, or ends the review
+func (m *InteractiveModel) showNextDiff() {
+	if len(m.pendingDiffs) == 0 {
+		if m.stepPlan != nil {
+			m.pauseAtStep()
+			return
+		}
+		m.isProcessing = false
+		return
+	}
+
+	diff := m.pendingDiffs[0]
+	m.addBlockAsChild(Block{
+		ID:        fmt.Sprintf("diff_%d", len(m.blocks)),
+		Type:      BlockEntryDiff,
+		Content:   fmt.Sprintf("Proposed changes to %s", diff.File),
+		Status:    StatusPending,
+		Timestamp: time.Now(),
+		Data:      diff,
+		ParentID:  m.currentUserID,
+		IsLast:    true,
+	})
+}
+
+// reviewBlockIndex returns the index of the diff block awaiting a decision
+func (m *InteractiveModel) reviewBlockIndex() int {
+	for i := len(m.blocks) - 1; i >= 0; i-- {
+		if m.blocks[i].Type == BlockEntryDiff && m.blocks[i].Status == StatusPending {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyDiff hands the edit to the editor when one is connected, otherwise writes it to disk
+func (m *InteractiveModel) applyDiff(diff ide.DiffResult) tea.Cmd {
+	return func() tea.Msg {
+		if m.ideServer != nil && m.ideServer.IsConnected() {
+			return DiffAppliedMsg{diff: diff, sent: true, err: m.ideServer.SendDiff(diff)}
+		}
+
+		// extractDiffs only proposes workspace files; check again before writing
+		root := "."
+		if m.ideContext != nil && m.ideContext.WorkspaceRoot != "" {
+			root = m.ideContext.WorkspaceRoot
+		}
+		absRoot, rootErr := filepath.Abs(root)
+		absFile, fileErr := filepath.Abs(diff.File)
+		if rootErr != nil || fileErr != nil || !ide.WithinWorkspace(absRoot, absFile) {
+			return DiffAppliedMsg{diff: diff, err: fmt.Errorf("refusing to write outside the workspace")}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(diff.File), 0755); err != nil {
+			return DiffAppliedMsg{diff: diff, err: err}
+		}
+		return DiffAppliedMsg{diff: diff, err: os.WriteFile(diff.File, []byte(diff.NewContent), 0644)}
+	}
+}
+
+// renderDiffBlock renders a proposed diff with removed lines in red and added lines in green
+func (m *InteractiveModel) renderDiffBlock(block Block) string {
+	borderColor := lipgloss.Color("214") // Orange
+	var footer string
+	switch block.Status {
+	case StatusComplete:
+		borderColor = lipgloss.Color("28")
+		footer = "✓ Applied"
+	case StatusSent:
+		borderColor = lipgloss.Color("39")
+		footer = "→ Sent to editor"
+	case StatusSkipped:
+		borderColor = lipgloss.Color("241")
+		footer = "Skipped"
+	case StatusError:
+		borderColor = lipgloss.Color("196")
+		footer = "✗ Not applied"
+	default:
+		footer = "Apply these changes? (y/n)"
+	}
+
+	addStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("28"))
+	removeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	hunkStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+
+	var lines []string
+	lines = append(lines, lipgloss.NewStyle().Bold(true).Render(block.Content), "")
+
+	if diff, ok := block.Data.(ide.DiffResult); ok {
+		for _, line := range strings.Split(strings.TrimSuffix(diff.Patch, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				lines = append(lines, lipgloss.NewStyle().Bold(true).Render(line))
+			case strings.HasPrefix(line, "@@"):
+				lines = append(lines, hunkStyle.Render(line))
+			case strings.HasPrefix(line, "+"):
+				lines = append(lines, addStyle.Render(line))
+			case strings.HasPrefix(line, "-"):
+				lines = append(lines, removeStyle.Render(line))
+			default:
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	lines = append(lines, "", footer)
+
+	style := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1).
+		Width(m.width - 4)
+
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// submitPrompt starts work on a prompt: the worker picked with /model alone,
+// or planning across all workers once any cost confirmation is given
+func (m *InteractiveModel) submitPrompt(prompt string) tea.Cmd {
+	m.currentPrompt = prompt
+	m.isProcessing = true
+
+	// A model picked with /model answers this prompt alone
+	if m.selectedWorker != "" {
+		workerID := m.selectedWorker
+		m.selectedWorker = ""
+		return m.askSingleWorker(workerID, prompt)
+	}
+
+	// Ask before fan-outs whose worst case is expensive
+	estimate := m.runner.EstimatePlanRun(prompt, m.ideContext)
+	if m.config.Cost.NeedsConfirmation(estimate.MaxCost) {
+		m.pendingRun = prompt
+		m.addCommandMessage(fmt.Sprintf("This run may cost up to $%.4f across %d workers and judges. y: run • n: cancel",
+			estimate.MaxCost, len(estimate.Calls)))
+		return nil
+	}
+
+	// Start processing
+	return m.startPlanning(prompt)
+}
+
 func (m *InteractiveModel) startPlanning(prompt string) tea.Cmd {
 	return tea.Batch(
 		// First step: Analyzing request
@@ -587,7 +952,7 @@ func (m *InteractiveModel) runPlanningProcess() tea.Cmd {
 		}),
 		// Actually generate the plan
 		func() tea.Msg {
-			plan, err := m.runner.GeneratePlan(m.currentPrompt, m.ideContext)
+			plan, err := m.runner.GeneratePlan(context.Background(), m.currentPrompt, m.ideContext)
 			if err != nil {
 				return PlanningCompleteMsg{plan: nil, err: err}
 			}
@@ -618,13 +983,103 @@ func (m *InteractiveModel) executePlan() tea.Cmd {
 	}
 }
 
+// executeStep runs one step of the plan being stepped through
+func (m *InteractiveModel) executeStep(index int) tea.Cmd {
+	plan := m.stepPlan
+	m.addCommandMessage(fmt.Sprintf("Running %s", m.stepHeading(index)))
+	return func() tea.Msg {
+		result, err := m.runner.ExecutePlanStep(plan, index, m.ideContext)
+		return StepCompleteMsg{index: index, result: result, err: err}
+	}
+}
+
+// pauseAtStep waits for the user before the next plan step, or finishes the
+// plan once every step has been run or skipped
+func (m *InteractiveModel) pauseAtStep() {
+	if m.stepIndex >= len(m.stepPlan.Steps) {
+		m.addCommandMessage(fmt.Sprintf("All %d plan steps done", len(m.stepPlan.Steps)))
+		m.finishSteps()
+		return
+	}
+
+	m.stepPaused = true
+	m.addCommandMessage(fmt.Sprintf("Next: %s. y: run it • n: skip it • a: stop here", m.stepHeading(m.stepIndex)))
+}
+
+// finishSteps ends step-by-step execution and hands the input back
+func (m *InteractiveModel) finishSteps() {
+	m.stepPlan = nil
+	m.stepPaused = false
+	m.isProcessing = false
+}
+
+// stepHeading names a plan step as "step 2/5 (title)"
+func (m *InteractiveModel) stepHeading(index int) string {
+	return fmt.Sprintf("step %d/%d (%s)", index+1, len(m.stepPlan.Steps), m.stepPlan.Steps[index].Title)
+}
+
+// waitForJudgeEvent delivers the judge progress events queued by the runner
+func (m *InteractiveModel) waitForJudgeEvent() tea.Cmd {
+	return func() tea.Msg {
+		return JudgeProgressMsg{events: m.judgeEvents.wait()}
+	}
+}
+
+// updateJudgeProgress shows a judge scoring a worker as a step under the
+// current prompt, updated in place when the judge finishes
+func (m *InteractiveModel) updateJudgeProgress(event runner.JudgeEvent) {
+	stepKey := fmt.Sprintf("%s/judge/%s/%s", m.currentUserID, event.JudgeID, event.WorkerID)
+
+	status := StatusWorking
+	content := fmt.Sprintf("Judge %s scoring %s", event.JudgeID, event.WorkerID)
+	switch {
+	case event.Done && event.Err != nil:
+		status = StatusError
+		content = fmt.Sprintf("Judge %s failed to score %s", event.JudgeID, event.WorkerID)
+	case event.Done:
+		status = StatusComplete
+		content = fmt.Sprintf("Judge %s scored %s %d/10", event.JudgeID, event.WorkerID, event.Score)
+	}
+
+	if index, exists := m.processingSteps[stepKey]; exists && index < len(m.blocks) {
+		m.blocks[index].Status = status
+		m.blocks[index].Content = content
+		return
+	}
+
+	m.processingSteps[stepKey] = len(m.blocks)
+	m.addBlockAsChild(Block{
+		ID:        fmt.Sprintf("judge_%d_%d", len(m.blocks), time.Now().UnixNano()),
+		Type:      BlockEntryPlanning,
+		Content:   content,
+		Status:    status,
+		Timestamp: time.Now(),
+		ParentID:  m.currentUserID,
+		StartTime: time.Now(),
+	})
+}
+
+// askSingleWorker sends the prompt straight to one worker, without planning or consensus
+func (m *InteractiveModel) askSingleWorker(workerID, prompt string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := m.runner.RunWorker(context.Background(), workerID, prompt)
+		return RunCompleteMsg{result: result, err: err}
+	}
+}
+
+// pollIDEContext schedules the next IDE context refresh. There's nothing to
+// poll without an IDE server, and the server may still be starting (or have
+// stopped), so the context is only read while it is running.
 func (m *InteractiveModel) pollIDEContext() tea.Cmd {
+	if m.ideServer == nil || m.quitting {
+		return nil
+	}
+	server := m.ideServer
 	return tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg {
-		if m.ideServer != nil {
-			context := m.ideServer.GetContext()
-			return IDEContextUpdateMsg{context: context}
+		if !server.IsRunning() {
+			return IDEContextUpdateMsg{}
 		}
-		return IDEContextUpdateMsg{context: nil}
+		return IDEContextUpdateMsg{context: server.GetContext()}
 	})
 }
 
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/evisdrenova/devgru/internal/ide"
+)
+
+// reviewModel is a model reviewing diffs proposed for files under root
+func reviewModel(root string, diffs ...ide.DiffResult) *InteractiveModel {
+	m := &InteractiveModel{
+		keys:            DefaultGlobalKeyMap(),
+		ideContext:      &ide.IDEContext{WorkspaceRoot: root},
+		processingSteps: make(map[string]int),
+		isProcessing:    true,
+	}
+	m.pendingDiffs = diffs
+	m.showNextDiff()
+	return m
+}
+
+// press sends a key to the model and runs the command it returns, feeding
+// the resulting message back, as the bubbletea loop would
+func press(t *testing.T, m *InteractiveModel, keys string) {
+	t.Helper()
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(keys)})
+	if cmd != nil {
+		m.Update(cmd())
+	}
+}
+
+func TestDiffReviewAppliesAndSkips(t *testing.T) {
+	root := t.TempDir()
+	first := filepath.Join(root, "first.txt")
+	second := filepath.Join(root, "second.txt")
+	m := reviewModel(root,
+		ide.DiffResult{File: first, NewContent: "applied\n", Patch: "+applied\n"},
+		ide.DiffResult{File: second, NewContent: "skipped\n", Patch: "+skipped\n"},
+	)
+
+	press(t, m, "y")
+	if data, err := os.ReadFile(first); err != nil || string(data) != "applied\n" {
+		t.Fatalf("first.txt = %q, %v; want the accepted diff written", data, err)
+	}
+	if len(m.pendingDiffs) != 1 {
+		t.Fatalf("%d diffs pending after accepting one, want 1", len(m.pendingDiffs))
+	}
+
+	press(t, m, "n")
+	if _, err := os.Stat(second); !os.IsNotExist(err) {
+		t.Errorf("second.txt written after it was rejected: %v", err)
+	}
+	if len(m.pendingDiffs) != 0 || m.isProcessing {
+		t.Errorf("review still open: %d diffs pending, processing %v", len(m.pendingDiffs), m.isProcessing)
+	}
+
+	var statuses []StepStatus
+	for _, block := range m.blocks {
+		if block.Type == BlockEntryDiff {
+			statuses = append(statuses, block.Status)
+		}
+	}
+	if len(statuses) != 2 || statuses[0] != StatusComplete || statuses[1] != StatusSkipped {
+		t.Errorf("diff block statuses = %v, want [complete skipped]", statuses)
+	}
+}
+
+func TestDiffReviewRefusesFilesOutsideWorkspace(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	m := reviewModel(root, ide.DiffResult{File: outside, NewContent: "x\n"})
+
+	press(t, m, "y")
+	if _, err := os.Stat(outside); !os.IsNotExist(err) {
+		t.Errorf("wrote a file outside the workspace: %v", err)
+	}
+	if m.blocks[0].Status != StatusError {
+		t.Errorf("status = %s, want error", m.blocks[0].Status)
+	}
+}
+
+func TestDiffSentToEditorIsNotShownAsApplied(t *testing.T) {
+	diff := ide.DiffResult{File: "main.go", NewContent: "package main\n", Patch: "+package main\n"}
+	m := reviewModel(".", diff)
+
+	m.applyingDiff = true
+	m.Update(DiffAppliedMsg{diff: diff, sent: true})
+	if m.blocks[0].Status != StatusSent {
+		t.Fatalf("status = %s, want sent", m.blocks[0].Status)
+	}
+
+	out := m.renderDiffBlock(m.blocks[0])
+	if !strings.Contains(out, "Sent to editor") || strings.Contains(out, "Applied") {
+		t.Errorf("diff block footer doesn't say the diff went to the editor:\n%s", out)
+	}
+}
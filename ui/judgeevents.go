@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// judgeEventQueue hands judge progress from the judging goroutines to the UI.
+// Pushing never blocks, so a busy UI can't stall judging, and no judge's
+// latest state is lost: while the UI is busy, a newer event for the same
+// judge and worker replaces the pending one, so the queue holds at most one
+// event per judge and worker.
+type judgeEventQueue struct {
+	mu      sync.Mutex
+	pending []runner.JudgeEvent // in arrival order of each judge and worker
+
+	ready chan struct{} // holds a token while events may be pending
+}
+
+func newJudgeEventQueue() *judgeEventQueue {
+	return &judgeEventQueue{ready: make(chan struct{}, 1)}
+}
+
+// push queues event, replacing a pending one for the same judge and worker
+func (q *judgeEventQueue) push(event runner.JudgeEvent) {
+	q.mu.Lock()
+	replaced := false
+	for i, pending := range q.pending {
+		if pending.JudgeID == event.JudgeID && pending.WorkerID == event.WorkerID {
+			q.pending[i] = event
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		q.pending = append(q.pending, event)
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// wait blocks until events are queued and takes them all; it may return
+// none when another wait took them first
+func (q *judgeEventQueue) wait() []runner.JudgeEvent {
+	<-q.ready
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	events := q.pending
+	q.pending = nil
+	return events
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+func TestJudgeEventQueueCoalescesPerJudgeAndWorker(t *testing.T) {
+	q := newJudgeEventQueue()
+	q.push(runner.JudgeEvent{JudgeID: "j", WorkerID: "a"})
+	q.push(runner.JudgeEvent{JudgeID: "j", WorkerID: "b"})
+	q.push(runner.JudgeEvent{JudgeID: "j", WorkerID: "a", Done: true, Score: 8})
+
+	events := q.wait()
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want one for each worker", events)
+	}
+	if events[0].WorkerID != "a" || !events[0].Done || events[0].Score != 8 {
+		t.Errorf("first event = %+v, want a's latest", events[0])
+	}
+	if events[1].WorkerID != "b" || events[1].Done {
+		t.Errorf("second event = %+v, want b starting", events[1])
+	}
+}
+
+func TestJudgeEventQueueKeepsEveryFinalState(t *testing.T) {
+	// Far more events than the UI takes at once; none of the judges may block
+	q := newJudgeEventQueue()
+	var wg sync.WaitGroup
+	for judge := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for worker := range 50 {
+				event := runner.JudgeEvent{JudgeID: fmt.Sprint(judge), WorkerID: fmt.Sprint(worker)}
+				q.push(event)
+				event.Done = true
+				q.push(event)
+			}
+		}()
+	}
+	wg.Wait()
+
+	done := make(map[string]bool)
+	for _, event := range q.wait() {
+		done[event.JudgeID+"/"+event.WorkerID] = event.Done
+	}
+	if len(done) != 500 {
+		t.Fatalf("%d judge and worker pairs reported, want 500", len(done))
+	}
+	for pair, finished := range done {
+		if !finished {
+			t.Errorf("%s left as still scoring", pair)
+		}
+	}
+}
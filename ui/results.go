@@ -20,8 +20,10 @@ type ResultsModel struct {
 	width        int
 	height       int
 	keys         KeyMap
-	scrollOffset int // Track vertical scroll position
-	totalHeight  int // Total height of all content
+	scrollOffset int  // Track vertical scroll position
+	totalHeight  int  // Total height of all content
+	followCursor bool // Scroll the selected section into view on the next render
+	raw          bool // Show response text as-is, without borders or wrapping, for copying
 }
 
 // KeyMap defines the key bindings
@@ -30,10 +32,13 @@ type KeyMap struct {
 	Down       key.Binding
 	Expand     key.Binding
 	Collapse   key.Binding
+	Next       key.Binding
+	Prev       key.Binding
 	ScrollUp   key.Binding
 	ScrollDown key.Binding
 	PageUp     key.Binding
 	PageDown   key.Binding
+	Raw        key.Binding
 	Quit       key.Binding
 }
 
@@ -56,6 +61,14 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("c"),
 			key.WithHelp("c", "collapse all"),
 		),
+		Next: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "focus next worker"),
+		),
+		Prev: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "focus previous worker"),
+		),
 		ScrollUp: key.NewBinding(
 			key.WithKeys("shift+up", "K"),
 			key.WithHelp("shift+↑/K", "scroll up"),
@@ -72,6 +85,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("pgdown", "ctrl+d"),
 			key.WithHelp("pgdn/ctrl+d", "page down"),
 		),
+		Raw: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "toggle raw text"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("q", "ctrl+c"),
 			key.WithHelp("q", "quit"),
@@ -116,6 +133,7 @@ func (m *ResultsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.Up):
 			if m.cursor > 0 {
 				m.cursor--
+				m.followCursor = true
 			}
 
 		case key.Matches(msg, m.keys.Down):
@@ -125,6 +143,17 @@ func (m *ResultsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			if m.cursor < maxCursor {
 				m.cursor++
+				m.followCursor = true
+			}
+
+		case key.Matches(msg, m.keys.Next):
+			if count := len(m.result.Workers); count > 0 {
+				m.focusWorker((min(m.cursor, count-1) + 1) % count)
+			}
+
+		case key.Matches(msg, m.keys.Prev):
+			if count := len(m.result.Workers); count > 0 {
+				m.focusWorker((min(m.cursor, count) - 1 + count) % count)
 			}
 
 		case key.Matches(msg, m.keys.Expand):
@@ -133,6 +162,10 @@ func (m *ResultsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.expanded[m.cursor] = !m.expanded[m.cursor]
 			}
 
+		case key.Matches(msg, m.keys.Raw):
+			m.raw = !m.raw
+			m.followCursor = true
+
 		case key.Matches(msg, m.keys.Collapse):
 			// Collapse all worker sections
 			for i := range m.result.Workers {
@@ -170,6 +203,15 @@ func (m *ResultsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// focusWorker selects a worker and expands it alone so it can be read in full
+func (m *ResultsModel) focusWorker(index int) {
+	for i := range m.result.Workers {
+		m.expanded[i] = i == index
+	}
+	m.cursor = index
+	m.followCursor = true
+}
+
 // View implements bubbletea.Model
 func (m *ResultsModel) View() string {
 	if m.width == 0 {
@@ -177,14 +219,21 @@ func (m *ResultsModel) View() string {
 	}
 
 	var sections []string
+	var selectedLine int
 
 	// Header
 	sections = append(sections, m.renderHeader())
 
 	// Worker responses
 	for i, worker := range m.result.Workers {
+		if i == m.cursor {
+			selectedLine = lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, sections...))
+		}
 		sections = append(sections, m.renderWorker(i, worker))
 	}
+	if m.cursor == len(m.result.Workers) {
+		selectedLine = lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, sections...))
+	}
 
 	// Consensus
 	if m.result.Consensus != nil {
@@ -198,6 +247,17 @@ func (m *ResultsModel) View() string {
 	contentLines := strings.Split(content, "\n")
 	m.totalHeight = len(contentLines)
 
+	// Limit to viewport height (leave space for footer)
+	viewportHeight := m.height - 2 // Reserve space for footer
+
+	// Bring the selected section's header into view after navigating
+	if m.followCursor {
+		m.followCursor = false
+		if selectedLine < m.scrollOffset || selectedLine >= m.scrollOffset+viewportHeight {
+			m.scrollOffset = min(selectedLine, max(m.totalHeight-m.height+3, 0))
+		}
+	}
+
 	// Apply scrolling
 	if m.scrollOffset > 0 {
 		if m.scrollOffset >= len(contentLines) {
@@ -207,8 +267,6 @@ func (m *ResultsModel) View() string {
 		}
 	}
 
-	// Limit to viewport height (leave space for footer)
-	viewportHeight := m.height - 2 // Reserve space for footer
 	if len(contentLines) > viewportHeight {
 		contentLines = contentLines[:viewportHeight]
 	}
@@ -232,7 +290,9 @@ func (m *ResultsModel) renderHeader() string {
 		Align(lipgloss.Center)
 
 	successIcon := "✅"
-	if !m.result.Success {
+	if m.result.Consensus != nil && m.result.Consensus.LowConfidence {
+		successIcon = "⚠️"
+	} else if !m.result.Success {
 		successIcon = "❌"
 	}
 
@@ -293,9 +353,18 @@ func (m *ResultsModel) renderWorker(index int, worker runner.WorkerResult) strin
 			worker.Stats.EstimatedCost)
 	}
 
+	if tags, ok := worker.Metadata["tags"].(map[string]string); ok {
+		headerText += " • " + formatTags(tags)
+	}
+
 	// Add average score if available
 	if len(worker.JudgeResults) > 0 {
 		headerText += fmt.Sprintf(" • Score: %.1f/10", worker.AverageScore)
+	} else if worker.Unscored {
+		headerText += " • Unscored (judges failed)"
+	}
+	if worker.Truncated() {
+		headerText += " • ⚠ cut off at max_tokens"
 	}
 
 	header := headerStyle.Width(m.width - 4).Render(headerText)
@@ -305,6 +374,15 @@ func (m *ResultsModel) renderWorker(index int, worker runner.WorkerResult) strin
 		return header
 	}
 
+	// Raw mode prints the response exactly as received so it can be copied
+	if m.raw {
+		content := worker.Content
+		if worker.Error != nil {
+			content = fmt.Sprintf("Error: %v", worker.Error)
+		}
+		return lipgloss.JoinVertical(lipgloss.Left, header, content)
+	}
+
 	// Expanded content
 	contentStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("252")).
@@ -364,6 +442,9 @@ func (m *ResultsModel) renderConsensus() string {
 	content.WriteString(fmt.Sprintf("Algorithm: %s\n", consensus.Algorithm))
 	content.WriteString(fmt.Sprintf("Winner: %s\n", consensus.Winner))
 	content.WriteString(fmt.Sprintf("Confidence: %.2f\n", consensus.Confidence))
+	if consensus.LowConfidence {
+		content.WriteString("Below min_confidence: review before relying on it\n")
+	}
 	content.WriteString(fmt.Sprintf("Participants: %d\n", consensus.Participants))
 
 	if consensus.Reasoning != "" {
@@ -373,7 +454,13 @@ func (m *ResultsModel) renderConsensus() string {
 		content.WriteString("\n")
 	}
 
-	content.WriteString("\nFinal Answer:\n")
+	content.WriteString("\nFinal Answer:")
+
+	// Raw mode leaves the answer outside the styled box so it copies cleanly
+	if m.raw {
+		return lipgloss.JoinVertical(lipgloss.Left, style.Width(m.width-4).Render(content.String()), consensus.Content)
+	}
+	content.WriteString("\n")
 
 	// Word wrap the final answer to prevent horizontal scrolling
 	finalAnswer := consensus.Content
@@ -394,7 +481,7 @@ func (m *ResultsModel) renderFooter() string {
 		Width(m.width - 4)
 
 	// Build help text
-	help := "↑/↓: navigate • enter/space: expand/collapse • c: collapse all"
+	help := "↑/↓: navigate • tab/shift+tab: focus worker • enter/space: expand/collapse • c: collapse all • m: raw text"
 
 	// Add scroll indicators if content is scrollable
 	maxScroll := m.totalHeight - m.height + 3
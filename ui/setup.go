@@ -0,0 +1,265 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/evisdrenova/devgru/internal/config"
+)
+
+// setupStep is a page of the setup wizard
+type setupStep int
+
+const (
+	setupProviders setupStep = iota // choose providers
+	setupModels                     // name a model for each
+	setupLocation                   // choose where the config goes
+)
+
+// providerLabels are the names the setup wizard shows for provider kinds
+var providerLabels = map[string]string{
+	"openai":    "OpenAI",
+	"anthropic": "Anthropic",
+}
+
+// SetupModel is the first-run wizard: it asks which providers to use and
+// which model each runs, then where to save the config. It only collects
+// answers; the caller writes the file with config.Scaffold.
+type SetupModel struct {
+	step      setupStep
+	cursor    int               // highlighted provider or location
+	chosen    map[string]bool   // chosen provider kinds
+	kinds     []string          // chosen kinds, in config.SetupKinds order
+	inputs    []textinput.Model // model name for each of kinds
+	focus     int               // focused model input
+	paths     []string          // locations the config can be saved to
+	message   string            // validation message shown under the page
+	finished  bool
+	cancelled bool
+}
+
+// NewSetupModel creates the wizard. Providers whose API key is set in the
+// environment start out chosen; paths are the locations offered for the
+// config, the first being the default.
+func NewSetupModel(paths []string) SetupModel {
+	chosen := make(map[string]bool)
+	for _, p := range config.DetectedProviders() {
+		chosen[p.Kind] = true
+	}
+	return SetupModel{chosen: chosen, paths: paths}
+}
+
+// Result returns the chosen providers and the path to save the config to;
+// ok is false when the wizard was cancelled
+func (m SetupModel) Result() (providers []config.SetupProvider, path string, ok bool) {
+	if !m.finished || m.cancelled {
+		return nil, "", false
+	}
+	for i, kind := range m.kinds {
+		providers = append(providers, config.SetupProvider{Kind: kind, Model: strings.TrimSpace(m.inputs[i].Value())})
+	}
+	return providers, m.paths[m.cursor], true
+}
+
+func (m SetupModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m SetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if keyMsg.String() == "ctrl+c" {
+		m.cancelled = true
+		return m, tea.Quit
+	}
+	m.message = ""
+
+	switch m.step {
+	case setupProviders:
+		return m.updateProviders(keyMsg)
+	case setupModels:
+		return m.updateModels(keyMsg)
+	default:
+		return m.updateLocation(keyMsg)
+	}
+}
+
+func (m SetupModel) updateProviders(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.cancelled = true
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor = (m.cursor + len(config.SetupKinds) - 1) % len(config.SetupKinds)
+	case "down", "j":
+		m.cursor = (m.cursor + 1) % len(config.SetupKinds)
+	case " ", "x":
+		kind := config.SetupKinds[m.cursor]
+		m.chosen[kind] = !m.chosen[kind]
+	case "enter":
+		var kinds []string
+		for _, kind := range config.SetupKinds {
+			if m.chosen[kind] {
+				kinds = append(kinds, kind)
+			}
+		}
+		if len(kinds) == 0 {
+			m.message = "Choose at least one provider (space to toggle)"
+			return m, nil
+		}
+
+		// Keep the models already typed for providers still chosen
+		typed := make(map[string]string)
+		for i, kind := range m.kinds {
+			typed[kind] = m.inputs[i].Value()
+		}
+		m.kinds = kinds
+		m.inputs = make([]textinput.Model, len(kinds))
+		for i, kind := range kinds {
+			input := textinput.New()
+			input.Prompt = fmt.Sprintf("%-10s ", providerLabels[kind])
+			input.SetValue(config.DefaultSetupModels[kind])
+			if value, ok := typed[kind]; ok {
+				input.SetValue(value)
+			}
+			m.inputs[i] = input
+		}
+		m.focus = 0
+		m.inputs[0].Focus()
+		m.step = setupModels
+	}
+	return m, nil
+}
+
+func (m SetupModel) updateModels(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.cursor = 0
+		m.step = setupProviders
+		return m, nil
+	case "up", "shift+tab":
+		return m.focusInput(m.focus - 1), nil
+	case "down", "tab":
+		return m.focusInput(m.focus + 1), nil
+	case "enter":
+		if strings.TrimSpace(m.inputs[m.focus].Value()) == "" {
+			m.message = "Enter a model name"
+			return m, nil
+		}
+		if m.focus < len(m.inputs)-1 {
+			return m.focusInput(m.focus + 1), nil
+		}
+		for i := range m.inputs {
+			if strings.TrimSpace(m.inputs[i].Value()) == "" {
+				m.message = "Enter a model name"
+				return m.focusInput(i), nil
+			}
+		}
+		m.inputs[m.focus].Blur()
+		m.cursor = 0
+		m.step = setupLocation
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+// focusInput moves the focus to the ith model input, wrapping around
+func (m SetupModel) focusInput(i int) SetupModel {
+	m.inputs[m.focus].Blur()
+	m.focus = (i + len(m.inputs)) % len(m.inputs)
+	m.inputs[m.focus].Focus()
+	return m
+}
+
+func (m SetupModel) updateLocation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.step = setupModels
+		return m.focusInput(0), nil
+	case "up", "k":
+		m.cursor = (m.cursor + len(m.paths) - 1) % len(m.paths)
+	case "down", "j":
+		m.cursor = (m.cursor + 1) % len(m.paths)
+	case "enter":
+		m.finished = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m SetupModel) View() string {
+	if m.finished || m.cancelled {
+		return ""
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("63")).
+		Render("Set up devgru")
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	selected := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+
+	var b strings.Builder
+	b.WriteString(title + "\n\n")
+
+	var help string
+	switch m.step {
+	case setupProviders:
+		b.WriteString("Which providers should your workers use?\n\n")
+		for i, kind := range config.SetupKinds {
+			box := "[ ]"
+			if m.chosen[kind] {
+				box = "[x]"
+			}
+			line := fmt.Sprintf("%s %-10s %s", box, providerLabels[kind], keyStatus(kind))
+			if i == m.cursor {
+				line = selected.Render("> " + line)
+			} else {
+				line = "  " + line
+			}
+			b.WriteString(line + "\n")
+		}
+		help = "↑/↓: move • space: toggle • enter: next • esc: skip setup"
+	case setupModels:
+		b.WriteString("Which model should each provider run?\n\n")
+		for _, input := range m.inputs {
+			b.WriteString("  " + input.View() + "\n")
+		}
+		help = "tab/↑/↓: move • enter: next • esc: back"
+	case setupLocation:
+		b.WriteString("Where should the config be saved?\n\n")
+		for i, path := range m.paths {
+			if i == m.cursor {
+				b.WriteString(selected.Render("> "+path) + "\n")
+			} else {
+				b.WriteString("  " + path + "\n")
+			}
+		}
+		help = "↑/↓: move • enter: save • esc: back"
+	}
+
+	if m.message != "" {
+		b.WriteString("\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(m.message) + "\n")
+	}
+	b.WriteString("\n" + helpStyle.Render(help) + "\n")
+	return b.String()
+}
+
+// keyStatus says whether the API key a provider kind needs is set
+func keyStatus(kind string) string {
+	envVar := config.APIKeyEnvVar(kind)
+	if os.Getenv(envVar) != "" {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("28")).Render(envVar + " found")
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(envVar + " not set")
+}
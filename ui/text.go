@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatTags renders worker tags as key=value pairs sorted by key
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + tags[key]
+	}
+	return strings.Join(pairs, " ")
+}
+
+// truncateRunes shortens s to at most limit runes, appending an ellipsis when
+// anything was cut. It never splits a multi-byte character, and backs up to
+// the last line break or space when one is close enough so words stay whole.
+// A limit of zero or less disables truncation.
+func truncateRunes(s string, limit int) (string, bool) {
+	if limit <= 0 || utf8.RuneCountInString(s) <= limit {
+		return s, false
+	}
+
+	// Walk rune boundaries so the cut always lands between characters
+	count := 0
+	for i := range s {
+		if count == limit {
+			return cutAtBoundary(s[:i]) + "...", true
+		}
+		count++
+	}
+	return s, false
+}
+
+// cutAtBoundary drops a trailing partial word or line from kept text, unless
+// that would throw away more than half of it (e.g. one very long token)
+func cutAtBoundary(kept string) string {
+	boundary := strings.LastIndex(kept, "\n")
+	if boundary < len(kept)/2 {
+		boundary = strings.LastIndexAny(kept, " \t\n")
+	}
+	if boundary < len(kept)/2 {
+		return kept
+	}
+	return strings.TrimRight(kept[:boundary], " \t\n")
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		limit     int
+		want      string
+		truncated bool
+	}{
+		{name: "fits", s: "hello", limit: 10, want: "hello"},
+		{name: "no limit", s: "hello world", limit: 0, want: "hello world"},
+		{name: "ascii at a word", s: "hello world again", limit: 13, want: "hello world...", truncated: true},
+		{name: "cjk", s: "日本語のテキストです", limit: 4, want: "日本語の...", truncated: true},
+		{name: "cjk fits exactly", s: "日本語", limit: 3, want: "日本語"},
+		{name: "cjk at a space", s: "日本語 テキストです", limit: 6, want: "日本語...", truncated: true},
+		{name: "emoji", s: "😀😃😄😁😆", limit: 2, want: "😀😃...", truncated: true},
+		{name: "accents", s: "héllo wörld", limit: 8, want: "héllo...", truncated: true},
+		{name: "mixed", s: "naïve 日本 😀 text", limit: 9, want: "naïve 日本...", truncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncateRunes(tt.s, tt.limit)
+			if got != tt.want || truncated != tt.truncated {
+				t.Errorf("truncateRunes(%q, %d) = %q, %v; want %q, %v", tt.s, tt.limit, got, truncated, tt.want, tt.truncated)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateRunes(%q, %d) split a character: %q", tt.s, tt.limit, got)
+			}
+			if tt.limit > 0 {
+				if width := utf8.RuneCountInString(strings.TrimSuffix(got, "...")); width > tt.limit {
+					t.Errorf("truncateRunes(%q, %d) kept %d runes", tt.s, tt.limit, width)
+				}
+			}
+		})
+	}
+}
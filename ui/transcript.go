@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/evisdrenova/devgru/internal/ide"
+	"github.com/evisdrenova/devgru/internal/output"
+	"github.com/evisdrenova/devgru/internal/runner"
+)
+
+// SetTranscript makes the session write a plain-text transcript to w as it
+// goes: prompts, messages, plans, results (as Markdown reports) and proposed
+// diffs. Progress lines are left out since they only matter while shown.
+func (m *InteractiveModel) SetTranscript(w io.Writer) {
+	m.transcript = w
+}
+
+// writeTranscript adds a block to the transcript, if one is being written.
+// A failed write stops the transcript and is reported in the session.
+func (m *InteractiveModel) writeTranscript(block Block) {
+	if m.transcript == nil {
+		return
+	}
+
+	var b strings.Builder
+	switch block.Type {
+	case BlockEntryUser:
+		fmt.Fprintf(&b, "\n> %s\n\n", block.Content)
+	case BlockEntrySystem:
+		fmt.Fprintf(&b, "%s\n\n", block.Content)
+	case BlockEntryError:
+		fmt.Fprintf(&b, "Error: %s\n\n", block.Content)
+	case BlockEntryPlanning:
+		// Only the finished plan; the other planning blocks are progress
+		if _, ok := block.Data.(*runner.PlanResult); !ok {
+			return
+		}
+		fmt.Fprintf(&b, "%s\n\n", strings.TrimRight(block.Content, "\n"))
+	case BlockEntryResult:
+		result, ok := block.Data.(*runner.RunResult)
+		if !ok {
+			return
+		}
+		if heading, _, found := strings.Cut(block.Content, "\n"); found && strings.HasPrefix(heading, "Result of ") {
+			fmt.Fprintf(&b, "%s\n\n", heading)
+		}
+		if err := output.WriteMarkdown(&b, result, nil); err != nil {
+			return
+		}
+		b.WriteString("\n")
+	case BlockEntryDiff:
+		diff, ok := block.Data.(ide.DiffResult)
+		if !ok {
+			return
+		}
+		fmt.Fprintf(&b, "%s\n\n```diff\n%s```\n\n", block.Content, diff.Patch)
+	default:
+		return
+	}
+
+	if _, err := io.WriteString(m.transcript, b.String()); err != nil {
+		m.transcript = nil
+		m.AddSystemMessage(fmt.Sprintf("Stopped writing the transcript: %v", err))
+	}
+}
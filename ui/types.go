@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"io"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -26,6 +27,9 @@ const (
 	StatusWorking  StepStatus = "working"
 	StatusComplete StepStatus = "complete"
 	StatusError    StepStatus = "error"
+	StatusPending  StepStatus = "pending"
+	StatusSkipped  StepStatus = "skipped"
+	StatusSent     StepStatus = "sent" // handed to the editor, which applies it
 )
 
 // Use the runner types instead of duplicating
@@ -38,15 +42,18 @@ const (
 	PlanStepDelete = runner.PlanStepDelete
 )
 
+// BlockEntryType is the kind of a Block; renderBlock switches on it, so a new
+// kind needs a constant here and a case there
 type BlockEntryType string
 
 const (
-	BlockEntryUser       BlockEntryType = "user"
-	BlockEntrySystem     BlockEntryType = "system"
-	BlockEntryPlanning   BlockEntryType = "planning"
-	BlockEntryResult     BlockEntryType = "result"
-	BlockEntryError      BlockEntryType = "error"
-	BlockEntryProcessing BlockEntryType = "processing"
+	BlockEntryUser     BlockEntryType = "user"
+	BlockEntrySystem   BlockEntryType = "system"
+	BlockEntryPlanning BlockEntryType = "planning"
+	BlockEntryResult   BlockEntryType = "result"
+	BlockEntryError    BlockEntryType = "error"
+	BlockEntryDiff     BlockEntryType = "diff"
+	BlockEntryExplain  BlockEntryType = "explain"
 )
 
 type PlanningStepMsg struct {
@@ -55,6 +62,11 @@ type PlanningStepMsg struct {
 	Status      StepStatus `json:"status"`
 }
 
+// JudgeProgressMsg carries judges starting or finishing their evaluation of workers
+type JudgeProgressMsg struct {
+	events []runner.JudgeEvent
+}
+
 type PlanningCompleteMsg struct {
 	plan *runner.PlanResult
 	err  error
@@ -76,6 +88,19 @@ type RunCompleteMsg struct {
 	err    error
 }
 
+// StepCompleteMsg carries the result of one plan step run with planning.step_by_step
+type StepCompleteMsg struct {
+	index  int
+	result *runner.RunResult
+	err    error
+}
+
+type DiffAppliedMsg struct {
+	diff ide.DiffResult
+	sent bool // handed to the editor rather than written to disk
+	err  error
+}
+
 type IDEContextUpdateMsg struct {
 	context *ide.IDEContext
 }
@@ -97,13 +122,6 @@ type Block struct {
 	Duration  time.Duration
 }
 
-type BlockEntry struct {
-	Type      BlockEntryType
-	Content   string
-	Timestamp time.Time
-	Data      interface{}
-}
-
 type InteractiveModel struct {
 	width  int
 	height int
@@ -123,6 +141,22 @@ type InteractiveModel struct {
 
 	ideContext *ide.IDEContext
 
+	pendingDiffs []ide.DiffResult
+	applyingDiff bool // the first pending diff is being applied; y/n wait for DiffAppliedMsg
+
+	selectedWorker string // worker that answers the next prompt alone, set with /model
+	pendingRun     string // prompt waiting for the user to confirm its estimated cost
+
+	stepPlan   *runner.PlanResult // plan being executed one step at a time
+	stepIndex  int                // next step of stepPlan to run
+	stepPaused bool               // waiting for the user to run, skip or stop at the next step
+
+	quitting bool // set once the user quits so polling stops rescheduling
+
+	transcript io.Writer // receives a plain-text copy of the session, set with SetTranscript
+
+	judgeEvents *judgeEventQueue // judge progress forwarded from the runner
+
 	keys            GlobalKeyMap
 	lastTimerUpdate time.Time
 }
@@ -133,4 +167,7 @@ type GlobalKeyMap struct {
 	Quit   key.Binding
 	Up     key.Binding
 	Down   key.Binding
+	Accept key.Binding
+	Reject key.Binding
+	Abort  key.Binding
 }
@@ -97,11 +97,10 @@ export function activate(context: vscode.ExtensionContext) {
 class DevGruClient implements vscode.Disposable {
   private ws: WebSocket | null = null;
   private reconnectTimer: NodeJS.Timeout | null = null;
+  private serverShutDown = false; // DevGru said goodbye; reconnect slowly
   private lastSelectionTime = 0;
   private currentPort: number = 8123;
   private readonly HANDSHAKE_MESSAGE = "###DEVGRU_VSCODE_HANDSHAKE###";
-  private readonly DIFF_START_MARKER = "<<<DEVGRU_DIFF_START>>>";
-  private readonly DIFF_END_MARKER = "<<<DEVGRU_DIFF_END>>>";
 
   constructor() {
     this.tryConnect();
@@ -135,7 +134,11 @@ class DevGruClient implements vscode.Disposable {
     if (this.ws && this.ws.readyState === WebSocket.OPEN) return;
 
     try {
-      const ws = new WebSocket(`ws://127.0.0.1:${this.currentPort}/ws`);
+      const settings = vscode.workspace.getConfiguration("devgru");
+      const host = settings.get<string>("serverHost", "127.0.0.1");
+      const token = settings.get<string>("authToken", "");
+      const query = token ? `?token=${encodeURIComponent(token)}` : "";
+      const ws = new WebSocket(`ws://${host}:${this.currentPort}/ws${query}`);
       this.ws = ws;
 
       ws.on("open", () => {
@@ -156,6 +159,7 @@ class DevGruClient implements vscode.Disposable {
           this.sendCurrentActiveFile();
         }, 1000);
 
+        this.serverShutDown = false;
         if (this.reconnectTimer) clearTimeout(this.reconnectTimer);
       });
 
@@ -190,10 +194,13 @@ class DevGruClient implements vscode.Disposable {
       return; // Already scheduled
     }
 
+    // Retry every 5 seconds, or every 30 after DevGru shut down; a new
+    // server's handshake in the terminal reconnects sooner
+    const delay = this.serverShutDown ? 30000 : 5000;
     this.reconnectTimer = setTimeout(() => {
       this.reconnectTimer = null;
       this.tryConnect();
-    }, 5000); // Retry every 5 seconds
+    }, delay);
   }
 
   private sendMessage(message: DevGruMessage): void {
@@ -377,14 +384,6 @@ class DevGruClient implements vscode.Disposable {
       );
       setTimeout(() => this.tryConnect(), 500);
     }
-
-    // Handle diff markers
-    if (data.includes(this.DIFF_START_MARKER)) {
-      // TODO: Capture diff content and show in VS Code diff viewer
-      vscode.window.showInformationMessage(
-        "DevGru generated a diff - check terminal for details"
-      );
-    }
   }
 
   private handleServerMessage(message: DevGruMessage): void {
@@ -395,6 +394,10 @@ class DevGruClient implements vscode.Disposable {
       case "status":
         vscode.window.showInformationMessage(`DevGru: ${message.data.message}`);
         break;
+      case "shutdown":
+        console.log("DevGru server is shutting down");
+        this.serverShutDown = true;
+        break;
       default:
         console.log("Unknown message type from DevGru:", message.type);
     }